@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+
+	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/internal/service"
+	"github.com/loog-project/loog/internal/util"
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+// cluster is one recorded cluster: a kubeconfig context and the mux
+// watching it.
+type cluster struct {
+	// name tags the cluster's objects in the capture (see store.ObjectID).
+	// It is empty when a single cluster is recorded.
+	name     string
+	context  string
+	mux      *mux.Mux
+	resolver *resourceResolver
+	// unwatched holds the kinds removed in the TUI; the collector skips
+	// their events still queued in the mux.
+	unwatched *kindSet
+	// owners decides which objects are recorded with --follow-owners; nil
+	// otherwise.
+	owners *ownerTracker
+	// events routes the Events recorded with --record-events; nil
+	// otherwise.
+	events *eventTracker
+	// versions tells how far the collector got in each watch, for the
+	// bookmarks of the capture.
+	versions *versionTracker
+}
+
+// unwatchKind stops the watches of a kind removed in the TUI: the one of
+// its own GVR, if known, and any other watched GVR of that kind (e.g. from
+// a resource argument or a CRD). Events still queued for it are skipped
+// from now on. The group of kinds only seen in events isn't known; such a
+// kind is unwatched in every group it is watched in.
+func (c cluster) unwatchKind(rk resource.Kind) {
+	anyGroup := rk.APIVersion == ""
+	group := schema.FromAPIVersionAndKind(rk.APIVersion, rk.Kind).Group
+	if !anyGroup {
+		c.unwatched.add(schema.GroupKind{Group: group, Kind: rk.Kind})
+	}
+
+	var targets []schema.GroupVersionResource
+	if rk.Resource != "" {
+		if gvr, err := util.ParseGroupVersionResource(rk.GVR()); err == nil {
+			targets = append(targets, gvr)
+		}
+	}
+	for _, gvr := range c.mux.GVRs() {
+		if anyGroup || gvr.Group == group {
+			if kind, err := c.resolver.kindFor(gvr); err == nil && kind == rk.Kind && !slices.Contains(targets, gvr) {
+				targets = append(targets, gvr)
+			}
+		}
+	}
+	for _, gvr := range targets {
+		c.unwatched.add(schema.GroupKind{Group: gvr.Group, Kind: rk.Kind})
+		if c.mux.Remove(gvr) {
+			log.Info().Str("cluster", c.name).Str("kind", rk.Kind).Str("gvr", gvr.String()).Msg("Removed dynamic watch")
+		}
+	}
+}
+
+// kindSet is a set of kinds, by group, safe for concurrent use. A nil set is
+// empty.
+type kindSet struct {
+	mu    sync.RWMutex
+	kinds map[schema.GroupKind]bool
+}
+
+func newKindSet() *kindSet {
+	return &kindSet{kinds: make(map[schema.GroupKind]bool)}
+}
+
+func (s *kindSet) add(gk schema.GroupKind) {
+	s.mu.Lock()
+	s.kinds[gk] = true
+	s.mu.Unlock()
+}
+
+func (s *kindSet) remove(gk schema.GroupKind) {
+	s.mu.Lock()
+	delete(s.kinds, gk)
+	s.mu.Unlock()
+}
+
+func (s *kindSet) has(gk schema.GroupKind) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.kinds[gk]
+}
+
+// clusterContexts returns the kubeconfig contexts to record; "" stands for
+// the current context.
+func clusterContexts() []string {
+	if len(kubeContexts) == 0 {
+		return []string{""}
+	}
+	return kubeContexts
+}
+
+// clusterName returns the name objects of [kctx] are tagged with: the
+// context itself when several are recorded, else empty so single-cluster
+// captures keep their plain object IDs.
+func clusterName(kctx string) string {
+	if len(kubeContexts) < 2 {
+		return ""
+	}
+	return kctx
+}
+
+// primaryContext is the context asked where only one cluster can be, like
+// shell completion.
+func primaryContext() string {
+	return clusterContexts()[0]
+}
+
+// validateContexts checks the --context flags.
+func validateContexts(contexts []string) error {
+	seen := make(map[string]bool, len(contexts))
+	for _, c := range contexts {
+		if c == "" && len(contexts) > 1 {
+			return fmt.Errorf("--context must name a context when given more than once")
+		}
+		if seen[c] {
+			return fmt.Errorf("--context %q is given twice", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// setupCluster connects to the cluster of [c]'s kubeconfig context and
+// creates its mux, watching the resource arguments there. When appending to
+// a capture, the watches are resumed from [resume] and the deletions they
+// missed are recorded with [trackerService]. The returned cleanups must run
+// even when an error is returned.
+func setupCluster(
+	ctx context.Context, c *cluster, args []string, resume *resumeState, trackerService *service.TrackerService,
+) (cleanups []func(), err error) {
+	kctx, name := c.context, c.name
+	setupLog.Info().Str("context", kctx).Msg("Preparing dynamic Kubernetes watch client...")
+	cfg, err := restConfigForKubeconfig(kubeConfigPath, kctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dynamic watch client: %w", err)
+	}
+	meta, err := metadata.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating metadata watch client: %w", err)
+	}
+
+	muxOpts := []mux.Option{mux.WithMetadataClient(meta)}
+	var scopes *scopeLookup
+	if len(namespaces) > 0 {
+		muxOpts = append(muxOpts, mux.WithNamespaces(namespaces...))
+		disc, discErr := discovery.NewDiscoveryClientForConfig(cfg)
+		if discErr != nil {
+			return nil, fmt.Errorf("error creating discovery client: %w", discErr)
+		}
+		scopes = newScopeLookup(disc)
+	}
+	m, err := mux.New(ctx, dyn, muxOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dynamic mux: %w", err)
+	}
+	c.mux = m
+	cleanups = append(cleanups, func() {
+		logDroppedEvents(m, name)
+		m.Stop()
+	})
+
+	// Each cluster resolves short names and wildcards with its own discovery.
+	resolver := newClusterResolver(cfg, kubeConfigPath, kctx)
+	c.resolver = resolver
+	resolved, err := resolver.resolve(args)
+	if err != nil {
+		return cleanups, err
+	}
+	watched := make(map[schema.GroupVersionResource]util.ResourceArg, len(resolved))
+	var roots []schema.GroupKind
+	for _, ra := range resolved {
+		gvr := ra.GVR
+		// The mux keeps one watch per GVR; a second argument can't change it.
+		if prev, dup := watched[gvr]; dup {
+			if prev != ra {
+				return cleanups, fmt.Errorf("GVR '%s' is given twice with different options", gvr)
+			}
+			continue
+		}
+		watched[gvr] = ra
+		if followOwners {
+			kind, kindErr := resolver.kindFor(gvr)
+			if kindErr != nil {
+				return cleanups, fmt.Errorf("cannot find the kind of GVR '%s': %w", gvr, kindErr)
+			}
+			roots = append(roots, schema.GroupKind{Group: gvr.Group, Kind: kind})
+		}
+
+		addOpts := []mux.AddOption{mux.LabelSelector(ra.LabelSelector), mux.FieldSelector(ra.FieldSelector)}
+		if scopes != nil {
+			clusterScoped, scopeErr := scopes.clusterScoped(gvr)
+			if scopeErr != nil {
+				return cleanups, fmt.Errorf("cannot add GVR '%s' to dynamic mux: %w", gvr, scopeErr)
+			}
+			if clusterScoped {
+				addOpts = append(addOpts, mux.ClusterScoped())
+			}
+		}
+		if ra.MetadataOnly {
+			// The metadata API doesn't tell the kind of the objects.
+			kind, kindErr := resolver.kindFor(gvr)
+			if kindErr != nil {
+				return cleanups, fmt.Errorf("cannot find the kind of GVR '%s': %w", gvr, kindErr)
+			}
+			addOpts = append(addOpts, mux.MetadataOnly(kind))
+		}
+		addOpts = append(addOpts, resume.options(name, gvr)...)
+		if muxAddErr := m.Add(gvr, addOpts...); muxAddErr != nil {
+			return cleanups, fmt.Errorf("cannot add GVR '%s' to dynamic mux: %w", gvr, muxAddErr)
+		}
+	}
+
+	if recordEvents {
+		if _, dup := watched[eventsGVR]; dup {
+			return cleanups, fmt.Errorf("--record-events cannot be combined with watching events as a resource")
+		}
+		if muxAddErr := m.Add(eventsGVR, resume.options(name, eventsGVR)...); muxAddErr != nil {
+			return cleanups, fmt.Errorf("cannot add GVR '%s' to dynamic mux: %w", eventsGVR, muxAddErr)
+		}
+		setupLog.Info().Str("context", kctx).Msg("Recording events of the recorded objects")
+		c.events = newEventTracker()
+	}
+
+	if followOwners {
+		followed := followedResources(roots)
+		for _, owned := range followed {
+			if muxAddErr := m.Add(owned.gvr, resume.options(name, owned.gvr)...); muxAddErr != nil {
+				return cleanups, fmt.Errorf("cannot add owned GVR '%s' to dynamic mux: %w", owned.gvr, muxAddErr)
+			}
+			setupLog.Info().Str("context", kctx).Str("gvr", owned.gvr.String()).Msg("Following owned resources")
+		}
+		c.owners = newOwnerTracker(followed)
+	}
+
+	for _, gvr := range m.GVRs() {
+		resume.reconcile(ctx, *c, gvr, trackerService)
+	}
+
+	if len(watchCRDs) > 0 {
+		crdCtx, crdCancel := context.WithCancel(ctx)
+		cleanups = append(cleanups, crdCancel)
+		crds := newCRDWatcher(m, watchCRDs)
+		crds.options = func(gvr schema.GroupVersionResource) []mux.AddOption { return resume.options(name, gvr) }
+		crds.synced = func(gvr schema.GroupVersionResource) { resume.reconcile(crdCtx, *c, gvr, trackerService) }
+		if crdErr := crds.start(crdCtx, dyn); crdErr != nil {
+			return cleanups, crdErr
+		}
+		setupLog.Info().Strs("patterns", watchCRDs).Msg("Watching CRDs for matching groups...")
+	}
+	return cleanups, nil
+}
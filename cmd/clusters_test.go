@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+func TestCluster_UnwatchKind(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{pods: "PodList", deployments: "DeploymentList"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := mux.New(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	for _, gvr := range []schema.GroupVersionResource{pods, deployments} {
+		if err := m.Add(gvr); err != nil {
+			t.Fatalf("Add %s: %v", gvr, err)
+		}
+	}
+	c := cluster{mux: m, resolver: testResolver(), unwatched: newKindSet()}
+
+	// Only the kind name is known for kinds seen in events; the watched
+	// GVRs are matched through discovery.
+	c.unwatchKind(resource.Kind{Kind: "Pod"})
+	if m.Has(pods) || !m.Has(deployments) {
+		t.Fatalf("watching %v after unwatching Pod", m.GVRs())
+	}
+	if !c.unwatched.has(schema.GroupKind{Kind: "Pod"}) || c.unwatched.has(schema.GroupKind{Group: "apps", Kind: "Deployment"}) {
+		t.Error("queued Pod events must be skipped, and only those")
+	}
+
+	// A kind of the same name in another group is left alone.
+	c.unwatchKind(resource.Kind{Kind: "Deployment", APIVersion: "example.com/v1", Resource: "deployments"})
+	if !m.Has(deployments) || c.unwatched.has(schema.GroupKind{Group: "apps", Kind: "Deployment"}) {
+		t.Fatal("unwatching example.com Deployments unwatched apps Deployments")
+	}
+
+	c.unwatchKind(resource.Kind{Kind: "Deployment", APIVersion: "apps/v1", Resource: "deployments"})
+	if m.Len() != 0 {
+		t.Fatalf("watching %v after unwatching Deployment", m.GVRs())
+	}
+	if !c.unwatched.has(schema.GroupKind{Group: "apps", Kind: "Deployment"}) {
+		t.Error("queued apps Deployment events must be skipped")
+	}
+}
@@ -22,10 +22,13 @@ var (
 	gvrsOnce   sync.Once
 )
 
-// loadClusterGVRs loads the GroupVersionResources (GVRs) from the Kubernetes cluster
-func loadClusterGVRs(kubeConfigPath, kubeContext string) ([]string, error) {
-	const cacheTTL = 60 * time.Second
+// discoveryCacheTTL is how long discovery results cached in the temp
+// directory are reused.
+const discoveryCacheTTL = 60 * time.Second
 
+// discoveryCachePath returns the temp file caching the discovery result
+// [name] for a kubeconfig and context.
+func discoveryCachePath(name, kubeConfigPath, kubeContext string) string {
 	cacheKey := strings.ReplaceAll(kubeConfigPath, string(os.PathSeparator), "_")
 	if cacheKey == "" {
 		if env := os.Getenv("KUBECONFIG"); env != "" {
@@ -38,14 +41,38 @@ func loadClusterGVRs(kubeConfigPath, kubeContext string) ([]string, error) {
 		// Different contexts point at different clusters; scope the cache.
 		cacheKey += "@" + kubeContext
 	}
-	cachePath := filepath.Join(os.TempDir(), "loog_complete_"+cacheKey+".json")
-	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < cacheTTL {
-		if data, err := os.ReadFile(cachePath); err == nil {
-			var cached []string
-			if json.Unmarshal(data, &cached) == nil {
-				return cached, nil
-			}
-		}
+	return filepath.Join(os.TempDir(), "loog_"+name+"_"+cacheKey+".json")
+}
+
+// readDiscoveryCache decodes the cache file at path into v, unless it is
+// missing or older than discoveryCacheTTL.
+func readDiscoveryCache(path string, v any) bool {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= discoveryCacheTTL {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+// writeDiscoveryCache stores v in the cache file at path.
+func writeDiscoveryCache(path string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		setupLog.Error().Err(err).Msg("failed to marshal discovery result for caching")
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		setupLog.Error().Err(err).Msg("failed to write discovery cache file")
+	}
+}
+
+// loadClusterGVRs loads the GroupVersionResources (GVRs) from the Kubernetes cluster
+func loadClusterGVRs(kubeConfigPath, kubeContext string) ([]string, error) {
+	cachePath := discoveryCachePath("complete", kubeConfigPath, kubeContext)
+	var cached []string
+	if readDiscoveryCache(cachePath, &cached) {
+		return cached, nil
 	}
 
 	cfg, err := restConfigForKubeconfig(kubeConfigPath, kubeContext)
@@ -88,25 +115,14 @@ func loadClusterGVRs(kubeConfigPath, kubeContext string) ([]string, error) {
 	})
 
 	// cache the GVRs to a file
-	func() {
-		data, err := json.Marshal(gvrList)
-		if err != nil {
-			setupLog.Error().Err(err).Msg("failed to marshal GVRs for caching")
-			return
-		}
-		err = os.WriteFile(cachePath, data, 0o644)
-		if err != nil {
-			setupLog.Error().Err(err).Msg("failed to write GVRs to cache file")
-			return
-		}
-	}()
+	writeDiscoveryCache(cachePath, gvrList)
 
 	return gvrList, nil
 }
 
 func gvrCompletion(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 	gvrsOnce.Do(func() {
-		if s, err := loadClusterGVRs(kubeConfigPath, kubeContext); err == nil {
+		if s, err := loadClusterGVRs(kubeConfigPath, primaryContext()); err == nil {
 			cachedGVRs = s
 		}
 	})
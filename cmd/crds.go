@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+var crdGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// watchMux is the part of *mux.Mux the CRD watcher drives.
+type watchMux interface {
+	Add(gvr schema.GroupVersionResource, opts ...mux.AddOption) error
+	Remove(gvr schema.GroupVersionResource) bool
+	Has(gvr schema.GroupVersionResource) bool
+}
+
+// crdRemoveRetry is how often the removal of a watch whose Add is still
+// starting is retried.
+const crdRemoveRetry = 10 * time.Millisecond
+
+// crdWatcher watches CustomResourceDefinitions and keeps a mux watch on the
+// custom resources of every established CRD whose group matches one of its
+// patterns, from the moment the CRD is installed until it is deleted. Only
+// the watches it added itself are removed, not those of resource arguments.
+type crdWatcher struct {
+	patterns []string
+	mux      watchMux
+	// options, if set, returns further options of the watch of a GVR, and
+	// synced is called once it synced; both used to resume on --append.
+	options func(gvr schema.GroupVersionResource) []mux.AddOption
+	synced  func(gvr schema.GroupVersionResource)
+
+	mu sync.Mutex
+	// watched maps a CRD name to the GVR it is watched as.
+	watched map[string]schema.GroupVersionResource
+	// added holds the GVRs the watcher added, each with a channel closed
+	// once their Add returned.
+	added map[schema.GroupVersionResource]chan struct{}
+}
+
+func newCRDWatcher(m watchMux, patterns []string) *crdWatcher {
+	return &crdWatcher{
+		patterns: patterns,
+		mux:      m,
+		watched:  make(map[string]schema.GroupVersionResource),
+		added:    make(map[schema.GroupVersionResource]chan struct{}),
+	}
+}
+
+// validateCRDPatterns checks the --watch-crds group patterns.
+func validateCRDPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid --watch-crds pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// start runs the CRD informer until ctx is done. It doesn't wait for the
+// initial list; matching CRDs are added as they are seen. The informer calls
+// the handlers one at a time, so watches start and stop in order.
+func (w *crdWatcher) start(ctx context.Context, client dynamic.Interface) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+	inf := factory.ForResource(crdGVR).Informer()
+	if _, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.onUpsert,
+		UpdateFunc: func(_, obj any) { w.onUpsert(obj) },
+		DeleteFunc: w.onDelete,
+	}); err != nil {
+		return fmt.Errorf("register CRD event handler: %w", err)
+	}
+	factory.Start(ctx.Done())
+	return nil
+}
+
+func (w *crdWatcher) onUpsert(obj any) {
+	crd, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	gvr, clusterScoped, ok := crdWatchTarget(crd, w.patterns)
+
+	w.mu.Lock()
+	prev, had := w.watched[crd.GetName()]
+	switch {
+	case ok && had && prev == gvr:
+		w.mu.Unlock()
+		return
+	case ok:
+		w.watched[crd.GetName()] = gvr
+	default:
+		delete(w.watched, crd.GetName())
+	}
+	w.mu.Unlock()
+
+	// The served version changed, or the CRD stopped matching.
+	if had && prev != gvr && w.remove(prev) {
+		log.Info().Str("crd", crd.GetName()).Str("gvr", prev.String()).Msg("Stopped watching custom resources")
+	}
+	if ok {
+		w.add(crd.GetName(), gvr, clusterScoped)
+	}
+}
+
+// add starts watching [gvr] for a CRD, unless it is watched already, e.g.
+// as a resource argument.
+func (w *crdWatcher) add(crdName string, gvr schema.GroupVersionResource, clusterScoped bool) {
+	if w.mux.Has(gvr) {
+		return
+	}
+	var opts []mux.AddOption
+	if clusterScoped {
+		opts = append(opts, mux.ClusterScoped())
+	}
+	if w.options != nil {
+		opts = append(opts, w.options(gvr)...)
+	}
+	done := make(chan struct{})
+	w.mu.Lock()
+	w.added[gvr] = done
+	w.mu.Unlock()
+	// Add blocks until the informer has synced; don't hold up the CRD
+	// informer meanwhile.
+	go func() {
+		defer close(done)
+		if err := w.mux.Add(gvr, opts...); err != nil {
+			log.Error().Err(err).Str("crd", crdName).Msg("Cannot watch custom resources")
+			return
+		}
+		log.Info().Str("crd", crdName).Str("gvr", gvr.String()).Msg("Watching custom resources")
+		if w.synced != nil {
+			w.synced(gvr)
+		}
+	}()
+}
+
+// remove stops watching [gvr] if the watcher added it, and reports whether
+// it did. An Add still starting is waited for, so its watch can't outlive
+// the CRD.
+func (w *crdWatcher) remove(gvr schema.GroupVersionResource) bool {
+	w.mu.Lock()
+	done, ok := w.added[gvr]
+	delete(w.added, gvr)
+	w.mu.Unlock()
+	if !ok {
+		return false
+	}
+	for !w.mux.Remove(gvr) {
+		select {
+		case <-done:
+			// Failed, or just synced.
+			w.mux.Remove(gvr)
+			return true
+		case <-time.After(crdRemoveRetry):
+		}
+	}
+	return true
+}
+
+func (w *crdWatcher) onDelete(obj any) {
+	if tomb, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tomb.Obj
+	}
+	crd, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	gvr, had := w.watched[crd.GetName()]
+	delete(w.watched, crd.GetName())
+	w.mu.Unlock()
+	if had && w.remove(gvr) {
+		log.Info().Str("crd", crd.GetName()).Str("gvr", gvr.String()).Msg("CRD deleted, stopped watching")
+	}
+}
+
+// crdWatchTarget returns the GVR to watch for a CRD: its storage version if
+// served, else its first served version. ok is false when the group matches
+// none of [patterns], the CRD isn't established yet or serves no version.
+func crdWatchTarget(crd *unstructured.Unstructured, patterns []string) (gvr schema.GroupVersionResource, clusterScoped, ok bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	if !matchesAny(group, patterns) || !crdEstablished(crd) {
+		return gvr, false, false
+	}
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	version := ""
+	for _, v := range versions {
+		vm, _ := v.(map[string]any)
+		name, _ := vm["name"].(string)
+		served, _ := vm["served"].(bool)
+		storage, _ := vm["storage"].(bool)
+		if !served || name == "" {
+			continue
+		}
+		if version == "" || storage {
+			version = name
+		}
+	}
+	if plural == "" || version == "" {
+		return gvr, false, false
+	}
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: plural}, scope == "Cluster", true
+}
+
+// crdEstablished reports whether the API server serves the CRD yet.
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conds, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conds {
+		cm, _ := c.(map[string]any)
+		if cm["type"] == "Established" {
+			return cm["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+func matchesAny(group string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, group); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+func testCRD(name, group, scope string, established bool, versions ...map[string]any) *unstructured.Unstructured {
+	vs := make([]any, len(versions))
+	for i, v := range versions {
+		vs[i] = v
+	}
+	status := "False"
+	if established {
+		status = "True"
+	}
+	return &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": name},
+		"spec": map[string]any{
+			"group":    group,
+			"scope":    scope,
+			"names":    map[string]any{"plural": "widgets"},
+			"versions": vs,
+		},
+		"status": map[string]any{"conditions": []any{
+			map[string]any{"type": "Established", "status": status},
+		}},
+	}}
+}
+
+func version(name string, served, storage bool) map[string]any {
+	return map[string]any{"name": name, "served": served, "storage": storage}
+}
+
+func TestCRDWatchTarget(t *testing.T) {
+	patterns := []string{"*.example.com"}
+
+	gvr, cluster, ok := crdWatchTarget(testCRD("widgets.apps.example.com", "apps.example.com", "Namespaced", true,
+		version("v1alpha1", true, false), version("v1beta1", true, true), version("v1", false, false)), patterns)
+	want := schema.GroupVersionResource{Group: "apps.example.com", Version: "v1beta1", Resource: "widgets"}
+	if !ok || cluster || gvr != want {
+		t.Fatalf("got %v cluster=%v ok=%v, want %v", gvr, cluster, ok, want)
+	}
+
+	// The storage version isn't served: take the first served one.
+	gvr, cluster, ok = crdWatchTarget(testCRD("w", "example.com.example.com", "Cluster", true,
+		version("v2", false, true), version("v1", true, false)), patterns)
+	if !ok || !cluster || gvr.Version != "v1" {
+		t.Fatalf("got %v cluster=%v ok=%v", gvr, cluster, ok)
+	}
+
+	for name, crd := range map[string]*unstructured.Unstructured{
+		"other group":     testCRD("w", "example.org", "Namespaced", true, version("v1", true, true)),
+		"not established": testCRD("w", "a.example.com", "Namespaced", false, version("v1", true, true)),
+		"nothing served":  testCRD("w", "a.example.com", "Namespaced", true, version("v1", false, true)),
+	} {
+		if _, _, ok := crdWatchTarget(crd, patterns); ok {
+			t.Errorf("%s: should not be watched", name)
+		}
+	}
+}
+
+// recordingMux records the watches a crdWatcher starts and stops. Adds wait
+// for [register], when set, before taking effect.
+type recordingMux struct {
+	added    chan schema.GroupVersionResource
+	removed  chan schema.GroupVersionResource
+	register chan struct{}
+
+	mu      sync.Mutex
+	watched map[schema.GroupVersionResource]bool
+	options map[schema.GroupVersionResource]int
+}
+
+func newRecordingMux() *recordingMux {
+	return &recordingMux{
+		added:   make(chan schema.GroupVersionResource, 4),
+		removed: make(chan schema.GroupVersionResource, 4),
+		watched: make(map[schema.GroupVersionResource]bool),
+		options: make(map[schema.GroupVersionResource]int),
+	}
+}
+
+func (r *recordingMux) Add(gvr schema.GroupVersionResource, opts ...mux.AddOption) error {
+	if r.register != nil {
+		<-r.register
+	}
+	r.mu.Lock()
+	r.watched[gvr] = true
+	r.options[gvr] = len(opts)
+	r.mu.Unlock()
+	r.added <- gvr
+	return nil
+}
+
+func (r *recordingMux) Remove(gvr schema.GroupVersionResource) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.watched[gvr] {
+		return false
+	}
+	delete(r.watched, gvr)
+	r.removed <- gvr
+	return true
+}
+
+func (r *recordingMux) Has(gvr schema.GroupVersionResource) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.watched[gvr]
+}
+
+func TestCRDWatcher_AddsAndRemoves(t *testing.T) {
+	rm := newRecordingMux()
+	w := newCRDWatcher(rm, []string{"*.example.com"})
+	expect := func(ch chan schema.GroupVersionResource, version string) {
+		t.Helper()
+		select {
+		case gvr := <-ch:
+			if gvr.Version != version {
+				t.Fatalf("got %v, want version %s", gvr, version)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Not established yet: nothing to watch.
+	w.onUpsert(testCRD("widgets.a.example.com", "a.example.com", "Namespaced", false, version("v1", true, true)))
+	w.onUpsert(testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true, version("v1", true, true)))
+	expect(rm.added, "v1")
+	// Unchanged updates don't add again.
+	w.onUpsert(testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true, version("v1", true, true)))
+
+	// A new storage version moves the watch.
+	w.onUpsert(testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true,
+		version("v1", true, false), version("v2", true, true)))
+	expect(rm.removed, "v1")
+	expect(rm.added, "v2")
+
+	w.onDelete(cache.DeletedFinalStateUnknown{Obj: testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true)})
+	expect(rm.removed, "v2")
+
+	select {
+	case gvr := <-rm.added:
+		t.Fatalf("unexpected Add(%v)", gvr)
+	case gvr := <-rm.removed:
+		t.Fatalf("unexpected Remove(%v)", gvr)
+	default:
+	}
+}
+
+func TestCRDWatcher_RemoveWaitsForAdd(t *testing.T) {
+	rm := newRecordingMux()
+	rm.register = make(chan struct{})
+	w := newCRDWatcher(rm, []string{"*.example.com"})
+	crd := testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true, version("v1", true, true))
+
+	// Deleted before its Add took effect: the watch must not outlive it.
+	w.onUpsert(crd)
+	time.AfterFunc(50*time.Millisecond, func() { close(rm.register) })
+	w.onDelete(crd)
+	if gvr := <-rm.added; rm.Has(gvr) {
+		t.Errorf("still watching %v after the CRD was deleted", gvr)
+	}
+}
+
+func TestCRDWatcher_KeepsResourceArguments(t *testing.T) {
+	rm := newRecordingMux()
+	w := newCRDWatcher(rm, []string{"*.example.com"})
+	gvr := schema.GroupVersionResource{Group: "a.example.com", Version: "v1", Resource: "widgets"}
+	_ = rm.Add(gvr) // given as a resource argument
+	<-rm.added
+
+	crd := testCRD("widgets.a.example.com", "a.example.com", "Namespaced", true, version("v1", true, true))
+	w.onUpsert(crd)
+	w.onDelete(crd)
+	if !rm.Has(gvr) {
+		t.Error("removed the watch of a resource argument")
+	}
+}
+
+func TestCRDWatcher_Resumes(t *testing.T) {
+	rm := newRecordingMux()
+	w := newCRDWatcher(rm, []string{"*.example.com"})
+	w.options = func(schema.GroupVersionResource) []mux.AddOption { return []mux.AddOption{mux.ResumeFrom("5")} }
+	synced := make(chan schema.GroupVersionResource, 1)
+	w.synced = func(gvr schema.GroupVersionResource) { synced <- gvr }
+
+	w.onUpsert(testCRD("widgets.a.example.com", "a.example.com", "Cluster", true, version("v1", true, true)))
+	select {
+	case gvr := <-synced:
+		rm.mu.Lock()
+		defer rm.mu.Unlock()
+		if rm.options[gvr] != 2 {
+			t.Errorf("added with %d options, want cluster-scoped and resumed", rm.options[gvr])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("synced not called")
+	}
+}
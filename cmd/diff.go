@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/loog-project/loog/pkg/diffpreview"
+)
+
+var diffContext int
+
+var diffCmd = &cobra.Command{
+	Use:   "diff FILE UID [FROM] TO",
+	Short: "Print the change between two revisions as a unified YAML diff",
+	Long: `Print the change between two revisions of an object in a .loog capture as a
+plain unified diff of their YAML, for scripts and for pasting into tickets.
+List items are matched the same way as in the TUI. Revisions are the hex IDs
+shown in the TUI; FROM defaults to the revision before TO.
+
+  loog diff capture.loog <uid> 0003 0007 -U 5`,
+	Args: cobra.RangeArgs(3, 4),
+	// The merge keys of the config match list items of CRDs, as in the TUI.
+	PreRunE: func(*cobra.Command, []string) error { return registerMergeKeys() },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := renderDiff(cmd.Context(), args[0], args[1], args[2:], diffContext)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(cmd.OutOrStdout(), out)
+		return err
+	},
+}
+
+func init() {
+	diffCmd.Flags().IntVarP(&diffContext, "unified", "U", 3,
+		"Number of unchanged lines to show around each change")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// renderDiff restores both revisions of uid from the capture at path and
+// returns the unified diff between them, or "" if they are equal.
+func renderDiff(ctx context.Context, path, uid string, revArgs []string, contextLines int) (string, error) {
+	if contextLines < 0 {
+		return "", fmt.Errorf("--unified must not be negative, got %d", contextLines)
+	}
+	from, to, err := restoreRevisionPair(ctx, path, uid, revArgs)
+	if err != nil {
+		return "", err
+	}
+	return diffpreview.RenderUnified(diffpreview.Diff(from.Object, to.Object), diffpreview.UnifiedOptions{
+		Context:   contextLines,
+		FromLabel: fmt.Sprintf("%s@%s", uid, from.ID),
+		ToLabel:   fmt.Sprintf("%s@%s", uid, to.ID),
+	}), nil
+}
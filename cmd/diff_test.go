@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/loog-project/loog/internal/service"
+	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
+)
+
+func TestRenderDiff(t *testing.T) {
+	ctx := context.Background()
+	path := writeReplicasCapture(t)
+
+	out, err := renderDiff(ctx, path, "u1", []string{"0", "2"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `--- u1@0000
++++ u1@0002
+@@ -2 +2 @@
+-  resourceVersion: 1
++  resourceVersion: 5
+@@ -5 +5 @@
+-  replicas: 1
++  replicas: 5
+`
+	if out != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	if out, err := renderDiff(ctx, path, "u1", []string{"1", "1"}, 3); err != nil || out != "" {
+		t.Fatalf("diff of a revision with itself = %q, %v; want empty", out, err)
+	}
+	if _, err := renderDiff(ctx, path, "u1", []string{"1"}, -1); err == nil {
+		t.Fatal("negative context should fail")
+	}
+}
+
+// List items of CRDs are matched by the merge keys of the config, like in
+// the TUI.
+func TestDiffCmd_MergeKeys(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "capture.loog")
+	st, err := bboltStore.New(path, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewTrackerService(st, 8, true)
+	for _, backends := range [][]any{
+		{map[string]any{"id": "a", "weight": int64(1)}, map[string]any{"id": "b", "weight": int64(1)}},
+		{map[string]any{"id": "b", "weight": int64(1)}, map[string]any{"id": "a", "weight": int64(2)}},
+	} {
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"uid": "u1"},
+			"spec":     map[string]any{"backends": backends},
+		}}
+		if _, err := svc.Commit(ctx, "u1", obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = svc.Close()
+	_ = st.Close()
+
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader("merge-keys:\n  - path: spec.backends\n    key: id\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := diffCmd.PreRunE(diffCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	out, err := renderDiff(ctx, path, "u1", []string{"0", "1"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `--- u1@0000
++++ u1@0001
+@@ -6 +6 @@
+-      weight: 1
++      weight: 2
+`
+	if out != want {
+		t.Fatalf("only the weight of backend a should change, got:\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/loog-project/loog/internal/service"
+	"github.com/loog-project/loog/internal/store"
+)
+
+// eventsGVR is the resource watched with --record-events.
+var eventsGVR = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// eventTracker routes, with --record-events, the core/v1 Events of one
+// cluster to the objects they are about. An Event is recorded as an
+// annotation of its involved object once that object is recorded; it is
+// never recorded as a resource of its own.
+//
+// Events of objects not recorded yet wait for them, since the watches of
+// Events and of their objects deliver independently. Deleted Events stop
+// waiting, so objects that are never recorded keep no more Events than the
+// cluster does.
+type eventTracker struct {
+	mu sync.Mutex
+	// waiting holds the latest state of the waiting Events by the UID of
+	// their involved object, then by their own UID.
+	waiting map[types.UID]map[types.UID]*unstructured.Unstructured
+}
+
+func newEventTracker() *eventTracker {
+	return &eventTracker{waiting: make(map[types.UID]map[types.UID]*unstructured.Unstructured)}
+}
+
+// isEvent reports whether [obj] is an Event to record as an annotation. A
+// nil tracker records no Events.
+func (t *eventTracker) isEvent(obj *unstructured.Unstructured) bool {
+	return t != nil && obj.GroupVersionKind().GroupKind() == schema.GroupKind{Kind: "Event"}
+}
+
+// admit reports whether [event] is to be recorded now, which [recorded]
+// tells from whether its involved object is. Otherwise the event waits for
+// that object, unless it was [deleted].
+func (t *eventTracker) admit(event *unstructured.Unstructured, deleted bool, recorded func(involved types.UID) bool) bool {
+	involved := involvedUID(event)
+	if involved == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	uid := event.GetUID()
+	if deleted {
+		t.unwait(involved, uid)
+		return false
+	}
+	// Checked under the lock: an object recorded meanwhile releases its
+	// events only after this one waits.
+	if recorded(involved) {
+		t.unwait(involved, uid)
+		return true
+	}
+	if t.waiting[involved] == nil {
+		t.waiting[involved] = make(map[types.UID]*unstructured.Unstructured)
+	}
+	t.waiting[involved][uid] = event
+	return false
+}
+
+// release returns and forgets the events waiting for the object [uid]. A
+// nil tracker has none.
+func (t *eventTracker) release(uid types.UID) []*unstructured.Unstructured {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []*unstructured.Unstructured
+	for _, event := range t.waiting[uid] {
+		out = append(out, event)
+	}
+	delete(t.waiting, uid)
+	return out
+}
+
+func (t *eventTracker) unwait(involved, uid types.UID) {
+	delete(t.waiting[involved], uid)
+	if len(t.waiting[involved]) == 0 {
+		delete(t.waiting, involved)
+	}
+}
+
+// involvedUID returns the UID of the object an Event is about.
+func involvedUID(event *unstructured.Unstructured) types.UID {
+	uid, _, _ := unstructured.NestedString(event.Object, "involvedObject", "uid")
+	return types.UID(uid)
+}
+
+// eventAnnotation converts an Event into the annotation recorded for its
+// involved object. Each occurrence of a repeated Event is an annotation of
+// its own, at the time it was last seen.
+func eventAnnotation(event *unstructured.Unstructured) store.Annotation {
+	str := func(fields ...string) string {
+		s, _, _ := unstructured.NestedString(event.Object, fields...)
+		return s
+	}
+	count, _, _ := unstructured.NestedInt64(event.Object, "count")
+	if seriesCount, ok, _ := unstructured.NestedInt64(event.Object, "series", "count"); ok && seriesCount > count {
+		count = seriesCount
+	}
+	a := store.Annotation{
+		ID:      string(event.GetUID()) + "/" + strconv.FormatInt(count, 10),
+		Type:    str("type"),
+		Reason:  str("reason"),
+		Message: str("message"),
+		Count:   int(count),
+		Source:  str("source", "component"),
+	}
+	if a.Source == "" {
+		a.Source = str("reportingComponent")
+	}
+	for _, field := range [][]string{{"series", "lastObservedTime"}, {"lastTimestamp"}, {"eventTime"}, {"firstTimestamp"}} {
+		if t, err := time.Parse(time.RFC3339Nano, str(field...)); err == nil {
+			a.Time = t
+			break
+		}
+	}
+	if a.Time.IsZero() {
+		a.Time = event.GetCreationTimestamp().Time
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	return a
+}
+
+// record commits an object of the cluster: an Event as an annotation of
+// its involved object, anything else as a revision followed by the Events
+// waiting for it and, when [deleted], a tombstone. It reports whether
+// anything was written.
+func (c cluster) record(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	deleted bool,
+	trackerService *service.TrackerService,
+	rps store.ResourcePatchStore,
+	handler revisionHandler,
+) bool {
+	if !c.events.isEvent(obj) {
+		written := commitObject(ctx, c.name, obj, trackerService, rps, handler)
+		if deleted {
+			written = deleteObject(ctx, c.name, obj, trackerService, rps, handler) || written
+		}
+		for _, event := range c.events.release(obj.GetUID()) {
+			recordEvent(ctx, c.name, event, rps, handler)
+		}
+		return written
+	}
+	admitted := c.events.admit(obj, deleted, func(involved types.UID) bool {
+		_, err := rps.GetLatestRevision(ctx, store.ObjectID(c.name, string(involved)))
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Error().Err(err).Str("cluster", c.name).Msg("Cannot look up the object of an event")
+		}
+		return err == nil
+	})
+	return admitted && recordEvent(ctx, c.name, obj, rps, handler)
+}
+
+// recordEvent writes an Event as an annotation of its involved object, if
+// the store keeps annotations, and hands it to the handler.
+func recordEvent(
+	ctx context.Context,
+	clusterName string,
+	event *unstructured.Unstructured,
+	rps store.ResourcePatchStore,
+	handler revisionHandler,
+) bool {
+	objectID := store.ObjectID(clusterName, string(involvedUID(event)))
+	a := eventAnnotation(event)
+	if as, ok := rps.(store.AnnotationStore); ok {
+		if err := as.AddAnnotation(ctx, objectID, &a); err != nil {
+			log.Error().Err(err).Str("cluster", clusterName).Str("event", event.GetName()).
+				Msg("Cannot record the event")
+			return false
+		}
+	}
+	handler.HandleAnnotation(objectID, a)
+	return true
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func coreEvent(uid, involved string, count int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata":   map[string]any{"name": uid, "uid": uid},
+		"involvedObject": map[string]any{
+			"kind": "Pod",
+			"uid":  involved,
+		},
+		"type":          "Warning",
+		"reason":        "BackOff",
+		"message":       "Back-off restarting failed container",
+		"count":         count,
+		"source":        map[string]any{"component": "kubelet"},
+		"lastTimestamp": "2026-10-18T12:00:00Z",
+	}}
+}
+
+func TestEventTracker(t *testing.T) {
+	tr := newEventTracker()
+	recorded := map[types.UID]bool{"pod-1": true}
+	isRecorded := func(uid types.UID) bool { return recorded[uid] }
+
+	if !tr.isEvent(coreEvent("e1", "pod-1", 1)) || tr.isEvent(pausedPod("pod-1", "1")) {
+		t.Fatal("isEvent misclassified")
+	}
+	if !tr.admit(coreEvent("e1", "pod-1", 1), false, isRecorded) {
+		t.Error("event of a recorded object not admitted")
+	}
+
+	// Events of objects not recorded yet wait for them.
+	if tr.admit(coreEvent("e2", "pod-2", 1), false, isRecorded) ||
+		tr.admit(coreEvent("e2", "pod-2", 2), false, isRecorded) ||
+		tr.admit(coreEvent("e3", "pod-2", 1), false, isRecorded) {
+		t.Fatal("event of an unrecorded object admitted")
+	}
+	// A deleted event stops waiting.
+	tr.admit(coreEvent("e3", "pod-2", 1), true, isRecorded)
+
+	released := tr.release("pod-2")
+	if len(released) != 1 || released[0].GetUID() != "e2" || eventAnnotation(released[0]).Count != 2 {
+		t.Fatalf("released %v, want the latest state of e2", uids(released))
+	}
+	if got := tr.release("pod-2"); got != nil {
+		t.Errorf("released %v again", uids(got))
+	}
+
+	var nilTracker *eventTracker
+	if nilTracker.isEvent(coreEvent("e1", "pod-1", 1)) || nilTracker.release("pod-1") != nil {
+		t.Error("nil tracker records events")
+	}
+}
+
+func TestEventAnnotation(t *testing.T) {
+	a := eventAnnotation(coreEvent("e1", "pod-1", 3))
+	want := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if a.ID != "e1/3" || !a.Time.Equal(want) || a.Type != "Warning" || a.Reason != "BackOff" ||
+		a.Count != 3 || a.Source != "kubelet" || a.Message == "" {
+		t.Errorf("annotation %+v", a)
+	}
+
+	// Each occurrence is an annotation of its own.
+	if next := eventAnnotation(coreEvent("e1", "pod-1", 4)); next.ID == a.ID {
+		t.Errorf("occurrences share the ID %q", a.ID)
+	}
+}
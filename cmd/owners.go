@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ownedResource is a resource whose objects are owned by objects of another
+// kind.
+type ownedResource struct {
+	gvr  schema.GroupVersionResource
+	kind schema.GroupKind
+}
+
+var (
+	ownedReplicaSets         = ownedResource{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}}
+	ownedPods                = ownedResource{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, schema.GroupKind{Kind: "Pod"}}
+	ownedControllerRevisions = ownedResource{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "controllerrevisions"}, schema.GroupKind{Group: "apps", Kind: "ControllerRevision"}}
+	ownedJobs                = ownedResource{schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, schema.GroupKind{Group: "batch", Kind: "Job"}}
+	ownedEndpointSlices      = ownedResource{schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}, schema.GroupKind{Group: "discovery.k8s.io", Kind: "EndpointSlice"}}
+)
+
+// ownedResources lists, by owner kind, the resources the built-in
+// controllers create objects of.
+var ownedResources = map[schema.GroupKind][]ownedResource{
+	{Group: "apps", Kind: "Deployment"}:  {ownedReplicaSets},
+	{Group: "apps", Kind: "ReplicaSet"}:  {ownedPods},
+	{Group: "apps", Kind: "StatefulSet"}: {ownedPods, ownedControllerRevisions},
+	{Group: "apps", Kind: "DaemonSet"}:   {ownedPods, ownedControllerRevisions},
+	{Group: "batch", Kind: "CronJob"}:    {ownedJobs},
+	{Group: "batch", Kind: "Job"}:        {ownedPods},
+	{Kind: "Service"}:                    {ownedEndpointSlices},
+}
+
+// followedResources returns the resources owned, directly or through other
+// owned objects, by objects of the [roots] kinds, without those of a root
+// kind themselves.
+func followedResources(roots []schema.GroupKind) []ownedResource {
+	seen := make(map[schema.GroupKind]bool, len(roots))
+	for _, gk := range roots {
+		seen[gk] = true
+	}
+	var out []ownedResource
+	queue := roots
+	for len(queue) > 0 {
+		gk := queue[0]
+		queue = queue[1:]
+		for _, owned := range ownedResources[gk] {
+			if seen[owned.kind] {
+				continue
+			}
+			seen[owned.kind] = true
+			out = append(out, owned)
+			queue = append(queue, owned.kind)
+		}
+	}
+	return out
+}
+
+// ownerTracker decides, with --follow-owners, which objects of one cluster
+// are recorded. Objects of the followed kinds are only recorded when their
+// ownerReferences lead back to a tracked object; other objects are recorded
+// when they match the filter and become tracked roots. An object stays
+// tracked until it is deleted.
+//
+// Followed objects seen before any of their owners is tracked wait, as
+// their latest state, for an owner to become tracked, since the watches of
+// owners and children deliver independently. Waiting objects are shared
+// with the informer caches, which hold them anyway.
+type ownerTracker struct {
+	followed map[schema.GroupKind]bool
+
+	mu      sync.Mutex
+	tracked map[types.UID]bool
+	waiting map[types.UID]*unstructured.Unstructured
+	// byOwner indexes the waiting objects by the UIDs of their owners.
+	byOwner map[types.UID]map[types.UID]bool
+}
+
+// newOwnerTracker follows the objects of the [followed] resources.
+func newOwnerTracker(followed []ownedResource) *ownerTracker {
+	t := &ownerTracker{
+		followed: make(map[schema.GroupKind]bool, len(followed)),
+		tracked:  make(map[types.UID]bool),
+		waiting:  make(map[types.UID]*unstructured.Unstructured),
+		byOwner:  make(map[types.UID]map[types.UID]bool),
+	}
+	for _, owned := range followed {
+		t.followed[owned.kind] = true
+	}
+	return t
+}
+
+// admit returns the objects to record for an event of [obj]: none, obj
+// itself, or obj followed by the waiting descendants it makes tracked.
+// [pass] tells whether obj matches the filter, which only matters for roots.
+// A nil tracker records what passes the filter.
+func (t *ownerTracker) admit(obj *unstructured.Unstructured, deleted, pass bool) []*unstructured.Unstructured {
+	if t == nil {
+		if pass {
+			return []*unstructured.Unstructured{obj}
+		}
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	uid := obj.GetUID()
+	if !t.followed[obj.GroupVersionKind().GroupKind()] {
+		if !pass {
+			return nil
+		}
+		return t.track(obj, deleted)
+	}
+	if t.tracked[uid] || t.ownedByTracked(obj) {
+		return t.track(obj, deleted)
+	}
+
+	t.unwait(uid)
+	if !deleted && len(obj.GetOwnerReferences()) > 0 {
+		t.waiting[uid] = obj
+		for _, ref := range obj.GetOwnerReferences() {
+			if t.byOwner[ref.UID] == nil {
+				t.byOwner[ref.UID] = make(map[types.UID]bool)
+			}
+			t.byOwner[ref.UID][uid] = true
+		}
+	}
+	return nil
+}
+
+func (t *ownerTracker) ownedByTracked(obj *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if t.tracked[ref.UID] {
+			return true
+		}
+	}
+	return false
+}
+
+// track marks obj as tracked, or forgets it once deleted, and returns it
+// with the waiting descendants it releases, owners first.
+func (t *ownerTracker) track(obj *unstructured.Unstructured, deleted bool) []*unstructured.Unstructured {
+	uid := obj.GetUID()
+	t.unwait(uid)
+	out := []*unstructured.Unstructured{obj}
+	if deleted {
+		delete(t.tracked, uid)
+		return out
+	}
+	if t.tracked[uid] {
+		return out
+	}
+	t.tracked[uid] = true
+
+	queue := []types.UID{uid}
+	for len(queue) > 0 {
+		owner := queue[0]
+		queue = queue[1:]
+		for child := range t.byOwner[owner] {
+			waiting := t.waiting[child]
+			t.unwait(child)
+			t.tracked[child] = true
+			out = append(out, waiting)
+			queue = append(queue, child)
+		}
+	}
+	return out
+}
+
+// unwait removes an object from the waiting ones, if it is.
+func (t *ownerTracker) unwait(uid types.UID) {
+	obj, ok := t.waiting[uid]
+	if !ok {
+		return
+	}
+	delete(t.waiting, uid)
+	for _, ref := range obj.GetOwnerReferences() {
+		delete(t.byOwner[ref.UID], uid)
+		if len(t.byOwner[ref.UID]) == 0 {
+			delete(t.byOwner, ref.UID)
+		}
+	}
+}
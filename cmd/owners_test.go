@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func ownedObject(apiVersion, kind, uid string, owners ...string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(uid)
+	obj.SetUID(types.UID(uid))
+	var refs []metav1.OwnerReference
+	for _, o := range owners {
+		refs = append(refs, metav1.OwnerReference{UID: types.UID(o), Name: o})
+	}
+	obj.SetOwnerReferences(refs)
+	return obj
+}
+
+func uids(objs []*unstructured.Unstructured) []string {
+	var out []string
+	for _, o := range objs {
+		out = append(out, string(o.GetUID()))
+	}
+	return out
+}
+
+func TestFollowedResources(t *testing.T) {
+	got := followedResources([]schema.GroupKind{{Group: "apps", Kind: "Deployment"}, {Group: "batch", Kind: "Job"}})
+	var kinds []string
+	for _, owned := range got {
+		kinds = append(kinds, owned.kind.Kind)
+	}
+	if want := []string{"ReplicaSet", "Pod"}; !slices.Equal(kinds, want) {
+		t.Errorf("followed %v, want %v", kinds, want)
+	}
+
+	// Kinds given as roots aren't followed.
+	got = followedResources([]schema.GroupKind{{Group: "apps", Kind: "ReplicaSet"}, {Kind: "Pod"}})
+	if len(got) != 0 {
+		t.Errorf("followed %+v, want nothing", got)
+	}
+}
+
+func TestOwnerTracker(t *testing.T) {
+	tr := newOwnerTracker(followedResources([]schema.GroupKind{{Group: "apps", Kind: "Deployment"}}))
+
+	// Children seen before their owners wait for them.
+	if got := tr.admit(ownedObject("v1", "Pod", "pod-1", "rs-1"), false, true); got != nil {
+		t.Fatalf("orphan pod admitted: %v", uids(got))
+	}
+	if got := tr.admit(ownedObject("apps/v1", "ReplicaSet", "rs-1", "deploy-1"), false, true); got != nil {
+		t.Fatalf("orphan replica set admitted: %v", uids(got))
+	}
+	// A deployment outside the filter isn't tracked.
+	if got := tr.admit(ownedObject("apps/v1", "Deployment", "deploy-1"), false, false); got != nil {
+		t.Fatalf("filtered deployment admitted: %v", uids(got))
+	}
+
+	got := tr.admit(ownedObject("apps/v1", "Deployment", "deploy-1"), false, true)
+	if want := []string{"deploy-1", "rs-1", "pod-1"}; !slices.Equal(uids(got), want) {
+		t.Fatalf("admitted %v, want %v", uids(got), want)
+	}
+
+	// New children are recorded regardless of the filter; others are not.
+	if got := tr.admit(ownedObject("v1", "Pod", "pod-2", "rs-1"), false, false); !slices.Equal(uids(got), []string{"pod-2"}) {
+		t.Errorf("owned pod: admitted %v", uids(got))
+	}
+	if got := tr.admit(ownedObject("v1", "Pod", "pod-3", "rs-other"), false, true); got != nil {
+		t.Errorf("pod of another replica set admitted: %v", uids(got))
+	}
+	if got := tr.admit(ownedObject("v1", "Pod", "pod-4"), false, true); got != nil {
+		t.Errorf("unowned pod admitted: %v", uids(got))
+	}
+
+	// A deleted waiting child doesn't come back with its owner.
+	tr.admit(ownedObject("v1", "Pod", "pod-5", "rs-2"), false, true)
+	tr.admit(ownedObject("v1", "Pod", "pod-5", "rs-2"), true, true)
+	got = tr.admit(ownedObject("apps/v1", "ReplicaSet", "rs-2", "deploy-1"), false, true)
+	if !slices.Equal(uids(got), []string{"rs-2"}) {
+		t.Errorf("admitted %v, want only rs-2", uids(got))
+	}
+	if len(tr.waiting) != 1 || len(tr.byOwner) != 1 {
+		t.Errorf("still waiting: %v (by owner %v), want only pod-3", tr.waiting, tr.byOwner)
+	}
+
+	// Tracked objects are recorded until deleted.
+	if got := tr.admit(ownedObject("v1", "Pod", "pod-1", "rs-1"), true, true); !slices.Equal(uids(got), []string{"pod-1"}) {
+		t.Errorf("deleted pod: admitted %v", uids(got))
+	}
+	if tr.tracked["pod-1"] {
+		t.Error("deleted pod still tracked")
+	}
+}
+
+func TestOwnerTracker_Nil(t *testing.T) {
+	var tr *ownerTracker
+	obj := ownedObject("v1", "Pod", "pod-1")
+	if got := tr.admit(obj, false, true); len(got) != 1 || got[0] != obj {
+		t.Errorf("admitted %v, want the object", uids(got))
+	}
+	if got := tr.admit(obj, false, false); got != nil {
+		t.Errorf("admitted %v outside the filter", uids(got))
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/internal/service"
+	"github.com/loog-project/loog/internal/store"
+	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+var (
+	patchType    string
+	patchReverse bool
+)
+
+var patchCmd = &cobra.Command{
+	Use:   "patch FILE UID [FROM] TO",
+	Short: "Print the change between two revisions as a JSON Patch or merge patch",
+	Long: `Print the change between two revisions of an object in a .loog capture as an
+RFC 6902 JSON Patch or an RFC 7386 JSON Merge Patch, so it can be reapplied or
+reverted with "kubectl patch --type json|merge". Revisions are the hex IDs shown
+in the TUI; FROM defaults to the revision before TO.
+
+  loog patch capture.loog <uid> 0003 0007 > change.json
+  kubectl patch deploy web --type json --patch-file change.json`,
+	Args: cobra.RangeArgs(3, 4),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := renderPatch(cmd.Context(), args[0], args[1], args[2:], patchType, patchReverse)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return err
+	},
+}
+
+func init() {
+	patchCmd.Flags().StringVarP(&patchType, "type", "t", "json",
+		"Patch format: json (RFC 6902) or merge (RFC 7386)")
+	patchCmd.Flags().BoolVar(&patchReverse, "reverse", false,
+		"Print the patch that reverts the change (TO back to FROM)")
+	rootCmd.AddCommand(patchCmd)
+}
+
+// renderPatch restores both revisions of uid from the capture at path and
+// returns the indented patch between them, leaving out the status and the
+// metadata the API server maintains.
+func renderPatch(ctx context.Context, path, uid string, revArgs []string, typ string, reverse bool) ([]byte, error) {
+	if typ != "json" && typ != "merge" {
+		return nil, fmt.Errorf("--type must be json or merge, got %q", typ)
+	}
+	from, to, err := restoreRevisionPair(ctx, path, uid, revArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	a, b := resource.PatchableObject(from.Object), resource.PatchableObject(to.Object)
+	if reverse {
+		a, b = b, a
+	}
+	if typ == "merge" {
+		return json.MarshalIndent(diffmap.MergePatch(a, b), "", "  ")
+	}
+	return json.MarshalIndent(diffmap.JSONPatch(a, b), "", "  ")
+}
+
+// restoreRevisionPair parses the [FROM] TO revision arguments shared by the
+// patch and diff commands and restores both revisions of uid from the
+// capture at path. FROM defaults to the revision before TO.
+func restoreRevisionPair(ctx context.Context, path, uid string, revArgs []string) (from, to *store.Snapshot, err error) {
+	revs := make([]store.RevisionID, len(revArgs))
+	for i, s := range revArgs {
+		id, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid revision %q: %w", s, err)
+		}
+		revs[i] = store.RevisionID(id)
+	}
+	toID := revs[len(revs)-1]
+	fromID := toID - 1
+	if len(revs) == 2 {
+		fromID = revs[0]
+	} else if toID == 0 {
+		return nil, nil, fmt.Errorf("revision %s has no previous revision; pass FROM explicitly", toID)
+	}
+
+	rps, err := bboltStore.NewWithOptions(path, bboltStore.Options{ReadOnly: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening capture: %w", err)
+	}
+	defer func() { _ = rps.Close() }()
+	trackerService := service.NewTrackerService(rps, snapshotInterval, false)
+	defer func() { _ = trackerService.Close() }()
+
+	from, err = trackerService.Restore(ctx, uid, fromID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("restoring %s@%s: %w", uid, fromID, err)
+	}
+	to, err = trackerService.Restore(ctx, uid, toID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("restoring %s@%s: %w", uid, toID, err)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/loog-project/loog/internal/service"
+	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
+)
+
+// writeReplicasCapture records three revisions of object u1 with
+// spec.replicas 1, 3 and 5 and returns the capture's path.
+func writeReplicasCapture(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "capture.loog")
+
+	st, err := bboltStore.New(path, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewTrackerService(st, 8, true)
+	for _, replicas := range []int64{1, 3, 5} {
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"uid": "u1", "resourceVersion": replicas},
+			"spec":     map[string]any{"replicas": replicas},
+		}}
+		if _, err := svc.Commit(ctx, "u1", obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = svc.Close()
+	_ = st.Close()
+	return path
+}
+
+func TestRenderPatch(t *testing.T) {
+	ctx := context.Background()
+	path := writeReplicasCapture(t)
+
+	out, err := renderPatch(ctx, path, "u1", []string{"0002"}, "merge", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merge map[string]any
+	_ = json.Unmarshal(out, &merge)
+	// The resourceVersion would make the patch a precondition that fails
+	// against the live object.
+	want := map[string]any{
+		"spec": map[string]any{"replicas": 5.0},
+	}
+	if !reflect.DeepEqual(merge, want) {
+		t.Fatalf("merge patch = %s", out)
+	}
+
+	out, err = renderPatch(ctx, path, "u1", []string{"0", "2"}, "json", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []map[string]any
+	_ = json.Unmarshal(out, &ops)
+	if len(ops) != 1 || ops[0]["path"] != "/spec/replicas" || ops[0]["value"] != 1.0 {
+		t.Fatalf("reverse JSON patch = %s", out)
+	}
+
+	if _, err := renderPatch(ctx, path, "u1", []string{"0"}, "json", false); err == nil {
+		t.Fatal("revision 0 without FROM should fail")
+	}
+	if _, err := renderPatch(ctx, path, "u1", []string{"1"}, "strategic", false); err == nil {
+		t.Fatal("unknown --type should fail")
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/loog-project/loog/internal/store"
+)
+
+// pauseGate holds back the collectors while recording is paused in the TUI.
+// Events received meanwhile are discarded or, with catch-up, coalesced to
+// the latest state of each object, which is committed on resume. Every
+// pause is recorded as a gap of the capture.
+type pauseGate struct {
+	catchUp bool
+
+	mu     sync.Mutex
+	paused bool
+	start  time.Time
+	events int // received while paused
+	// latest holds the last state of each object seen while paused, by
+	// object ID; only with catch-up.
+	latest map[string]pausedObject
+}
+
+// pausedObject is the latest state of an object seen while paused, and
+// whether it was deleted meanwhile.
+type pausedObject struct {
+	cluster string
+	obj     *unstructured.Unstructured
+	deleted bool
+}
+
+// commitFunc commits the state of an object of a cluster, and its deletion
+// when [deleted], and reports whether a revision was written.
+type commitFunc func(cluster string, obj *unstructured.Unstructured, deleted bool) bool
+
+func newPauseGate(catchUp bool) *pauseGate {
+	return &pauseGate{catchUp: catchUp}
+}
+
+// hold reports whether [obj] of [cluster], [deleted] or not, must not be
+// committed now because recording is paused. A nil gate never holds.
+func (g *pauseGate) hold(cluster string, obj *unstructured.Unstructured, deleted bool) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return false
+	}
+	g.events++
+	if g.catchUp {
+		id := store.ObjectID(cluster, string(obj.GetUID()))
+		// A deletion sticks, even when the final state arrives again later.
+		deleted = deleted || g.latest[id].deleted
+		g.latest[id] = pausedObject{cluster: cluster, obj: obj, deleted: deleted}
+	}
+	return true
+}
+
+// pause starts holding back events at [now]; it does nothing when recording
+// is already paused.
+func (g *pauseGate) pause(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return
+	}
+	g.paused = true
+	g.start = now
+	g.events = 0
+	g.latest = make(map[string]pausedObject)
+}
+
+// resume ends the pause at [now] and returns its gap; ok is false when
+// recording wasn't paused. With catch-up and a non-nil [commit], the latest
+// state of every object changed while paused is committed first. Events
+// arriving meanwhile are still coalesced and committed in turn, so the
+// collectors only resume once nothing is left and no newer state can
+// overtake a caught-up one.
+func (g *pauseGate) resume(now time.Time, commit commitFunc) (gap store.Gap, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return gap, false
+	}
+
+	written := 0
+	caughtUp := make(map[string]bool)
+	for commit != nil && len(g.latest) > 0 {
+		batch := g.latest
+		g.latest = make(map[string]pausedObject)
+		g.mu.Unlock()
+		for id, po := range batch {
+			if commit(po.cluster, po.obj, po.deleted) {
+				written++
+				caughtUp[id] = true
+			}
+		}
+		g.mu.Lock()
+	}
+
+	g.paused = false
+	g.latest = nil
+	return store.Gap{
+		Start:     g.start,
+		End:       now,
+		Discarded: g.events - written,
+		CaughtUp:  len(caughtUp),
+	}, true
+}
+
+// run applies the pause (true) and resume (false) requests of the TUI in
+// order until ctx is done, passing each gap to [record]. A pause still open
+// then is ended without catch-up.
+func (g *pauseGate) run(ctx context.Context, requests <-chan bool, commit commitFunc, record func(store.Gap)) {
+	for {
+		select {
+		case <-ctx.Done():
+			if gap, ok := g.resume(time.Now(), nil); ok {
+				record(gap)
+			}
+			return
+		case paused := <-requests:
+			if paused {
+				g.pause(time.Now())
+				log.Info().Bool("catch-up", g.catchUp).Msg("Recording paused")
+				continue
+			}
+			if gap, ok := g.resume(time.Now(), commit); ok {
+				record(gap)
+			}
+		}
+	}
+}
+
+// recordGap writes a gap to the capture, if its store keeps gaps, and hands
+// it to the handler.
+func recordGap(ctx context.Context, rps store.ResourcePatchStore, handler revisionHandler, gap store.Gap) {
+	log.Info().
+		Time("start", gap.Start).
+		Dur("duration", gap.End.Sub(gap.Start)).
+		Int("discarded", gap.Discarded).
+		Int("caught-up", gap.CaughtUp).
+		Msg("Recording resumed")
+	if gs, ok := rps.(store.GapStore); ok {
+		// Also when resuming because loog exits.
+		if err := gs.AddGap(context.WithoutCancel(ctx), &gap); err != nil {
+			log.Error().Err(err).Msg("Cannot record the gap of the pause")
+		}
+	}
+	handler.HandleGap(gap)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/loog-project/loog/internal/service"
+	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
+)
+
+func pausedPod(uid, rv string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind("Pod")
+	obj.SetUID(types.UID(uid))
+	obj.SetResourceVersion(rv)
+	return obj
+}
+
+func TestPauseGate_Discard(t *testing.T) {
+	g := newPauseGate(false)
+	if g.hold("", pausedPod("a", "1"), false) {
+		t.Fatal("held an event while recording")
+	}
+
+	start := time.Now()
+	g.pause(start)
+	for _, rv := range []string{"2", "3"} {
+		if !g.hold("", pausedPod("a", rv), false) {
+			t.Fatalf("event %s not held while paused", rv)
+		}
+	}
+
+	committed := 0
+	gap, ok := g.resume(start.Add(time.Minute), func(string, *unstructured.Unstructured, bool) bool {
+		committed++
+		return true
+	})
+	if !ok {
+		t.Fatal("resume reported no pause")
+	}
+	if committed != 0 || gap.Discarded != 2 || gap.CaughtUp != 0 {
+		t.Errorf("committed %d, gap %+v; want nothing committed and 2 discarded", committed, gap)
+	}
+	if !gap.Start.Equal(start) || !gap.End.Equal(start.Add(time.Minute)) {
+		t.Errorf("gap spans %v - %v", gap.Start, gap.End)
+	}
+	if g.hold("", pausedPod("a", "4"), false) {
+		t.Error("held an event after resuming")
+	}
+	if _, ok := g.resume(time.Now(), nil); ok {
+		t.Error("resume without pause reported a gap")
+	}
+}
+
+func TestPauseGate_CatchUp(t *testing.T) {
+	g := newPauseGate(true)
+	g.pause(time.Now())
+	g.hold("", pausedPod("a", "1"), false)
+	g.hold("", pausedPod("a", "2"), false)
+	g.hold("east", pausedPod("a", "3"), false) // same UID, other cluster
+	g.hold("", pausedPod("b", "4"), false)
+
+	committed := map[string]string{}
+	var again bool
+	gap, _ := g.resume(time.Now(), func(cluster string, obj *unstructured.Unstructured, _ bool) bool {
+		committed[cluster+"/"+string(obj.GetUID())] = obj.GetResourceVersion()
+		// An event arriving while catching up is committed too, before the
+		// collectors resume.
+		if !again {
+			again = true
+			if !g.hold("", pausedPod("c", "5"), false) {
+				t.Error("event not held while catching up")
+			}
+		}
+		return true
+	})
+
+	want := map[string]string{"/a": "2", "east/a": "3", "/b": "4", "/c": "5"}
+	if len(committed) != len(want) {
+		t.Fatalf("committed %v, want %v", committed, want)
+	}
+	for id, rv := range want {
+		if committed[id] != rv {
+			t.Errorf("%s committed at %q, want %q", id, committed[id], rv)
+		}
+	}
+	if gap.CaughtUp != 4 || gap.Discarded != 1 {
+		t.Errorf("gap %+v, want 4 caught up and 1 discarded", gap)
+	}
+}
+
+func TestPauseGate_CatchUpDeletion(t *testing.T) {
+	ctx := context.Background()
+	st, err := bboltStore.New(filepath.Join(t.TempDir(), "capture.loog"), nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = st.Close() }()
+	svc := service.NewTrackerService(st, 8, true)
+	defer func() { _ = svc.Close() }()
+	c := cluster{}
+	if !c.record(ctx, pausedPod("a", "1"), false, svc, st, noOpRevisionHandler{}) {
+		t.Fatal("pod not recorded")
+	}
+
+	g := newPauseGate(true)
+	g.pause(time.Now())
+	g.hold("", pausedPod("a", "2"), true)
+	// The final state may come again after the deletion.
+	g.hold("", pausedPod("a", "2"), false)
+	g.resume(time.Now(), func(_ string, obj *unstructured.Unstructured, deleted bool) bool {
+		return c.record(ctx, obj, deleted, svc, st, noOpRevisionHandler{})
+	})
+
+	latest, err := st.GetLatestRevision(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, patch, err := st.Get(ctx, "a", latest); err != nil || patch == nil || !patch.Deleted {
+		t.Errorf("latest revision %v is not a tombstone (err %v)", patch, err)
+	}
+}
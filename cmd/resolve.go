@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/loog-project/loog/internal/util"
+)
+
+// resourceResolver turns resource arguments into GVRs. Exact
+// "group/version/resource" arguments are taken as they are; anything else
+// is resolved against the cluster's discovery, loaded on first use:
+//
+//   - "all" and wildcards ("apps/*", "*/deployments", "apps/v1/*") expand
+//     to every matching resource of the discovered list,
+//   - short names, resources and kinds ("deploy", "po", "Deployment.apps")
+//     go through a RESTMapper, like kubectl.
+type resourceResolver struct {
+	// listGVRs returns the watchable resources as "group/version/resource".
+	listGVRs func() ([]string, error)
+	// groupResources returns the API groups and their resources.
+	groupResources func() ([]*restmapper.APIGroupResources, error)
+
+	mu     sync.Mutex // guards loading groups and mapper
+	groups []*restmapper.APIGroupResources
+	mapper meta.RESTMapper
+}
+
+// newClusterResolver resolves against the cluster [cfg] points at, caching
+// discovery like shell completion does.
+func newClusterResolver(cfg *rest.Config, kubeConfigPath, kubeContext string) *resourceResolver {
+	return &resourceResolver{
+		listGVRs: func() ([]string, error) { return loadClusterGVRs(kubeConfigPath, kubeContext) },
+		groupResources: func() ([]*restmapper.APIGroupResources, error) {
+			return loadAPIGroupResources(cfg, kubeConfigPath, kubeContext)
+		},
+	}
+}
+
+// resolve parses the resource arguments and resolves their names; a
+// pattern yields one ResourceArg per matching resource, each with the
+// argument's selectors.
+func (r *resourceResolver) resolve(args []string) ([]util.ResourceArg, error) {
+	var out []util.ResourceArg
+	for _, arg := range args {
+		name, ra, err := util.ParseResourceSelectors(arg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse argument '%s': %w", arg, err)
+		}
+		var gvrs []schema.GroupVersionResource
+		typ, objName, isObject := util.SplitObjectName(name)
+		switch {
+		case isObject:
+			var gvr schema.GroupVersionResource
+			gvr, err = r.lookupObject(typ, objName, &ra)
+			gvrs = []schema.GroupVersionResource{gvr}
+		case !util.IsResourcePattern(name):
+			gvr, err := util.ParseGroupVersionResource(name)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse argument '%s' to GVR: %w", arg, err)
+			}
+			gvrs = []schema.GroupVersionResource{gvr}
+		case name == "all" || strings.Contains(name, "*"):
+			gvrs, err = r.expand(name)
+		default:
+			var gvr schema.GroupVersionResource
+			gvr, err = r.lookup(name)
+			gvrs = []schema.GroupVersionResource{gvr}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve argument '%s': %w", arg, err)
+		}
+		if util.IsResourcePattern(name) {
+			setupLog.Debug().Str("arg", name).Int("resources", len(gvrs)).Msg("Resolved resource argument")
+		}
+		for _, gvr := range gvrs {
+			ra.GVR = gvr
+			out = append(out, ra)
+		}
+	}
+	return out, nil
+}
+
+// validateResourceArg checks a resource argument without contacting the
+// cluster; names that need discovery are only checked for valid syntax.
+func validateResourceArg(arg string) error {
+	name, _, err := util.ParseResourceSelectors(arg)
+	if err != nil {
+		return err
+	}
+	if _, objName, ok := util.SplitObjectName(name); ok {
+		// Whether this is TYPE/NAME or GROUP/RESOURCE needs discovery.
+		return (&util.ResourceArg{}).SelectName(objName)
+	}
+	if !util.IsResourcePattern(name) {
+		_, err = util.ParseGroupVersionResource(name)
+		return err
+	}
+	if name == "" {
+		return errors.New("empty resource name")
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	return nil
+}
+
+// expand returns the discovered resources matching a wildcard pattern.
+// Three parts match group, version and resource; two parts match group
+// and resource, or core version and resource; one part matches the
+// resource of any group. Subresources never match.
+func (r *resourceResolver) expand(pattern string) ([]schema.GroupVersionResource, error) {
+	list, err := r.listGVRs()
+	if err != nil {
+		return nil, fmt.Errorf("discovering resources: %w", err)
+	}
+	parts := strings.Split(pattern, "/")
+	for _, p := range parts {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var gvrs []schema.GroupVersionResource
+	for _, s := range list {
+		gvr, ok := parseDiscoveredGVR(s)
+		if !ok {
+			continue
+		}
+		if pattern == "all" || matchesGVR(parts, gvr) {
+			gvrs = append(gvrs, gvr)
+		}
+	}
+	if len(gvrs) == 0 {
+		return nil, fmt.Errorf("no resources match %q", pattern)
+	}
+	return gvrs, nil
+}
+
+func matchesGVR(parts []string, gvr schema.GroupVersionResource) bool {
+	match := func(p, s string) bool {
+		ok, _ := path.Match(p, s)
+		return ok
+	}
+	switch len(parts) {
+	case 1:
+		return match(parts[0], gvr.Resource)
+	case 2:
+		if !match(parts[1], gvr.Resource) {
+			return false
+		}
+		if gvr.Group == "" {
+			return match(parts[0], gvr.Version)
+		}
+		return match(parts[0], gvr.Group)
+	case 3:
+		return match(parts[0], gvr.Group) && match(parts[1], gvr.Version) && match(parts[2], gvr.Resource)
+	}
+	return false
+}
+
+// parseDiscoveredGVR parses an entry of the loadClusterGVRs list. Core
+// subresources ("v1/pods/status") look like grouped resources and are
+// skipped, as are grouped ones ("apps/v1/deployments/scale").
+func parseDiscoveredGVR(s string) (schema.GroupVersionResource, bool) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, true
+	case len(parts) == 3 && parts[0] != "v1":
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, true
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// lookupObject resolves a "TYPE/NAME" name to TYPE, restricting [ra] to the
+// object NAME, like kubectl. When TYPE is no resource, the name is a group
+// and resource instead, e.g. "apps/deployments".
+func (r *resourceResolver) lookupObject(typ, objName string, ra *util.ResourceArg) (schema.GroupVersionResource, error) {
+	gvr, err := r.lookup(typ)
+	if err == nil {
+		return gvr, ra.SelectName(objName)
+	}
+	if grGVR, grErr := r.lookup(objName + "." + typ); grErr == nil {
+		return grGVR, nil
+	}
+	return schema.GroupVersionResource{}, err
+}
+
+// lookup resolves a short name, resource or kind, optionally qualified by
+// its group ("deploy", "deployments.apps", "Deployment.apps"), to the
+// preferred version of the resource.
+func (r *resourceResolver) lookup(name string) (schema.GroupVersionResource, error) {
+	if err := r.loadMapper(); err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	gr := r.expandShortName(schema.ParseGroupResource(name))
+	gvr, err := r.mapper.ResourceFor(gr.WithVersion(""))
+	if err == nil {
+		return gvr, nil
+	}
+	// Kinds are usually found above as their singular resource name; this
+	// catches the rest.
+	gk := schema.ParseGroupKind(name)
+	if mapping, kindErr := r.mapper.RESTMapping(gk); kindErr == nil {
+		return mapping.Resource, nil
+	}
+	return schema.GroupVersionResource{}, err
+}
+
+// kindFor returns the kind of a resource's objects.
+func (r *resourceResolver) kindFor(gvr schema.GroupVersionResource) (string, error) {
+	if err := r.loadMapper(); err != nil {
+		return "", err
+	}
+	gvk, err := r.mapper.KindFor(gvr)
+	if err != nil {
+		return "", err
+	}
+	return gvk.Kind, nil
+}
+
+func (r *resourceResolver) loadMapper() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mapper != nil {
+		return nil
+	}
+	groups, err := r.groupResources()
+	if err != nil {
+		return fmt.Errorf("discovering resources: %w", err)
+	}
+	r.groups = groups
+	r.mapper = restmapper.NewDiscoveryRESTMapper(groups)
+	return nil
+}
+
+// expandShortName replaces a short name ("po", "deploy") by the resource it
+// stands for, preferring resources of the group given, if any.
+func (r *resourceResolver) expandShortName(gr schema.GroupResource) schema.GroupResource {
+	for _, g := range r.groups {
+		if gr.Group != "" && gr.Group != g.Group.Name {
+			continue
+		}
+		for _, res := range g.VersionedResources[g.Group.PreferredVersion.Version] {
+			for _, short := range res.ShortNames {
+				if strings.EqualFold(short, gr.Resource) {
+					return schema.GroupResource{Group: g.Group.Name, Resource: res.Name}
+				}
+			}
+		}
+	}
+	return gr
+}
+
+// loadAPIGroupResources loads the API groups and resources for a
+// RESTMapper, cached like loadClusterGVRs.
+func loadAPIGroupResources(cfg *rest.Config, kubeConfigPath, kubeContext string) ([]*restmapper.APIGroupResources, error) {
+	cachePath := discoveryCachePath("restmapper", kubeConfigPath, kubeContext)
+	var cached []*restmapper.APIGroupResources
+	if readDiscoveryCache(cachePath, &cached) {
+		return cached, nil
+	}
+
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating discovery client: %w", err)
+	}
+	groups, err := restmapper.GetAPIGroupResources(disc)
+	if err != nil {
+		// As in loadClusterResourceKinds, use partial results when some
+		// groups failed (e.g. metrics-server unavailable).
+		var partial *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &partial) || len(groups) == 0 {
+			return nil, fmt.Errorf("getting API group resources: %w", err)
+		}
+		setupLog.Warn().Err(err).Msg("Partial resource discovery; some resource types may be missing")
+		return groups, nil
+	}
+	writeDiscoveryCache(cachePath, groups)
+	return groups, nil
+}
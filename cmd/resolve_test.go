@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/loog-project/loog/internal/util"
+)
+
+func testResolver() *resourceResolver {
+	group := func(name, version string, res ...metav1.APIResource) *restmapper.APIGroupResources {
+		gv := metav1.GroupVersionForDiscovery{GroupVersion: version, Version: version}
+		if name != "" {
+			gv.GroupVersion = name + "/" + version
+		}
+		return &restmapper.APIGroupResources{
+			Group: metav1.APIGroup{
+				Name:             name,
+				Versions:         []metav1.GroupVersionForDiscovery{gv},
+				PreferredVersion: gv,
+			},
+			VersionedResources: map[string][]metav1.APIResource{version: res},
+		}
+	}
+	groups := []*restmapper.APIGroupResources{
+		group("", "v1",
+			metav1.APIResource{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, ShortNames: []string{"po"}},
+			metav1.APIResource{Name: "configmaps", SingularName: "configmap", Kind: "ConfigMap", Namespaced: true, ShortNames: []string{"cm"}}),
+		group("apps", "v1",
+			metav1.APIResource{Name: "deployments", SingularName: "deployment", Kind: "Deployment", Namespaced: true, ShortNames: []string{"deploy"}},
+			metav1.APIResource{Name: "replicasets", SingularName: "replicaset", Kind: "ReplicaSet", Namespaced: true, ShortNames: []string{"rs"}}),
+		group("example.com", "v1alpha1",
+			metav1.APIResource{Name: "deployments", SingularName: "deployment", Kind: "Deployment", Namespaced: true}),
+	}
+	list := []string{
+		"apps/v1/deployments", "apps/v1/replicasets",
+		"example.com/v1alpha1/deployments",
+		"v1/configmaps", "v1/pods", "v1/pods/status",
+	}
+	return &resourceResolver{
+		listGVRs:       func() ([]string, error) { return list, nil },
+		groupResources: func() ([]*restmapper.APIGroupResources, error) { return groups, nil },
+	}
+}
+
+func TestResourceResolver(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	replicaSets := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	exampleDeployments := schema.GroupVersionResource{Group: "example.com", Version: "v1alpha1", Resource: "deployments"}
+
+	tests := []struct {
+		arg     string
+		want    []schema.GroupVersionResource
+		wantErr bool
+	}{
+		{arg: "v1/pods", want: []schema.GroupVersionResource{pods}},
+		{arg: "po", want: []schema.GroupVersionResource{pods}},
+		{arg: "pod", want: []schema.GroupVersionResource{pods}},
+		{arg: "deploy", want: []schema.GroupVersionResource{deployments}},
+		{arg: "deploy/api", want: []schema.GroupVersionResource{deployments}},
+		{arg: "Deployment.apps", want: []schema.GroupVersionResource{deployments}},
+		{arg: "deployments.example.com", want: []schema.GroupVersionResource{exampleDeployments}},
+		{arg: "ReplicaSet", want: []schema.GroupVersionResource{replicaSets}},
+		{arg: "apps/*", want: []schema.GroupVersionResource{deployments, replicaSets}},
+		{arg: "*/deployments", want: []schema.GroupVersionResource{deployments, exampleDeployments}},
+		{arg: "v1/*", want: []schema.GroupVersionResource{configMaps, pods}},
+		{arg: "*.com/*/*", want: []schema.GroupVersionResource{exampleDeployments}},
+		{arg: "all", want: []schema.GroupVersionResource{deployments, replicaSets, exampleDeployments, configMaps, pods}},
+		{arg: "nope", wantErr: true},
+		{arg: "nope/*", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := testResolver().resolve([]string{tt.arg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("resolve(%q) = %+v, want %v", tt.arg, got, tt.want)
+			}
+			for i := range got {
+				if got[i].GVR != tt.want[i] {
+					t.Errorf("resolve(%q)[%d] = %v, want %v", tt.arg, i, got[i].GVR, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Exact GVRs don't need discovery; selectors apply to every expanded GVR.
+func TestResourceResolver_Selectors(t *testing.T) {
+	r := testResolver()
+	r.listGVRs = func() ([]string, error) {
+		t.Fatal("exact GVRs must not trigger discovery")
+		return nil, nil
+	}
+	got, err := r.resolve([]string{"v1/pods", "apps/v1/deployments?labelSelector=app=api,tier!=db&fieldSelector=metadata.name=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := util.ResourceArg{
+		GVR:           schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		LabelSelector: "app=api,tier!=db",
+		FieldSelector: "metadata.name=x",
+	}
+	if len(got) != 2 || got[1] != want {
+		t.Fatalf("got %+v, want %+v last", got, want)
+	}
+	if _, err := r.resolve([]string{"v1/pods?labelSelector=a&labelSelector=b"}); err == nil {
+		t.Fatal("a repeated selector should be rejected")
+	}
+
+	got, err = testResolver().resolve([]string{"apps/*?labelSelector=app=api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ra := range got {
+		if ra.LabelSelector != "app=api" {
+			t.Errorf("%v lost its selector: %+v", ra.GVR, ra)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+
+	// "TYPE/NAME" selects one object, like kubectl.
+	got, err = testResolver().resolve([]string{"deploy/api?fieldSelector=metadata.namespace=prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].FieldSelector != "metadata.name=api,metadata.namespace=prod" {
+		t.Fatalf("got %+v, want deployments with a metadata.name selector", got)
+	}
+
+	// A group and resource is no TYPE/NAME: "apps" is no resource.
+	got, err = testResolver().resolve([]string{"apps/deployments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].GVR != (schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}) ||
+		got[0].FieldSelector != "" {
+		t.Fatalf("got %+v, want all deployments", got)
+	}
+}
+
+// Metadata-only watches need the kind, which the metadata API doesn't send.
+func TestResourceResolver_KindFor(t *testing.T) {
+	r := testResolver()
+	got, err := r.resolve([]string{"v1/configmaps?metadataOnly=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].MetadataOnly {
+		t.Fatalf("got %+v, want one metadata-only resource", got)
+	}
+	kind, err := r.kindFor(got[0].GVR)
+	if err != nil || kind != "ConfigMap" {
+		t.Fatalf("kindFor(%v) = %q, %v, want ConfigMap", got[0].GVR, kind, err)
+	}
+	if _, err := r.kindFor(schema.GroupVersionResource{Version: "v1", Resource: "nope"}); err == nil {
+		t.Fatal("kindFor of an unknown resource should fail")
+	}
+}
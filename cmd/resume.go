@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/loog-project/loog/internal/service"
+	"github.com/loog-project/loog/internal/store"
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+// bookmarkInterval is how often the bookmarks of a capture are saved while
+// recording, besides once at exit.
+var bookmarkInterval = 30 * time.Second
+
+// bookmarkKey returns the key the bookmark of a cluster's watch is saved
+// under, e.g. "apps/v1/deployments".
+func bookmarkKey(clusterName string, gvr schema.GroupVersionResource) string {
+	return store.ObjectID(clusterName, gvr.GroupVersion().String()+"/"+gvr.Resource)
+}
+
+// recordedObject is what a capture last knew of an object: enough to look
+// it up in its cluster again.
+type recordedObject struct {
+	gk        schema.GroupKind
+	namespace string
+	name      string
+	deleted   bool
+}
+
+// resumeState is what a capture resumed with --append knew when its
+// previous run ended.
+type resumeState struct {
+	// bookmarks are the resourceVersions the watches were last in sync at,
+	// by [bookmarkKey].
+	bookmarks map[string]string
+	// objects are the recorded objects by object ID.
+	objects map[string]recordedObject
+}
+
+// loadResumeState reads the resume state of the capture in [rps]. It is nil
+// for a new capture.
+func loadResumeState(rps store.ResourcePatchStore) (*resumeState, error) {
+	rs := &resumeState{objects: make(map[string]recordedObject)}
+	if bs, ok := rps.(store.BookmarkStore); ok {
+		bookmarks, err := bs.Bookmarks()
+		if err != nil {
+			return nil, err
+		}
+		rs.bookmarks = bookmarks
+	}
+	err := rps.WalkObjectRevisions(func(objectID string, _ store.RevisionID, snapshot *store.Snapshot, patch *store.Patch) bool {
+		ro := rs.objects[objectID]
+		if snapshot != nil {
+			// The identity of an object never changes.
+			obj := unstructured.Unstructured{Object: snapshot.Object}
+			ro.gk = obj.GroupVersionKind().GroupKind()
+			ro.namespace = obj.GetNamespace()
+			ro.name = obj.GetName()
+		}
+		ro.deleted = patch != nil && patch.Deleted
+		rs.objects[objectID] = ro
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rs.bookmarks) == 0 && len(rs.objects) == 0 {
+		return nil, nil
+	}
+	return rs, nil
+}
+
+// options returns the options resuming the watch of [gvr] in a cluster from
+// its bookmark, if it has one. A nil state has none.
+func (rs *resumeState) options(clusterName string, gvr schema.GroupVersionResource) []mux.AddOption {
+	if rs == nil {
+		return nil
+	}
+	if rv := rs.bookmarks[bookmarkKey(clusterName, gvr)]; rv != "" {
+		return []mux.AddOption{mux.ResumeFrom(rv)}
+	}
+	return nil
+}
+
+// reconcile records the deletion of every object of [c]'s watch of [gvr]
+// that the capture knows as alive but that is gone from the cluster: deleted
+// while loog was down and not replayed, because the server no longer kept
+// the changes since the bookmark or because they came after it. Objects
+// only missing from the watch, e.g. because of other selectors, are looked
+// up first and kept.
+func (rs *resumeState) reconcile(
+	ctx context.Context, c cluster, gvr schema.GroupVersionResource, trackerService *service.TrackerService,
+) {
+	if rs == nil {
+		return
+	}
+	l := log.With().Str("context", c.context).Str("gvr", gvr.String()).Logger()
+	if st := c.mux.Stats()[gvr]; st.Resumed {
+		l.Info().Uint64("replayed", st.Replayed).Msg("Resumed watch from its bookmark")
+	}
+	kind, err := c.resolver.kindFor(gvr)
+	if err != nil {
+		l.Warn().Err(err).Msg("Cannot find the kind of the watch, not looking for deleted objects")
+		return
+	}
+	gk := schema.GroupKind{Group: gvr.Group, Kind: kind}
+
+	live := make(map[types.UID]bool)
+	for _, obj := range c.mux.Objects(gvr) {
+		if accessor, accErr := meta.Accessor(obj); accErr == nil {
+			live[accessor.GetUID()] = true
+		}
+	}
+	deleted := 0
+	for objectID, ro := range rs.objects {
+		cluster, uid := store.SplitObjectID(objectID)
+		if cluster != c.name || ro.gk != gk || ro.deleted || live[types.UID(uid)] ||
+			c.mux.ReplayedDeletion(gvr, types.UID(uid)) {
+			continue
+		}
+		if !gone(ctx, c, gvr, ro, types.UID(uid)) {
+			continue
+		}
+		_, delErr := trackerService.Delete(ctx, objectID)
+		switch {
+		case errors.Is(delErr, service.ErrAlreadyDeleted):
+		case delErr != nil:
+			l.Error().Err(delErr).Str("name", ro.name).Msg("Cannot record the deletion of an object")
+		default:
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		l.Info().Int("deleted", deleted).Msg("Recorded objects deleted while loog was down")
+	}
+}
+
+// gone reports whether the object [uid] no longer exists in the cluster:
+// nothing has its name anymore, or something else does.
+func gone(ctx context.Context, c cluster, gvr schema.GroupVersionResource, ro recordedObject, uid types.UID) bool {
+	// Through the metadata API for metadata-only watches.
+	obj, err := c.mux.Get(ctx, gvr, ro.namespace, ro.name)
+	switch {
+	case apierrors.IsNotFound(err):
+		return true
+	case err != nil:
+		log.Debug().Err(err).Str("name", ro.name).Msg("Cannot look up a recorded object")
+		return false
+	}
+	accessor, err := meta.Accessor(obj)
+	return err == nil && accessor.GetUID() != uid
+}
+
+// versionTracker remembers the resourceVersion of the latest event of each
+// watch that the collector committed or skipped on purpose, so a resumed
+// capture replays the events still queued, or held while paused, at exit.
+// With --namespace, a watch has an informer per namespace whose events
+// interleave, so the oldest of their latest versions is the one to resume
+// from. A nil tracker remembers nothing.
+type versionTracker struct {
+	perNamespace bool
+
+	mu     sync.Mutex
+	latest map[versionKey]string
+}
+
+type versionKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+}
+
+func newVersionTracker(perNamespace bool) *versionTracker {
+	return &versionTracker{perNamespace: perNamespace, latest: make(map[versionKey]string)}
+}
+
+// handled notes that the collector is done with the event of [obj].
+func (t *versionTracker) handled(obj *unstructured.Unstructured) {
+	if t == nil {
+		return
+	}
+	rv := obj.GetResourceVersion()
+	if rv == "" {
+		return
+	}
+	key := versionKey{gvk: obj.GroupVersionKind()}
+	if t.perNamespace {
+		key.namespace = obj.GetNamespace()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Caught-up objects come in no particular order.
+	if last, ok := t.latest[key]; !ok || mux.CompareResourceVersions(rv, last) > 0 {
+		t.latest[key] = rv
+	}
+}
+
+// bookmark returns the resourceVersion to resume the watch of objects of
+// kind [gvk] from, empty when the collector handled none of them yet.
+func (t *versionTracker) bookmark(gvk schema.GroupVersionKind) string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	oldest := ""
+	for key, rv := range t.latest {
+		if key.gvk == gvk && (oldest == "" || mux.CompareResourceVersions(rv, oldest) < 0) {
+			oldest = rv
+		}
+	}
+	return oldest
+}
+
+// keepBookmarks saves how far the collectors got in each watch of the
+// clusters, every [bookmarkInterval] until the returned stop is called,
+// which saves them a last time.
+func keepBookmarks(ctx context.Context, clusters []cluster, bs store.BookmarkStore) (stop func()) {
+	saved := make(map[string]string)
+	save := func() {
+		for _, c := range clusters {
+			for _, gvr := range c.mux.GVRs() {
+				kind, err := c.resolver.kindFor(gvr)
+				if err != nil {
+					continue
+				}
+				key, rv := bookmarkKey(c.name, gvr), c.versions.bookmark(gvr.GroupVersion().WithKind(kind))
+				if rv == "" || saved[key] == rv {
+					continue
+				}
+				// Also when saving because loog exits.
+				if err := bs.SetBookmark(context.WithoutCancel(ctx), key, rv); err != nil {
+					log.Error().Err(err).Str("cluster", c.name).Str("gvr", gvr.String()).Msg("Cannot save the bookmark of a watch")
+					continue
+				}
+				saved[key] = rv
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(bookmarkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				save()
+			}
+		}
+	})
+	return func() {
+		cancel()
+		wg.Wait()
+		save()
+	}
+}
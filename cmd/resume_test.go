@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"github.com/loog-project/loog/internal/service"
+	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
+	"github.com/loog-project/loog/pkg/mux"
+)
+
+func resumedPod(name string, labels map[string]string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]any{
+			"name":            name,
+			"namespace":       "default",
+			"uid":             "uid-" + name,
+			"resourceVersion": "1",
+		},
+	}}
+	pod.SetLabels(labels)
+	return pod
+}
+
+func TestResume_TombstonesVanishedObjects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	watched := map[string]string{"app": "web"}
+
+	st, err := bboltStore.New(filepath.Join(t.TempDir(), "capture.loog"), nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = st.Close() }()
+	svc := service.NewTrackerService(st, 8, true)
+	defer func() { _ = svc.Close() }()
+
+	// The capture knows alpha, kept; bravo, deleted while loog was down;
+	// charlie, deleted while recording; and delta, no longer watched but
+	// still there.
+	for _, pod := range []*unstructured.Unstructured{
+		resumedPod("alpha", watched), resumedPod("bravo", watched),
+		resumedPod("charlie", watched), resumedPod("delta", nil),
+	} {
+		if _, err := svc.Commit(ctx, string(pod.GetUID()), pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := svc.Delete(ctx, "uid-charlie"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetBookmark(ctx, bookmarkKey("", pods), "42"); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := loadResumeState(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts := rs.options("", pods); len(opts) != 1 {
+		t.Errorf("options = %d, want resuming from the bookmark", len(opts))
+	}
+	if opts := rs.options("other", pods); opts != nil {
+		t.Error("options of a cluster without bookmarks")
+	}
+	if !rs.objects["uid-charlie"].deleted || rs.objects["uid-bravo"].deleted ||
+		rs.objects["uid-bravo"].gk != (schema.GroupKind{Kind: "Pod"}) {
+		t.Fatalf("objects = %+v", rs.objects)
+	}
+
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{pods: "PodList"},
+		resumedPod("alpha", watched), resumedPod("delta", nil))
+	m, err := mux.New(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if err := m.Add(pods, mux.LabelSelector("app=web")); err != nil {
+		t.Fatal(err)
+	}
+	rs.reconcile(ctx, cluster{mux: m, resolver: testResolver()}, pods, svc)
+
+	for uid, wantDeleted := range map[string]bool{
+		"uid-alpha": false, "uid-bravo": true, "uid-charlie": true, "uid-delta": false,
+	} {
+		latest, err := st.GetLatestRevision(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, patch, err := st.Get(ctx, uid, latest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted := patch != nil && patch.Deleted; deleted != wantDeleted {
+			t.Errorf("%s deleted = %v, want %v", uid, deleted, wantDeleted)
+		}
+	}
+}
+
+func TestLoadResumeState_NewCapture(t *testing.T) {
+	st, err := bboltStore.New(filepath.Join(t.TempDir(), "capture.loog"), nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = st.Close() }()
+
+	rs, err := loadResumeState(st)
+	if err != nil || rs != nil {
+		t.Fatalf("loadResumeState = %v, %v; want nothing to resume", rs, err)
+	}
+	if opts := rs.options("", eventsGVR); opts != nil {
+		t.Error("a nil state resumes watches")
+	}
+}
+
+func TestVersionTracker(t *testing.T) {
+	pod := func(namespace, rv string) *unstructured.Unstructured {
+		obj := resumedPod("p", nil)
+		obj.SetNamespace(namespace)
+		obj.SetResourceVersion(rv)
+		return obj
+	}
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	all := newVersionTracker(false)
+	for _, rv := range []string{"5", "9", "7"} {
+		all.handled(pod("a", rv))
+	}
+	all.handled(pod("b", "3"))
+	if got := all.bookmark(podGVK); got != "9" {
+		t.Errorf("bookmark = %q, want the latest handled 9", got)
+	}
+	if got := all.bookmark(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}); got != "" {
+		t.Errorf("bookmark of an unhandled kind = %q", got)
+	}
+
+	// An informer per namespace: the one lagging behind decides.
+	perNamespace := newVersionTracker(true)
+	perNamespace.handled(pod("a", "9"))
+	perNamespace.handled(pod("b", "3"))
+	if got := perNamespace.bookmark(podGVK); got != "3" {
+		t.Errorf("bookmark = %q, want 3", got)
+	}
+
+	var nilTracker *versionTracker
+	nilTracker.handled(pod("a", "1"))
+	if nilTracker.bookmark(podGVK) != "" {
+		t.Error("nil tracker has a bookmark")
+	}
+}
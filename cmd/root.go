@@ -20,8 +20,10 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
 
 	"github.com/loog-project/loog/internal/adapter"
@@ -33,6 +35,7 @@ import (
 	"github.com/loog-project/loog/internal/tui"
 	"github.com/loog-project/loog/internal/util"
 	"github.com/loog-project/loog/pkg/diffmap"
+	"github.com/loog-project/loog/pkg/diffpreview"
 	"github.com/loog-project/loog/pkg/mux"
 )
 
@@ -40,7 +43,7 @@ var (
 	// persistent flags
 	cfgFile          string
 	kubeConfigPath   string
-	kubeContext      string
+	kubeContexts     []string
 	enableDebugMode  bool
 	truncateDebugLog bool
 
@@ -48,6 +51,7 @@ var (
 	outputFile       string
 	noDurableSync    bool
 	disableCache     bool
+	cacheMemory      string
 	disableCompress  bool
 	snapshotInterval uint64
 	filterExpr       string
@@ -55,6 +59,11 @@ var (
 	simulateMode     bool
 	appendOutput     bool
 	replayFile       string
+	namespaces       []string
+	watchCRDs        []string
+	pauseCatchUp     bool
+	followOwners     bool
+	recordEvents     bool
 )
 
 var rootCmd = &cobra.Command{
@@ -85,8 +94,8 @@ func init() {
 		"config file (default is $HOME/.loog.yaml)")
 	rootCmd.PersistentFlags().StringVar(&kubeConfigPath, "kubeconfig", "",
 		"Path to the kubeconfig file (overrides $KUBECONFIG; defaults to $KUBECONFIG or $HOME/.kube/config)")
-	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "",
-		"Name of the kubeconfig context to use (defaults to the current context)")
+	rootCmd.PersistentFlags().StringArrayVar(&kubeContexts, "context", nil,
+		"Name of the kubeconfig context to use (defaults to the current context); repeat to record several clusters into one capture")
 	rootCmd.PersistentFlags().BoolVar(&enableDebugMode, "debug", false,
 		"Enable debug mode, which will print additional information to the debug.log file")
 	rootCmd.PersistentFlags().BoolVar(&truncateDebugLog, "truncate-debug", false,
@@ -103,6 +112,8 @@ func init() {
 		"Skip fsync on every commit to improve throughput (unsafe on crashes)")
 	rootCmd.Flags().BoolVar(&disableCache, "disable-cache", false,
 		"Disable in‑memory cache layer for the revision store")
+	rootCmd.Flags().StringVar(&cacheMemory, "cache-memory", "256Mi",
+		"Approximate memory budget of the in-memory cache (e.g. 512Mi, 2Gi); least recently used objects are evicted beyond it")
 	rootCmd.Flags().BoolVar(&disableCompress, "no-compress", false,
 		"Disable s2 compression for stored payloads (larger DB but slightly less CPU)")
 	rootCmd.Flags().Uint64VarP(&snapshotInterval, "snapshot-interval", "s", 8,
@@ -113,6 +124,16 @@ func init() {
 		"Allow --output to resume an existing .loog file instead of refusing it")
 	rootCmd.Flags().StringVar(&replayFile, "replay", "",
 		"Open an existing .loog file read-only and browse it, without connecting to Kubernetes")
+	rootCmd.Flags().StringArrayVar(&watchCRDs, "watch-crds", nil,
+		"Watch the custom resources of every CRD whose group matches this pattern (e.g. '*.example.com'), including CRDs installed later (repeatable)")
+	rootCmd.Flags().BoolVar(&pauseCatchUp, "pause-catch-up", false,
+		"When resuming a paused recording, write the latest state of every object changed meanwhile instead of discarding those changes")
+	rootCmd.Flags().BoolVar(&followOwners, "follow-owners", false,
+		"Also watch the objects the resource arguments' objects own (ReplicaSets and Pods of Deployments, Jobs of CronJobs, ...) and record only those owned by a recorded object")
+	rootCmd.Flags().BoolVar(&recordEvents, "record-events", false,
+		"Also watch core/v1 Events and record those about a recorded object with it, shown in the timeline and revision list")
+	rootCmd.Flags().StringArrayVarP(&namespaces, "namespace", "n", nil,
+		"Only watch namespaced resources in this namespace (repeatable; needs list/watch there only). Default: all namespaces")
 
 	// allow some flags to be set via environment variables / config file
 	mustBind("kubeconfig",
@@ -125,6 +146,8 @@ func init() {
 		viper.BindPFlag("no-durable-sync", rootCmd.Flags().Lookup("no-durable-sync")))
 	mustBind("disable-cache",
 		viper.BindPFlag("disable-cache", rootCmd.Flags().Lookup("disable-cache")))
+	mustBind("cache-memory",
+		viper.BindPFlag("cache-memory", rootCmd.Flags().Lookup("cache-memory")))
 	mustBind("snapshot-interval",
 		viper.BindPFlag("snapshot-interval", rootCmd.Flags().Lookup("snapshot-interval")))
 }
@@ -159,6 +182,9 @@ func initConfig() {
 // run is the main entry point for the command execution.
 func run(ctx context.Context, args []string) error {
 	setupDebugLogger()
+	if err := registerMergeKeys(); err != nil {
+		return err
+	}
 
 	// Replay mode: open an existing capture read-only and browse it.
 	if replayFile != "" {
@@ -171,7 +197,7 @@ func run(ctx context.Context, args []string) error {
 	}
 
 	// Production mode: connect to Kubernetes
-	cleanup, prog, trackerService, rps, m, err := setupProduction(ctx, args)
+	cleanup, prog, trackerService, rps, clusters, err := setupProduction(ctx, args)
 	defer cleanup()
 	if err != nil {
 		return err
@@ -183,9 +209,9 @@ func run(ctx context.Context, args []string) error {
 	var wg sync.WaitGroup
 
 	if headlessMode {
-		runHeadless(ctx, cancel, &wg, m, trackerService, rps, prog)
+		runHeadless(ctx, cancel, &wg, clusters, trackerService, rps, prog)
 	} else {
-		runInteractive(ctx, cancel, &wg, m, trackerService, rps, prog)
+		runInteractive(ctx, cancel, &wg, clusters, trackerService, rps, prog)
 	}
 
 	wg.Wait()
@@ -193,6 +219,31 @@ func run(ctx context.Context, args []string) error {
 	return nil
 }
 
+// mergeKeyConfig is one entry of the "merge-keys" config list, naming the
+// fields that identify list items of a CRD for the diff views:
+//
+//	merge-keys:
+//	  - path: spec.endpoints
+//	    key: port
+type mergeKeyConfig struct {
+	Path string `mapstructure:"path"`
+	Key  string `mapstructure:"key"`
+}
+
+// registerMergeKeys registers the merge keys from the config file.
+func registerMergeKeys() error {
+	var entries []mergeKeyConfig
+	if err := viper.UnmarshalKey("merge-keys", &entries); err != nil {
+		return fmt.Errorf("invalid merge-keys config: %w", err)
+	}
+	for _, e := range entries {
+		if err := diffpreview.RegisterMergeKey(e.Path, e.Key); err != nil {
+			return fmt.Errorf("invalid merge-keys config: %w", err)
+		}
+	}
+	return nil
+}
+
 // setupDebugLogger configures the global zerolog logger.
 func setupDebugLogger() {
 	if enableDebugMode {
@@ -297,14 +348,15 @@ func runReplayMode() error {
 	return nil
 }
 
-// setupProduction initializes the output file, filter, store, kube client, and mux.
-// It returns a cleanup function, the compiled filter, tracker service, store, and mux.
+// setupProduction initializes the output file, filter, store, and a kube client and
+// mux per cluster. It returns a cleanup function, the compiled filter, tracker
+// service, store, and clusters.
 func setupProduction(ctx context.Context, args []string) (
 	cleanup func(),
 	prog *vm.Program,
 	trackerService *service.TrackerService,
 	rps store.ResourcePatchStore,
-	m *mux.Mux,
+	clusters []cluster,
 	err error,
 ) {
 	var cleanups []func()
@@ -359,47 +411,70 @@ func setupProduction(ctx context.Context, args []string) (
 		return
 	}
 	cleanups = append(cleanups, func() { _ = rps.Close() })
-	trackerService = service.NewTrackerService(rps, snapshotInterval, !disableCache)
-	cleanups = append(cleanups, func() { _ = trackerService.Close() })
-
-	setupLog.Info().Msg("Preparing dynamic Kubernetes watch client...")
-	cfg, cfgErr := restConfigForKubeconfig(kubeConfigPath, kubeContext)
-	if cfgErr != nil {
-		err = fmt.Errorf("error loading kubeconfig: %w", cfgErr)
-		return
-	}
-	dyn, dynErr := dynamic.NewForConfig(cfg)
-	if dynErr != nil {
-		err = fmt.Errorf("error creating dynamic watch client: %w", dynErr)
-		return
-	}
-
-	m, err = mux.New(ctx, dyn)
-	if err != nil {
-		err = fmt.Errorf("error creating dynamic mux: %w", err)
+	cacheBudget, budgetErr := parseCacheMemory(cacheMemory)
+	if budgetErr != nil {
+		err = budgetErr
 		return
 	}
+	trackerService = service.NewTrackerServiceWithOptions(rps, service.Options{
+		SnapshotEvery: snapshotInterval,
+		Cache:         !disableCache,
+		CacheMemory:   cacheBudget,
+	})
 	cleanups = append(cleanups, func() {
-		// Defensive: the mux is only appended after a successful New above,
-		// but keep the guard so a future refactor can't reintroduce a nil deref.
-		if m != nil {
-			m.Stop()
-		}
+		logCacheStats(trackerService)
+		_ = trackerService.Close()
 	})
 
-	for _, r := range args {
-		gvr, gvrParseErr := util.ParseGroupVersionResource(r)
-		if gvrParseErr != nil {
-			err = fmt.Errorf("cannot parse argument '%s' to GVR: %w", r, gvrParseErr)
+	var resume *resumeState
+	if appendOutput {
+		resume, err = loadResumeState(rps)
+		if err != nil {
+			err = fmt.Errorf("error reading the capture to resume: %w", err)
 			return
 		}
-		if muxAddErr := m.Add(gvr); muxAddErr != nil {
-			err = fmt.Errorf("cannot add GVR '%s' to dynamic mux: %w", gvr, muxAddErr)
+	}
+	for _, kctx := range clusterContexts() {
+		c := cluster{
+			name:      clusterName(kctx),
+			context:   kctx,
+			unwatched: newKindSet(),
+			versions:  newVersionTracker(len(namespaces) > 0),
+		}
+		var clusterCleanups []func()
+		clusterCleanups, err = setupCluster(ctx, &c, args, resume, trackerService)
+		cleanups = append(cleanups, clusterCleanups...)
+		if err != nil {
+			if c.name != "" {
+				err = fmt.Errorf("context %q: %w", kctx, err)
+			}
 			return
 		}
+		clusters = append(clusters, c)
+	}
+	if bs, ok := rps.(store.BookmarkStore); ok {
+		// Runs before the muxes stop, so the last bookmarks are saved.
+		cleanups = append(cleanups, keepBookmarks(ctx, clusters, bs))
 	}
 
-	return cleanup, prog, trackerService, rps, m, nil
+	return cleanup, prog, trackerService, rps, clusters, nil
+}
+
+// logDroppedEvents reports the watches that had to drop events because the
+// collector fell behind, and how many of those objects were re-sent.
+func logDroppedEvents(m *mux.Mux, clusterName string) {
+	for gvr, st := range m.Stats() {
+		if st.Dropped == 0 {
+			continue
+		}
+		setupLog.Warn().
+			Str("cluster", clusterName).
+			Str("gvr", gvr.String()).
+			Uint64("dropped", st.Dropped).
+			Uint64("repaired", st.Repaired).
+			Int("pending", st.Pending).
+			Msg("Events were dropped while the collector fell behind")
+	}
 }
 
 // runHeadless runs the collector without a TUI, waiting for SIGINT.
@@ -407,16 +482,18 @@ func runHeadless(
 	ctx context.Context,
 	cancel context.CancelFunc,
 	wg *sync.WaitGroup,
-	m *mux.Mux,
+	clusters []cluster,
 	trackerService *service.TrackerService,
 	rps store.ResourcePatchStore,
 	prog *vm.Program,
 ) {
 	setupLog.Info().Msg("Running in headless mode, using no-op revision handler")
 
-	wg.Go(func() {
-		runCollector(ctx, m, trackerService, rps, prog, &noOpRevisionHandler{})
-	})
+	for _, c := range clusters {
+		wg.Go(func() {
+			runCollector(ctx, c, trackerService, rps, prog, &noOpRevisionHandler{}, nil)
+		})
+	}
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -432,7 +509,7 @@ func runInteractive(
 	ctx context.Context,
 	cancel context.CancelFunc,
 	wg *sync.WaitGroup,
-	m *mux.Mux,
+	clusters []cluster,
 	trackerService *service.TrackerService,
 	rps store.ResourcePatchStore,
 	prog *vm.Program,
@@ -440,8 +517,21 @@ func runInteractive(
 	setupLog.Info().Msg("Running in interactive mode with new TUI")
 
 	liveStore := adapter.NewLiveStore()
+	gate := newPauseGate(pauseCatchUp)
+	// Applied in order off the event loop: catching up may take a while.
+	pauseRequests := make(chan bool, 16)
 	app := tui.NewApp(liveStore,
 		tui.WithRecording(),
+		tui.WithPauseCallback(func(paused bool) { pauseRequests <- paused }),
+		tui.WithDroppedEvents(func() uint64 {
+			var n uint64
+			for _, c := range clusters {
+				for _, st := range c.mux.Stats() {
+					n += st.Dropped
+				}
+			}
+			return n
+		}),
 		tui.WithWatchCallbacks(
 			func(rk resource.Kind) {
 				gvr, err := util.ParseGroupVersionResource(rk.GVR())
@@ -453,16 +543,27 @@ func runInteractive(
 				// runs on the bubbletea event loop, so blocking here would
 				// freeze the whole UI (indefinitely if the GVR never syncs).
 				// Run it in the background; events arrive via the collector.
-				go func() {
-					if err := m.Add(gvr); err != nil {
-						log.Error().Err(err).Str("kind", rk.Kind).Msg("Cannot add watch to mux")
-					} else {
-						log.Info().Str("kind", rk.Kind).Str("gvr", rk.GVR()).Msg("Added dynamic watch")
-					}
-				}()
+				var addOpts []mux.AddOption
+				if !rk.Namespaced {
+					addOpts = append(addOpts, mux.ClusterScoped())
+				}
+				for _, c := range clusters {
+					c.unwatched.remove(schema.GroupKind{Group: gvr.Group, Kind: rk.Kind})
+					go func() {
+						if err := c.mux.Add(gvr, addOpts...); err != nil {
+							log.Error().Err(err).Str("cluster", c.name).Str("kind", rk.Kind).Msg("Cannot add watch to mux")
+						} else {
+							log.Info().Str("cluster", c.name).Str("kind", rk.Kind).Str("gvr", rk.GVR()).Msg("Added dynamic watch")
+						}
+					}()
+				}
 			},
-			func(kind string) {
-				log.Info().Str("kind", kind).Msg("Watch kind removed from TUI (mux removal requires GVR)")
+			func(rk resource.Kind) {
+				// Finding the kind's GVRs may need discovery, and Remove
+				// waits for sends in flight; keep both off the event loop.
+				for _, c := range clusters {
+					go c.unwatchKind(rk)
+				}
 			},
 		),
 	)
@@ -486,7 +587,7 @@ func runInteractive(
 
 	// Discover cluster resource kinds in the background for WatchManager
 	go func() {
-		kinds, err := loadClusterResourceKinds(kubeConfigPath, kubeContext)
+		kinds, err := loadClusterResourceKinds(kubeConfigPath, primaryContext())
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to discover cluster resource kinds for WatchManager")
 			return
@@ -501,7 +602,7 @@ func runInteractive(
 
 	// Register the goroutines with the WaitGroup before launching them,
 	// ensuring wg.Wait() in the caller cannot return prematurely.
-	wg.Add(2)
+	wg.Add(2 + len(clusters))
 	go func() {
 		defer wg.Done()
 		program.Send(nil) // wait until program is ready
@@ -513,9 +614,24 @@ func runInteractive(
 		liveStore.SortTimeline()
 		program.Send(adapter.LiveRevisionMsg{})
 	}()
+	for _, c := range clusters {
+		go func() {
+			defer wg.Done()
+			runCollector(ctx, c, trackerService, rps, prog, handler, gate)
+		}()
+	}
 	go func() {
 		defer wg.Done()
-		runCollector(ctx, m, trackerService, rps, prog, handler)
+		byName := make(map[string]cluster, len(clusters))
+		for _, c := range clusters {
+			byName[c.name] = c
+		}
+		commit := func(clusterName string, obj *unstructured.Unstructured, deleted bool) bool {
+			c := byName[clusterName]
+			defer c.versions.handled(obj)
+			return c.record(ctx, obj, deleted, trackerService, rps, handler)
+		}
+		gate.run(ctx, pauseRequests, commit, func(gap store.Gap) { recordGap(ctx, rps, handler, gap) })
 	}()
 
 	if _, teaErr := program.Run(); teaErr != nil {
@@ -529,11 +645,17 @@ func runInteractive(
 // revisionHandler is the handler used by the collector to handle revisions.
 type revisionHandler interface {
 	HandleRevision(
+		objectID string,
 		obj *unstructured.Unstructured,
 		revisionID store.RevisionID,
 		snapshot *store.Snapshot,
 		patch *store.Patch,
 	) error
+	// HandleGap is called for each span in which recording was paused.
+	HandleGap(gap store.Gap)
+	// HandleAnnotation is called for each annotation of an object, such as
+	// a recorded Event.
+	HandleAnnotation(objectID string, a store.Annotation)
 }
 
 // tuiLogWriter is an io.Writer that captures lines written by external
@@ -583,12 +705,15 @@ var _ revisionHandler = (*noOpRevisionHandler)(nil)
 type noOpRevisionHandler struct{}
 
 func (n noOpRevisionHandler) HandleRevision(
+	objectID string,
 	obj *unstructured.Unstructured,
 	revisionID store.RevisionID,
 	_ *store.Snapshot,
 	_ *store.Patch,
 ) error {
+	cluster, _ := store.SplitObjectID(objectID)
 	log.Debug().
+		Str("cluster", cluster).
 		Str("revision-id", revisionID.String()).
 		Str("namespace", obj.GetNamespace()).
 		Str("name", obj.GetName()).
@@ -598,26 +723,47 @@ func (n noOpRevisionHandler) HandleRevision(
 	return nil
 }
 
+func (n noOpRevisionHandler) HandleGap(gap store.Gap) {
+	log.Debug().
+		Time("start", gap.Start).
+		Time("end", gap.End).
+		Msg("Recorded gap")
+}
+
+func (n noOpRevisionHandler) HandleAnnotation(objectID string, a store.Annotation) {
+	cluster, uid := store.SplitObjectID(objectID)
+	log.Debug().
+		Str("cluster", cluster).
+		Str("uid", uid).
+		Str("reason", a.Reason).
+		Msg("Storing annotation...")
+}
+
 // runCollector runs the collector that listens to events from the dynamic mux
+// of one cluster, tagging the objects with the cluster name. Events held
+// back by [gate] while recording is paused aren't committed; gate may be nil.
+// Recorded Events bypass the filter: they follow their involved object.
 func runCollector(
 	ctx context.Context,
-	m *mux.Mux,
+	c cluster,
 	trackerService *service.TrackerService,
 	rps store.ResourcePatchStore,
 	filterExprProgram *vm.Program,
 	handler revisionHandler,
+	gate *pauseGate,
 ) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
-		case ev, ok := <-m.Events():
+		case ev, ok := <-c.mux.Events():
 			if !ok {
 				return
 			}
 
 			l := log.With().
+				Str("cluster", c.name).
 				Str("event-type", string(ev.Type)).
 				Logger()
 
@@ -626,11 +772,24 @@ func runCollector(
 				l.Warn().Msgf("Expected unstructured.Unstructured, got %T", ev.Object)
 				continue
 			}
+			// Queued before the kind was unwatched in the TUI.
+			if c.unwatched.has(obj.GroupVersionKind().GroupKind()) {
+				c.versions.handled(obj)
+				continue
+			}
+			if c.events.isEvent(obj) {
+				if !gate.hold(c.name, obj, ev.Type == watch.Deleted) {
+					c.record(ctx, obj, ev.Type == watch.Deleted, trackerService, rps, handler)
+					c.versions.handled(obj)
+				}
+				continue
+			}
 
 			// make sure we want to store this object
 			pass, err := expr.Run(filterExprProgram, util.EventEntryEnv{
-				Event:  ev,
-				Object: obj,
+				Event:       ev,
+				Object:      obj,
+				ClusterName: c.name,
 			})
 			if err != nil {
 				l.Error().Err(err).Msg("Error executing filter expression")
@@ -641,43 +800,106 @@ func runCollector(
 				l.Error().Msgf("Filter expression returned %T instead of bool", pass)
 				continue
 			}
-			if !passBool {
-				continue
+			// Held objects count as handled once caught up, if ever.
+			held := false
+			for _, o := range c.owners.admit(obj, ev.Type == watch.Deleted, passBool) {
+				deleted := ev.Type == watch.Deleted && o == obj
+				if gate.hold(c.name, o, deleted) {
+					held = held || o == obj
+					continue
+				}
+				c.record(ctx, o, deleted, trackerService, rps, handler)
 			}
+			if !held {
+				c.versions.handled(obj)
+			}
+		}
+	}
+}
 
-			l = l.With().
-				Str("namespace", obj.GetNamespace()).
-				Str("name", obj.GetName()).
-				Str("kind", obj.GetKind()).
-				Logger()
+// commitObject commits an object of a cluster to the tracker service and
+// hands the new revision to the handler. It reports whether a revision was
+// written.
+func commitObject(
+	ctx context.Context,
+	clusterName string,
+	obj *unstructured.Unstructured,
+	trackerService *service.TrackerService,
+	rps store.ResourcePatchStore,
+	handler revisionHandler,
+) bool {
+	l := log.With().
+		Str("cluster", clusterName).
+		Str("namespace", obj.GetNamespace()).
+		Str("name", obj.GetName()).
+		Str("kind", obj.GetKind()).
+		Logger()
 
-			l.Debug().Msg("Processing event...")
+	l.Debug().Msg("Processing event...")
 
-			// empty managed fields before committing as they only clutter and we in 99/100 cases don't need them
-			obj.SetManagedFields(nil)
-			revisionID, err := trackerService.Commit(ctx, string(obj.GetUID()), obj)
-			if err != nil {
-				var dupErr service.DuplicateResourceVersionError
-				if errors.As(err, &dupErr) {
-					l.Debug().Msgf("Resource version %s is already present in revision %d, skipping commit",
-						obj.GetResourceVersion(), revisionID)
-					continue
-				}
-				l.Error().Err(err).Msg("Error committing to tracker service")
-				continue
-			}
+	// empty managed fields before committing as they only clutter and we in 99/100 cases don't need them
+	obj.SetManagedFields(nil)
+	objectID := store.ObjectID(clusterName, string(obj.GetUID()))
+	revisionID, err := trackerService.Commit(ctx, objectID, obj)
+	if err != nil {
+		var dupErr service.DuplicateResourceVersionError
+		if errors.As(err, &dupErr) {
+			l.Debug().Msgf("Resource version %s is not newer than revision %d, skipping commit",
+				obj.GetResourceVersion(), revisionID)
+			return false
+		}
+		l.Error().Err(err).Msg("Error committing to tracker service")
+		return false
+	}
 
-			snapshot, patch, err := rps.Get(ctx, string(obj.GetUID()), revisionID)
-			if err != nil {
-				l.Error().Err(err).Msgf("Error loading snapshot/patch for revision %s", revisionID.String())
-				continue
-			}
+	snapshot, patch, err := rps.Get(ctx, objectID, revisionID)
+	if err != nil {
+		l.Error().Err(err).Msgf("Error loading snapshot/patch for revision %s", revisionID.String())
+		return true
+	}
 
-			if handleErr := handler.HandleRevision(obj, revisionID, snapshot, patch); handleErr != nil {
-				l.Error().Err(handleErr).Msg("Error handling revision")
-			}
+	if handleErr := handler.HandleRevision(objectID, obj, revisionID, snapshot, patch); handleErr != nil {
+		l.Error().Err(handleErr).Msg("Error handling revision")
+	}
+	return true
+}
+
+// deleteObject records the deletion of an object of a cluster as a
+// tombstone and hands it to the handler. It reports whether a revision was
+// written.
+func deleteObject(
+	ctx context.Context,
+	clusterName string,
+	obj *unstructured.Unstructured,
+	trackerService *service.TrackerService,
+	rps store.ResourcePatchStore,
+	handler revisionHandler,
+) bool {
+	l := log.With().
+		Str("cluster", clusterName).
+		Str("namespace", obj.GetNamespace()).
+		Str("name", obj.GetName()).
+		Str("kind", obj.GetKind()).
+		Logger()
+
+	objectID := store.ObjectID(clusterName, string(obj.GetUID()))
+	revisionID, err := trackerService.Delete(ctx, objectID)
+	if err != nil {
+		if !errors.Is(err, service.ErrAlreadyDeleted) && !errors.Is(err, store.ErrNotFound) {
+			l.Error().Err(err).Msg("Error recording deletion in tracker service")
 		}
+		return false
+	}
+
+	_, patch, err := rps.Get(ctx, objectID, revisionID)
+	if err != nil {
+		l.Error().Err(err).Msgf("Error loading tombstone for revision %s", revisionID.String())
+		return true
+	}
+	if handleErr := handler.HandleRevision(objectID, obj, revisionID, nil, patch); handleErr != nil {
+		l.Error().Err(handleErr).Msg("Error handling revision")
 	}
+	return true
 }
 
 func loadHistoryFromDB(
@@ -715,7 +937,7 @@ func loadHistoryFromDB(
 			// Deep-clone the previous state so Apply doesn't mutate it
 			// through shared nested map references.
 			base := resource.CloneMap(prev.Object)
-			diffmap.Apply(base, patch.Patch)
+			diffmap.ApplyFormat(base, patch.Patch, patch.Format)
 			current = &store.Snapshot{
 				ID:     revisionID,
 				Object: base,
@@ -727,7 +949,8 @@ func loadHistoryFromDB(
 		unstructuredObj := &unstructured.Unstructured{Object: current.Object}
 
 		// make sure we want to track this object
-		pass, err := expr.Run(filterExprProgram, util.EventEntryEnv{Object: unstructuredObj})
+		cluster, _ := store.SplitObjectID(objectUID)
+		pass, err := expr.Run(filterExprProgram, util.EventEntryEnv{Object: unstructuredObj, ClusterName: cluster})
 		if err != nil {
 			log.Error().Err(err).Msgf("Error executing filter expression for historic object %s/%s/%s",
 				unstructuredObj.GetNamespace(), unstructuredObj.GetName(), unstructuredObj.GetKind())
@@ -747,12 +970,35 @@ func loadHistoryFromDB(
 		// the handler derives the correct event type and PreviousID. `current`
 		// always has PreviousID 0, which made every historic revision look ADDED.
 		// The full reconstructed object travels in unstructuredObj.
-		if handleErr := handler.HandleRevision(unstructuredObj, revisionID, snapshot, patch); handleErr != nil {
+		if handleErr := handler.HandleRevision(objectUID, unstructuredObj, revisionID, snapshot, patch); handleErr != nil {
 			log.Error().Err(handleErr).Msg("Error handling historic revision")
 		}
 		return true
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	if gs, ok := rps.(store.GapStore); ok {
+		gaps, gapsErr := gs.Gaps()
+		if gapsErr != nil {
+			return fmt.Errorf("loading gaps: %w", gapsErr)
+		}
+		for _, gap := range gaps {
+			handler.HandleGap(gap)
+		}
+	}
+	if as, ok := rps.(store.AnnotationStore); ok {
+		// Annotations of objects the filter skipped are dropped by the handler.
+		err = as.WalkAnnotations(func(objectID string, a *store.Annotation) bool {
+			handler.HandleAnnotation(objectID, *a)
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("loading annotations: %w", err)
+		}
+	}
+	return nil
 }
 
 func validateArgsAndFlags(_ *cobra.Command, args []string) error {
@@ -774,9 +1020,18 @@ func validateArgsAndFlags(_ *cobra.Command, args []string) error {
 		return nil
 	}
 
-	if len(args) == 0 && outputFile == "" {
+	if len(args) == 0 && outputFile == "" && len(watchCRDs) == 0 {
 		return fmt.Errorf(
-			"at least one resource argument or the --output flag must be provided (you may provide both)")
+			"at least one resource argument, --watch-crds or the --output flag must be provided")
+	}
+	if followOwners && len(args) == 0 {
+		return fmt.Errorf("--follow-owners needs resource arguments to start from")
+	}
+	if err := validateCRDPatterns(watchCRDs); err != nil {
+		return err
+	}
+	if err := validateContexts(kubeContexts); err != nil {
+		return err
 	}
 
 	// Guard against silently appending to (and pre-loading) an existing capture.
@@ -789,9 +1044,13 @@ func validateArgsAndFlags(_ *cobra.Command, args []string) error {
 		}
 	}
 
+	if _, err := parseCacheMemory(cacheMemory); err != nil {
+		return err
+	}
+
 	// validate each provided resource argument
 	for _, a := range args {
-		if _, err := util.ParseGroupVersionResource(a); err != nil {
+		if err := validateResourceArg(a); err != nil {
 			return fmt.Errorf("invalid resource argument %q: %w", a, err)
 		}
 	}
@@ -799,6 +1058,45 @@ func validateArgsAndFlags(_ *cobra.Command, args []string) error {
 	return nil
 }
 
+// parseCacheMemory parses a --cache-memory value written as a Kubernetes
+// quantity ("512Mi", "2Gi", "300M") into bytes. Empty means the default.
+func parseCacheMemory(s string) (int64, error) {
+	if s == "" {
+		return service.DefaultCacheMemory, nil
+	}
+	q, err := apiresource.ParseQuantity(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --cache-memory %q: %w", s, err)
+	}
+	if q.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid --cache-memory %q: must be positive", s)
+	}
+	return q.Value(), nil
+}
+
+// logCacheStats reports the hot-state cache counters on shutdown so
+// --cache-memory can be tuned from a real session.
+func logCacheStats(trackerService *service.TrackerService) {
+	if trackerService == nil || disableCache {
+		return
+	}
+	st := trackerService.CacheStats()
+	lookups := st.Hits + st.Misses
+	if lookups == 0 {
+		return
+	}
+	setupLog.Info().
+		Uint64("hits", st.Hits).
+		Uint64("misses", st.Misses).
+		Float64("hit-ratio", float64(st.Hits)/float64(lookups)).
+		Uint64("evictions", st.Evictions).
+		Uint64("expired", st.Expired).
+		Int("entries", st.Entries).
+		Int64("bytes", st.Bytes).
+		Int64("budget", st.Budget).
+		Msg("State cache statistics")
+}
+
 func mustBind(flagName string, err error) {
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Failed to bind flag %s", flagName)
@@ -3,7 +3,12 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/loog-project/loog/pkg/diffpreview"
 )
 
 // resetFlags clears the package-level flag vars that validateArgsAndFlags reads,
@@ -14,6 +19,9 @@ func resetFlags() {
 	replayFile = ""
 	headlessMode = false
 	simulateMode = false
+	watchCRDs = nil
+	kubeContexts = nil
+	followOwners = false
 }
 
 func TestValidateArgsAndFlags(t *testing.T) {
@@ -67,6 +75,81 @@ func TestValidateArgsAndFlags(t *testing.T) {
 			setup:   func() { outputFile = existing; appendOutput = true },
 			wantErr: false,
 		},
+		{
+			name:    "resource with selectors",
+			setup:   func() {},
+			args:    []string{"v1/pods?labelSelector=app=api", "apps/v1/deployments?fieldSelector=metadata.name=x"},
+			wantErr: false,
+		},
+		{
+			name:    "resource with an invalid label selector is rejected",
+			setup:   func() {},
+			args:    []string{"v1/pods?labelSelector=app in (a"},
+			wantErr: true,
+		},
+		{
+			name:    "resource with an unknown option is rejected",
+			setup:   func() {},
+			args:    []string{"v1/pods?selector=app"},
+			wantErr: true,
+		},
+		{
+			name:    "metadata-only resource",
+			setup:   func() {},
+			args:    []string{"v1/secrets?metadataOnly=true", "configmaps?metadataOnly=1&labelSelector=app"},
+			wantErr: false,
+		},
+		{
+			name:    "resource with an invalid metadataOnly is rejected",
+			setup:   func() {},
+			args:    []string{"v1/secrets?metadataOnly=yes"},
+			wantErr: true,
+		},
+		{
+			name:    "short names, kinds and wildcards",
+			setup:   func() {},
+			args:    []string{"deploy", "Deployment.apps", "apps/*?labelSelector=app=api", "all"},
+			wantErr: false,
+		},
+		{
+			name:    "malformed wildcard is rejected",
+			setup:   func() {},
+			args:    []string{"apps/[*"},
+			wantErr: true,
+		},
+		{
+			name:    "several contexts",
+			setup:   func() { kubeContexts = []string{"staging", "prod"} },
+			args:    []string{"v1/pods"},
+			wantErr: false,
+		},
+		{
+			name:    "context given twice is rejected",
+			setup:   func() { kubeContexts = []string{"prod", "prod"} },
+			args:    []string{"v1/pods"},
+			wantErr: true,
+		},
+		{
+			name:    "watch CRDs without resource args",
+			setup:   func() { watchCRDs = []string{"*.example.com"} },
+			wantErr: false,
+		},
+		{
+			name:    "invalid CRD pattern is rejected",
+			setup:   func() { watchCRDs = []string{"[example.com"} },
+			wantErr: true,
+		},
+		{
+			name:    "follow owners of one object",
+			setup:   func() { followOwners = true },
+			args:    []string{"deploy/api"},
+			wantErr: false,
+		},
+		{
+			name:    "follow owners without resource args is rejected",
+			setup:   func() { followOwners = true; outputFile = filepath.Join(dir, "follow.loog") },
+			wantErr: true,
+		},
 		{
 			name:    "no args and no output is rejected",
 			setup:   func() {},
@@ -86,3 +169,38 @@ func TestValidateArgsAndFlags(t *testing.T) {
 	}
 	resetFlags()
 }
+
+func TestRegisterMergeKeys(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.SetConfigType("yaml")
+	cfg := "merge-keys:\n  - path: spec.podMetricsEndpoints\n    key: port\n"
+	if err := viper.ReadConfig(strings.NewReader(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerMergeKeys(); err != nil {
+		t.Fatalf("registerMergeKeys: %v", err)
+	}
+
+	a := map[string]any{"spec": map[string]any{"podMetricsEndpoints": []any{
+		map[string]any{"port": "a", "path": "/m"},
+		map[string]any{"port": "b", "path": "/m"},
+	}}}
+	b := map[string]any{"spec": map[string]any{"podMetricsEndpoints": []any{
+		map[string]any{"port": "b", "path": "/m"},
+		map[string]any{"port": "a", "path": "/m"},
+	}}}
+	got := diffpreview.RenderYAML(diffpreview.Diff(a, b), diffpreview.Theme{}, diffpreview.RenderOptions{ChangesOnly: true})
+	if got != "" {
+		t.Fatalf("reordered endpoints should match by the configured key, got diff:\n%s", got)
+	}
+
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader("merge-keys:\n  - path: spec.x\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerMergeKeys(); err == nil {
+		t.Fatal("entry without key should be rejected")
+	}
+}
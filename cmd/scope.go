@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// scopeLookup tells namespaced from cluster-scoped resources using the API
+// server's discovery, fetching each group version once. It is only needed
+// with --namespace, where cluster-scoped resources still have to be watched
+// cluster-wide.
+type scopeLookup struct {
+	disc discovery.DiscoveryInterface
+	// namespaced maps a group version to its resources' scopes.
+	namespaced map[schema.GroupVersion]map[string]bool
+}
+
+func newScopeLookup(disc discovery.DiscoveryInterface) *scopeLookup {
+	return &scopeLookup{disc: disc, namespaced: make(map[schema.GroupVersion]map[string]bool)}
+}
+
+// clusterScoped reports whether gvr is a cluster-scoped resource.
+func (l *scopeLookup) clusterScoped(gvr schema.GroupVersionResource) (bool, error) {
+	gv := gvr.GroupVersion()
+	scopes, ok := l.namespaced[gv]
+	if !ok {
+		list, err := l.disc.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			return false, fmt.Errorf("discovering resources of %s: %w", gv, err)
+		}
+		scopes = make(map[string]bool, len(list.APIResources))
+		for _, res := range list.APIResources {
+			scopes[res.Name] = res.Namespaced
+		}
+		l.namespaced[gv] = scopes
+	}
+	namespaced, ok := scopes[gvr.Resource]
+	if !ok {
+		return false, fmt.Errorf("resource %q not found in %s", gvr.Resource, gv)
+	}
+	return !namespaced, nil
+}
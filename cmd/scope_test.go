@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestScopeLookup(t *testing.T) {
+	fake := &kubetesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Namespaced: true},
+			{Name: "nodes", Namespaced: false},
+		},
+	}}}
+	l := newScopeLookup(&fakediscovery.FakeDiscovery{Fake: fake})
+
+	for res, want := range map[string]bool{"pods": false, "nodes": true} {
+		got, err := l.clusterScoped(schema.GroupVersionResource{Version: "v1", Resource: res})
+		if err != nil || got != want {
+			t.Errorf("clusterScoped(%s) = %v, %v; want %v", res, got, err, want)
+		}
+	}
+	if n := len(fake.Actions()); n != 1 {
+		t.Errorf("discovery called %d times, want once per group version", n)
+	}
+
+	if _, err := l.clusterScoped(schema.GroupVersionResource{Version: "v1", Resource: "widgets"}); err == nil {
+		t.Error("unknown resource should be an error")
+	}
+	if _, err := l.clusterScoped(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}); err == nil {
+		t.Error("unknown group version should be an error")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/loog-project/loog/internal/resource"
 	"github.com/loog-project/loog/internal/store"
 	"github.com/loog-project/loog/internal/util"
+	"github.com/loog-project/loog/pkg/diffmap"
 )
 
 // LiveRevisionMsg tells the TUI that a new revision has been ingested into the LiveStore.
@@ -29,11 +30,14 @@ type TUIRevisionHandler struct {
 // HandleRevision is called by the collector goroutine (and loadHistoryFromDB) for each
 // new or historic revision. It extracts resource metadata from the unstructured object,
 // builds a resource.Revision, ingests it into the LiveStore, and notifies the TUI.
+// The resource is keyed by objectID, the store's ID of the object (see
+// [store.ObjectID]), which also names its cluster.
 //
 // The obj parameter always contains the full object state at this revision:
 //   - In the collector path: obj is the live watch event object
 //   - In the history path: obj is reconstructed from snapshot + patches
 func (h *TUIRevisionHandler) HandleRevision(
+	objectID string,
 	obj *unstructured.Unstructured,
 	revisionID store.RevisionID,
 	snapshot *store.Snapshot,
@@ -43,23 +47,41 @@ func (h *TUIRevisionHandler) HandleRevision(
 		return fmt.Errorf("nil unstructured object")
 	}
 
-	uid := string(obj.GetUID())
+	cluster, _ := store.SplitObjectID(objectID)
 	kind := obj.GetKind()
 	name := obj.GetName()
 	namespace := obj.GetNamespace()
 
 	rev := buildRevision(obj, revisionID, snapshot, patch)
 
-	h.Store.IngestRevision(uid, kind, name, namespace, rev)
+	h.Store.IngestClusterRevision(cluster, objectID, kind, name, namespace, rev)
 
 	// Send message to TUI (non-blocking: bubbletea's Send is goroutine-safe)
 	if h.Program != nil {
-		h.Program.Send(LiveRevisionMsg{ResourceUID: uid})
+		h.Program.Send(LiveRevisionMsg{ResourceUID: objectID})
 	}
 
 	return nil
 }
 
+// HandleGap records a span in which recording was paused and notifies the
+// TUI, which shows it in the timeline.
+func (h *TUIRevisionHandler) HandleGap(gap store.Gap) {
+	h.Store.AddGap(gap)
+	if h.Program != nil {
+		h.Program.Send(LiveRevisionMsg{})
+	}
+}
+
+// HandleAnnotation adds an annotation to its resource, which must already
+// be in the LiveStore, and notifies the TUI.
+func (h *TUIRevisionHandler) HandleAnnotation(objectID string, a store.Annotation) {
+	h.Store.AddAnnotation(objectID, a)
+	if h.Program != nil {
+		h.Program.Send(LiveRevisionMsg{ResourceUID: objectID})
+	}
+}
+
 // buildRevision constructs a resource.Revision from the production store types.
 // The full object is always taken from obj.Object (available in both live and history paths).
 // Snapshot/patch provide metadata (time, previousID) and the patch diff.
@@ -94,8 +116,13 @@ func buildRevision(
 	} else if patch != nil {
 		rev.PreviousID = patch.PreviousID
 		rev.Time = patch.Time
-		rev.Patch = resource.CloneMap(patch.Patch)
+		// Normalize to the current change-set format so patches from older
+		// captures can be composed with new ones.
+		rev.Patch = diffmap.Upgrade(resource.CloneMap(patch.Patch), patch.Format)
 		rev.EventType = resource.EventModified
+		if patch.Deleted {
+			rev.EventType = resource.EventDeleted
+		}
 	}
 
 	return rev
@@ -80,3 +80,38 @@ func TestBuildRevision_FirstSnapshotIsAdded(t *testing.T) {
 		t.Errorf("first snapshot event = %v, want ADDED", rev.EventType)
 	}
 }
+
+// A tombstone patch is DELETED.
+func TestBuildRevision_TombstoneIsDeleted(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{"kind": "Pod"}}
+	patch := &store.Patch{PreviousID: 3, Time: time.Now(), Deleted: true}
+	rev := buildRevision(obj, 4, nil, patch)
+	if rev.EventType != resource.EventDeleted {
+		t.Errorf("tombstone event = %v, want DELETED", rev.EventType)
+	}
+}
+
+// The same UID recorded from two clusters stays two resources, each tagged
+// with its cluster.
+func TestHandleRevision_TagsCluster(t *testing.T) {
+	s := NewLiveStore()
+	h := &TUIRevisionHandler{Store: s}
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"kind":     "Pod",
+		"metadata": map[string]any{"uid": "u1", "name": "p", "namespace": "default"},
+	}}
+	snap := &store.Snapshot{Time: time.Now()}
+	for _, cluster := range []string{"staging", "prod"} {
+		if err := h.HandleRevision(store.ObjectID(cluster, "u1"), obj, 1, snap, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := s.TotalResourceCount(); n != 2 {
+		t.Fatalf("expected 2 resources, got %d", n)
+	}
+	rd := s.GetResource(store.ObjectID("prod", "u1"))
+	if rd == nil || rd.Resource.Cluster != "prod" {
+		t.Fatalf("prod resource = %+v", rd)
+	}
+}
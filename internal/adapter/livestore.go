@@ -25,11 +25,15 @@ type LiveStore struct {
 	// Precomputed kind groups (rebuilt on demand via RebuildKindGroups)
 	kindGroups []*resource.KindGroup
 
-	// Watched kinds (e.g., "Pod", "Deployment")
-	watchedKinds map[string]bool
+	// Watched kinds (e.g., "Pod", "Deployment"). Kinds only seen in events
+	// have just their name set.
+	watchedKinds map[string]resource.Kind
 
-	// Unwatched kinds available on the cluster (populated externally)
-	unwatchedKinds []resource.Kind
+	// Kinds available on the cluster (populated externally)
+	clusterKinds []resource.Kind
+
+	// Spans in which recording was paused, oldest first
+	gaps []resource.Gap
 
 	// Cached totals for fast access
 	totalRevisions int
@@ -39,7 +43,7 @@ type LiveStore struct {
 func NewLiveStore() *LiveStore {
 	return &LiveStore{
 		resources:    make(map[string]*resource.Data),
-		watchedKinds: make(map[string]bool),
+		watchedKinds: make(map[string]resource.Kind),
 	}
 }
 
@@ -48,6 +52,16 @@ func NewLiveStore() *LiveStore {
 func (s *LiveStore) IngestRevision(
 	uid, kind, name, namespace string,
 	rev resource.Revision,
+) {
+	s.IngestClusterRevision("", uid, kind, name, namespace, rev)
+}
+
+// IngestClusterRevision is [LiveStore.IngestRevision] for a resource of a
+// named cluster. uid must be unique across clusters, like the store's
+// cluster-qualified object IDs.
+func (s *LiveStore) IngestClusterRevision(
+	cluster, uid, kind, name, namespace string,
+	rev resource.Revision,
 ) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -60,14 +74,17 @@ func (s *LiveStore) IngestRevision(
 				Kind:      kind,
 				Name:      name,
 				Namespace: namespace,
+				Cluster:   cluster,
 			},
 		}
 		s.resources[uid] = rd
-		s.watchedKinds[kind] = true
+		if _, ok := s.watchedKinds[kind]; !ok {
+			s.watchedKinds[kind] = resource.Kind{Kind: kind}
+		}
 	}
 
 	// Create a new Data snapshot with the appended revision (copy-on-write).
-	newData := s.appendRevision(uid, rd, rev)
+	newData := s.appendRevision(uid, rd, &rev)
 	s.totalRevisions++
 
 	// Prepend to timeline (newest-first)
@@ -156,7 +173,7 @@ func (s *LiveStore) FilterTimeline(expr string, starredOnly bool) []resource.Tim
 				continue
 			}
 		}
-		if expr != "" && !resource.MatchesSubstring(lower, e.Resource) {
+		if expr != "" && !resource.MatchesTimelineEntry(lower, e) {
 			continue
 		}
 		result = append(result, e)
@@ -198,6 +215,26 @@ func (s *LiveStore) WatchedKinds() []string {
 	return kinds
 }
 
+// WatchedKind returns the resource type of a watched kind, from the cluster
+// kinds when it was only seen in events.
+func (s *LiveStore) WatchedKind(kind string) resource.Kind {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rk, ok := s.watchedKinds[kind]
+	if !ok {
+		rk = resource.Kind{Kind: kind}
+	}
+	if rk.Resource == "" {
+		for _, ck := range s.clusterKinds {
+			if ck.Kind == kind {
+				return ck
+			}
+		}
+	}
+	return rk
+}
+
 func (s *LiveStore) ResourceCountByKind(kind string) int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -228,10 +265,14 @@ func (s *LiveStore) UnwatchedKinds() []resource.Kind {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Return a copy: callers (e.g. the watch manager) retain this slice across
-	// render frames, and AddWatchKind must not mutate the array underneath them.
-	out := make([]resource.Kind, len(s.unwatchedKinds))
-	copy(out, s.unwatchedKinds)
+	// Return a fresh slice: callers (e.g. the watch manager) retain it across
+	// render frames, and must not see later changes underneath them.
+	out := make([]resource.Kind, 0, len(s.clusterKinds))
+	for _, ck := range s.clusterKinds {
+		if _, watched := s.watchedKinds[ck.Kind]; !watched {
+			out = append(out, ck)
+		}
+	}
 	return out
 }
 
@@ -239,28 +280,25 @@ func (s *LiveStore) AddWatchKind(rk resource.Kind) []*resource.Data {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.watchedKinds[rk.Kind] = true
-
-	// Remove from unwatched list. Allocate a fresh slice instead of reusing the
-	// backing array with [:0]; the old array may still be held by the UI.
-	filtered := make([]resource.Kind, 0, len(s.unwatchedKinds))
-	for _, uk := range s.unwatchedKinds {
-		if uk.Kind != rk.Kind {
-			filtered = append(filtered, uk)
-		}
-	}
-	s.unwatchedKinds = filtered
+	s.watchedKinds[rk.Kind] = rk
 
 	// In production mode, adding a watch kind triggers mux.Add() which is
 	// handled externally by the caller. We don't create synthetic resources here.
 	return nil
 }
 
-func (s *LiveStore) RemoveWatchKind(kind string) {
+// RemoveWatchKind stops listing the kind as watched; stopping the watch
+// itself is up to the caller. Its resources are removed unless keepData is
+// set.
+func (s *LiveStore) RemoveWatchKind(rk resource.Kind, keepData bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	kind := rk.Kind
 	delete(s.watchedKinds, kind)
+	if keepData {
+		return
+	}
 
 	// Remove all resources of this kind
 	for uid, rd := range s.resources {
@@ -292,8 +330,9 @@ func (s *LiveStore) ToggleStar(uid string) bool {
 	newResource := rd.Resource
 	newResource.Starred = !newResource.Starred
 	newData := &resource.Data{
-		Resource:  newResource,
-		Revisions: rd.Revisions,
+		Resource:    newResource,
+		Revisions:   rd.Revisions,
+		Annotations: rd.Annotations,
 	}
 	s.resources[uid] = newData
 	for i := range s.timeline {
@@ -314,7 +353,7 @@ func (s *LiveStore) AddRevision(resourceUID string, rev resource.Revision) {
 	}
 
 	// Create a new Data snapshot with the appended revision (copy-on-write).
-	newData := s.appendRevision(resourceUID, rd, rev)
+	newData := s.appendRevision(resourceUID, rd, &rev)
 	s.totalRevisions++
 
 	entry := resource.TimelineEntry{
@@ -326,6 +365,68 @@ func (s *LiveStore) AddRevision(resourceUID string, rev resource.Revision) {
 	s.timeline[0] = entry
 }
 
+// AddAnnotation adds an annotation to a resource, replacing the one with
+// the same ID. Annotations of unknown resources are dropped.
+func (s *LiveStore) AddAnnotation(uid string, a resource.Annotation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rd, ok := s.resources[uid]
+	if !ok {
+		return
+	}
+	// Copy-on-write, like revisions.
+	annotations := slices.DeleteFunc(slices.Clone(rd.Annotations), func(old resource.Annotation) bool {
+		return old.ID == a.ID
+	})
+	i, _ := slices.BinarySearchFunc(annotations, a, func(x, y resource.Annotation) int {
+		return x.Time.Compare(y.Time)
+	})
+	s.resources[uid] = &resource.Data{
+		Resource:    rd.Resource,
+		Revisions:   rd.Revisions,
+		Annotations: slices.Insert(annotations, i, a),
+	}
+}
+
+// Annotations returns the annotations of all resources, or of the starred
+// ones, oldest first.
+func (s *LiveStore) Annotations(starredOnly bool) []resource.TimelineAnnotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []resource.TimelineAnnotation
+	for _, rd := range s.resources {
+		if starredOnly && !rd.Resource.Starred {
+			continue
+		}
+		for _, a := range rd.Annotations {
+			out = append(out, resource.TimelineAnnotation{Resource: rd.Resource, Annotation: a})
+		}
+	}
+	slices.SortStableFunc(out, func(x, y resource.TimelineAnnotation) int {
+		return x.Annotation.Time.Compare(y.Annotation.Time)
+	})
+	return out
+}
+
+// AddGap records a span in which recording was paused.
+func (s *LiveStore) AddGap(gap resource.Gap) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, _ := slices.BinarySearchFunc(s.gaps, gap, func(a, b resource.Gap) int {
+		return a.Start.Compare(b.Start)
+	})
+	s.gaps = slices.Insert(s.gaps, i, gap)
+}
+
+func (s *LiveStore) Gaps() []resource.Gap {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.gaps)
+}
+
 func (s *LiveStore) RebuildKindGroups() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -341,9 +442,7 @@ func (s *LiveStore) RebuildKindGroups() {
 func (s *LiveStore) SortTimeline() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	slices.SortStableFunc(s.timeline, func(a, b resource.TimelineEntry) int {
-		return resource.CompareRevisionsNewestFirst(a.Revision, b.Revision)
-	})
+	slices.SortStableFunc(s.timeline, resource.CompareEntriesNewestFirst)
 }
 
 func (s *LiveStore) ForEachResource(fn func(uid string, rd *resource.Data)) {
@@ -367,7 +466,8 @@ func (s *LiveStore) ForEachResource(fn func(uid string, rd *resource.Data)) {
 	}
 }
 
-// SetUnwatchedKinds sets the list of unwatched resource kinds available on the cluster.
+// SetUnwatchedKinds sets the list of resource kinds available on the
+// cluster; UnwatchedKinds returns those not watched.
 func (s *LiveStore) SetUnwatchedKinds(kinds []resource.Kind) {
 	// Copy so the store owns its backing array independent of the caller.
 	owned := make([]resource.Kind, len(kinds))
@@ -375,7 +475,7 @@ func (s *LiveStore) SetUnwatchedKinds(kinds []resource.Kind) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.unwatchedKinds = owned
+	s.clusterKinds = owned
 }
 
 func (s *LiveStore) allResourcesLocked() []*resource.Data {
@@ -390,13 +490,17 @@ func (s *LiveStore) allResourcesLocked() []*resource.Data {
 // appendRevision creates a new *resource.Data with the revision appended,
 // stores it in the map, and returns it. The old pointer remains valid for
 // anyone who already holds it (copy-on-write).
-func (s *LiveStore) appendRevision(uid string, rd *resource.Data, rev resource.Revision) *resource.Data {
+func (s *LiveStore) appendRevision(uid string, rd *resource.Data, rev *resource.Revision) *resource.Data {
+	if rev.ChangedPaths == nil {
+		rev.ChangedPaths = resource.ChangedPaths(rd.LatestRevision(), *rev)
+	}
 	newRevisions := make([]resource.Revision, len(rd.Revisions)+1)
 	copy(newRevisions, rd.Revisions)
-	newRevisions[len(rd.Revisions)] = rev
+	newRevisions[len(rd.Revisions)] = *rev
 	newData := &resource.Data{
-		Resource:  rd.Resource,
-		Revisions: newRevisions,
+		Resource:    rd.Resource,
+		Revisions:   newRevisions,
+		Annotations: rd.Annotations,
 	}
 	s.resources[uid] = newData
 	return newData
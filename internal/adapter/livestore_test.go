@@ -1,6 +1,7 @@
 package adapter
 
 import (
+	"slices"
 	"sync"
 	"testing"
 
@@ -113,3 +114,75 @@ func TestConcurrentIngestAndRead(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestIngestRevision_ChangedPaths verifies that ingest records the changed
+// field paths, from the patch or by diffing snapshots, and that the timeline
+// filter can match on them.
+func TestIngestRevision_ChangedPaths(t *testing.T) {
+	s := NewLiveStore()
+	obj := func(replicas int, image string) map[string]any {
+		return map[string]any{"spec": map[string]any{
+			"replicas": replicas,
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "app", "image": image},
+			}}},
+		}}
+	}
+	s.IngestRevision("uid", "Deployment", "web", "default", resource.Revision{ID: 1, Object: obj(1, "nginx:1")})
+	// Snapshot revision: no patch, paths come from diffing the previous one.
+	s.IngestRevision("uid", "Deployment", "web", "default", resource.Revision{ID: 2, PreviousID: 1, Object: obj(1, "nginx:2")})
+	s.IngestRevision("uid", "Deployment", "web", "default", resource.Revision{
+		ID: 3, PreviousID: 2, Object: obj(3, "nginx:2"),
+		Patch: map[string]any{"spec": map[string]any{"replicas": 3}},
+	})
+
+	revs := s.GetResource("uid").Revisions
+	if len(revs[0].ChangedPaths) != 0 {
+		t.Errorf("first revision paths = %q, want none", revs[0].ChangedPaths)
+	}
+	if got, want := revs[1].ChangedPaths, []string{"spec.template.spec.containers[name=app].image"}; !slices.Equal(got, want) {
+		t.Errorf("snapshot revision paths = %q, want %q", got, want)
+	}
+	if got, want := revs[2].ChangedPaths, []string{"spec.replicas"}; !slices.Equal(got, want) {
+		t.Errorf("patch revision paths = %q, want %q", got, want)
+	}
+
+	got := s.FilterTimeline("path:containers[name=app]", false)
+	if len(got) != 1 || got[0].Revision.ID != 2 {
+		t.Fatalf("path filter matched %+v, want only revision 2", got)
+	}
+}
+
+// TestRemoveWatchKind verifies unwatching a kind lists it as available
+// again, and removes its resources unless they are kept.
+func TestRemoveWatchKind(t *testing.T) {
+	pod := resource.Kind{Kind: "Pod", APIVersion: "v1", Resource: "pods", Namespaced: true}
+	svc := resource.Kind{Kind: "Service", APIVersion: "v1", Resource: "services", Namespaced: true}
+	s := NewLiveStore()
+	s.SetUnwatchedKinds([]resource.Kind{pod, svc})
+	s.IngestRevision("uid-1", "Pod", "nginx", "default", resource.Revision{ID: 1})
+	s.IngestRevision("uid-2", "Service", "web", "default", resource.Revision{ID: 2})
+
+	if got := s.UnwatchedKinds(); len(got) != 0 {
+		t.Fatalf("unwatched kinds = %+v, want none", got)
+	}
+	// Kinds seen in events are completed from the cluster kinds.
+	if got := s.WatchedKind("Pod"); got != pod {
+		t.Fatalf("WatchedKind(Pod) = %+v, want %+v", got, pod)
+	}
+
+	s.RemoveWatchKind(pod, true)
+	if s.GetResource("uid-1") == nil || s.TotalRevisionCount() != 2 {
+		t.Error("kept Pod data was removed")
+	}
+	s.RemoveWatchKind(svc, false)
+	if s.GetResource("uid-2") != nil || s.TotalRevisionCount() != 1 || len(s.Timeline()) != 1 {
+		t.Error("Service data was not removed")
+	}
+	if got := s.WatchedKinds(); len(got) != 0 {
+		t.Errorf("watched kinds = %v, want none", got)
+	}
+	if got := s.UnwatchedKinds(); len(got) != 2 {
+		t.Errorf("unwatched kinds = %+v, want Pod and Service", got)
+	}
+}
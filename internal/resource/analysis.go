@@ -438,3 +438,67 @@ func toMapSlice(items []any) []map[string]any {
 	}
 	return result
 }
+
+// ChangedPaths returns the field paths [rev] changed relative to [prev], the
+// revision before it (nil for the first one). The stored patch is used when
+// there is one; snapshot revisions are diffed against [prev] instead.
+func ChangedPaths(prev *Revision, rev Revision) []string {
+	if rev.Patch != nil {
+		return diffmap.Paths(rev.Patch)
+	}
+	if prev == nil || prev.Object == nil || rev.Object == nil {
+		return nil
+	}
+	return diffmap.Paths(diffmap.Diff(prev.Object, rev.Object))
+}
+
+// WithoutServerManagedPaths returns the changed field [paths] that aren't
+// metadata the API server maintains, such as the resourceVersion every
+// revision changes.
+func WithoutServerManagedPaths(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if !isServerManagedPath(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func isServerManagedPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "metadata.")
+	if !ok {
+		return false
+	}
+	for _, field := range serverManagedMetadata {
+		if after, ok := strings.CutPrefix(rest, field); ok &&
+			(after == "" || after[0] == '.' || after[0] == '[') {
+			return true
+		}
+	}
+	return false
+}
+
+// serverManagedMetadata are the metadata fields the API server maintains.
+var serverManagedMetadata = []string{
+	"creationTimestamp", "deletionGracePeriodSeconds", "deletionTimestamp",
+	"generation", "managedFields", "resourceVersion", "selfLink", "uid",
+}
+
+// PatchableObject returns a copy of [obj] without its status and the
+// metadata the API server maintains, for patches that apply to the live
+// object: the API server takes a resourceVersion in a patch as a
+// precondition and rejects it once the object changed.
+func PatchableObject(obj map[string]any) map[string]any {
+	if obj == nil {
+		return nil
+	}
+	out := CloneMap(obj)
+	delete(out, "status")
+	if meta, ok := out["metadata"].(map[string]any); ok {
+		for _, field := range serverManagedMetadata {
+			delete(meta, field)
+		}
+	}
+	return out
+}
@@ -7,16 +7,19 @@ import (
 )
 
 // BuildKindGroups organizes resources into kind groups for tree display.
-// Groups are sorted in a preferred Kubernetes kind order, and resources
-// within each group are sorted by name.
+// Resources of different clusters get groups of their own, sorted by
+// cluster first. Groups are sorted in a preferred Kubernetes kind order, and
+// resources within each group are sorted by name.
 func BuildKindGroups(resources []*Data) []*KindGroup {
 	kindMap := make(map[string]*KindGroup)
 	for _, rd := range resources {
-		k := rd.Resource.Kind
-		if _, ok := kindMap[k]; !ok {
-			kindMap[k] = &KindGroup{Kind: k, Expanded: true}
+		g := &KindGroup{Kind: rd.Resource.Kind, Cluster: rd.Resource.Cluster, Expanded: true}
+		if existing, ok := kindMap[g.Key()]; ok {
+			g = existing
+		} else {
+			kindMap[g.Key()] = g
 		}
-		kindMap[k].Resources = append(kindMap[k].Resources, rd)
+		g.Resources = append(g.Resources, rd)
 	}
 
 	kindOrder := map[string]int{
@@ -32,6 +35,9 @@ func BuildKindGroups(resources []*Data) []*KindGroup {
 		groups = append(groups, g)
 	}
 	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Cluster != groups[j].Cluster {
+			return groups[i].Cluster < groups[j].Cluster
+		}
 		oi, ok1 := kindOrder[groups[i].Kind]
 		oj, ok2 := kindOrder[groups[j].Kind]
 		if ok1 && ok2 {
@@ -112,12 +118,22 @@ func CompareRevisionsNewestFirst(a, b Revision) int {
 	return b.Time.Compare(a.Time)
 }
 
+// CompareEntriesNewestFirst is [CompareRevisionsNewestFirst] for timeline
+// entries. resourceVersions of different clusters are unrelated, so those
+// entries are ordered by time only.
+func CompareEntriesNewestFirst(a, b TimelineEntry) int {
+	if a.Resource.Cluster != b.Resource.Cluster {
+		return b.Revision.Time.Compare(a.Revision.Time)
+	}
+	return CompareRevisionsNewestFirst(a.Revision, b.Revision)
+}
+
 // SortTimelineNewestFirst sorts timeline entries in place, newest-first, using
 // causal order where resourceVersion is available (see
-// CompareRevisionsNewestFirst).
+// CompareEntriesNewestFirst).
 func SortTimelineNewestFirst(entries []TimelineEntry) {
 	sort.SliceStable(entries, func(i, j int) bool {
-		return CompareRevisionsNewestFirst(entries[i].Revision, entries[j].Revision) < 0
+		return CompareEntriesNewestFirst(entries[i], entries[j]) < 0
 	})
 }
 
@@ -166,20 +182,65 @@ func cloneSlice(s []any) []any {
 	return cloned
 }
 
+// ClusterFilterPrefix starts a filter that only matches resources of the
+// clusters whose name contains the word after it, optionally followed by a
+// regular query, e.g. "cluster:prod" or "cluster:prod nginx".
+const ClusterFilterPrefix = "cluster:"
+
 // MatchesSubstring returns true if the query (already lowercased) appears as a
-// case-insensitive substring in any of the resource's name, kind, namespace, or
-// kind/name combination. Returns true for an empty query.
+// case-insensitive substring in any of the resource's name, kind, namespace,
+// cluster, or kind/name combination. Returns true for an empty query. See
+// [ClusterFilterPrefix] for restricting the query to clusters.
 func MatchesSubstring(query string, r Resource) bool {
+	if rest, ok := strings.CutPrefix(query, ClusterFilterPrefix); ok {
+		cluster, rest, _ := strings.Cut(rest, " ")
+		if !strings.Contains(strings.ToLower(r.Cluster), cluster) {
+			return false
+		}
+		query = strings.TrimSpace(rest)
+	}
 	if query == "" {
 		return true
 	}
 	// Build a single lowercased string to search; cheaper than four
 	// separate ToLower+Contains calls. The "/" separator is the same one
 	// used by KindName(), so kind/name queries still work.
-	haystack := strings.ToLower(r.Kind + "/" + r.Name + " " + r.Namespace)
+	haystack := strings.ToLower(r.Kind + "/" + r.Name + " " + r.Namespace + " " + r.Cluster)
 	return strings.Contains(haystack, query)
 }
 
+// PathFilterPrefix starts a timeline filter that matches changed field paths
+// instead of resource names, e.g. "path:containers[name=app].image".
+const PathFilterPrefix = "path:"
+
+// MatchesTimelineEntry is [MatchesSubstring] for timeline entries. A query
+// starting with [PathFilterPrefix] matches entries whose revision changed a
+// field path containing the rest of the query.
+func MatchesTimelineEntry(query string, e TimelineEntry) bool {
+	sub, ok := strings.CutPrefix(query, PathFilterPrefix)
+	if !ok {
+		return MatchesSubstring(query, e.Resource)
+	}
+	for _, p := range e.Revision.ChangedPaths {
+		if strings.Contains(strings.ToLower(p), sub) {
+			return true
+		}
+	}
+	return sub == ""
+}
+
+// MatchesTimelineAnnotation reports whether a timeline annotation matches a
+// lowercased filter query: by its resource, like [MatchesTimelineEntry], or
+// by its reason or message. Changed-path queries match no annotation.
+func MatchesTimelineAnnotation(query string, a TimelineAnnotation) bool {
+	if strings.HasPrefix(query, PathFilterPrefix) {
+		return false
+	}
+	return MatchesSubstring(query, a.Resource) ||
+		strings.Contains(strings.ToLower(a.Annotation.Reason), query) ||
+		strings.Contains(strings.ToLower(a.Annotation.Message), query)
+}
+
 // SortByKindName sorts a slice of [*Data] by kind then name (ascending).
 func SortByKindName(rds []*Data) {
 	sort.Slice(rds, func(i, j int) bool {
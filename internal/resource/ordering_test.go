@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -54,3 +55,49 @@ func TestSortTimelineNewestFirst_CausalOrder(t *testing.T) {
 			entries[0].Revision.ResourceVersion, entries[1].Revision.ResourceVersion)
 	}
 }
+
+// resourceVersions of different clusters are unrelated; only time orders them.
+func TestSortTimelineNewestFirst_AcrossClusters(t *testing.T) {
+	base := time.Now()
+	entries := []TimelineEntry{
+		{Resource: Resource{Cluster: "prod"}, Revision: Revision{ResourceVersion: 900, Time: base}},
+		{Resource: Resource{Cluster: "staging"}, Revision: Revision{ResourceVersion: 10, Time: base.Add(time.Second)}},
+	}
+	SortTimelineNewestFirst(entries)
+	if entries[0].Resource.Cluster != "staging" {
+		t.Errorf("expected the later staging entry first, got %s", entries[0].Resource.Cluster)
+	}
+}
+
+func TestBuildKindGroups_SplitsClusters(t *testing.T) {
+	rds := []*Data{
+		{Resource: Resource{Kind: "Pod", Name: "a", Cluster: "staging"}},
+		{Resource: Resource{Kind: "Pod", Name: "b", Cluster: "prod"}},
+		{Resource: Resource{Kind: "Deployment", Name: "c", Cluster: "prod"}},
+	}
+	groups := BuildKindGroups(rds)
+	var keys []string
+	for _, g := range groups {
+		keys = append(keys, g.Key())
+	}
+	want := []string{"prod/Pod", "prod/Deployment", "staging/Pod"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("groups = %v, want %v", keys, want)
+	}
+}
+
+func TestMatchesSubstring_ClusterPrefix(t *testing.T) {
+	r := Resource{Kind: "Pod", Name: "nginx", Namespace: "web", Cluster: "prod-eu"}
+	for query, want := range map[string]bool{
+		"prod":                true,
+		"cluster:prod":        true,
+		"cluster:prod nginx":  true,
+		"cluster:prod redis":  false,
+		"cluster:staging":     false,
+		"cluster:staging pod": false,
+	} {
+		if got := MatchesSubstring(query, r); got != want {
+			t.Errorf("MatchesSubstring(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
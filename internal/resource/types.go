@@ -43,7 +43,10 @@ type Resource struct {
 	Kind      string
 	Name      string
 	Namespace string
-	Starred   bool
+	// Cluster is the kubeconfig context the resource was recorded from, or
+	// empty when a single cluster is recorded.
+	Cluster string
+	Starred bool
 }
 
 // KindName returns "Kind/name" (e.g., "Pod/nginx-abc").
@@ -78,6 +81,24 @@ type Revision struct {
 	// loog's observation time and can invert for near-simultaneous events on
 	// separate watch streams.
 	ResourceVersion uint64
+	// ChangedPaths lists the fields this revision changed, as returned by
+	// diffmap.Paths. Stores fill it in on ingest; see [ChangedPaths].
+	ChangedPaths []string
+}
+
+// Gap is a span in which recording was paused.
+// It is a type alias for store.Gap.
+type Gap = store.Gap
+
+// Annotation is a note about a resource recorded alongside its revisions,
+// such as a Kubernetes Event involving it.
+// It is a type alias for store.Annotation.
+type Annotation = store.Annotation
+
+// TimelineAnnotation is an annotation shown in the unified timeline.
+type TimelineAnnotation struct {
+	Resource   Resource
+	Annotation Annotation
 }
 
 // TimelineEntry represents a single entry in the unified timeline.
@@ -100,8 +121,9 @@ type CompareItem struct {
 
 // Data holds a resource and all its revisions.
 type Data struct {
-	Resource  Resource
-	Revisions []Revision // sorted oldest-first (index 0 = oldest)
+	Resource    Resource
+	Revisions   []Revision   // sorted oldest-first (index 0 = oldest)
+	Annotations []Annotation // sorted oldest-first
 }
 
 // LatestRevision returns the most recent revision, or nil if empty.
@@ -158,10 +180,20 @@ func (rd *Data) ChangeFrequency() float64 {
 // KindGroup represents a collapsible group in the resource tree.
 type KindGroup struct {
 	Kind      string
+	Cluster   string // empty unless several clusters are recorded
 	Resources []*Data
 	Expanded  bool
 }
 
+// Key identifies the group in the tree: the kind, qualified by the cluster
+// when there is one (e.g. "prod/Deployment").
+func (g *KindGroup) Key() string {
+	if g.Cluster == "" {
+		return g.Kind
+	}
+	return g.Cluster + "/" + g.Kind
+}
+
 // Kind represents a Kubernetes resource type (CRD or built-in)
 // available on the cluster.
 type Kind struct {
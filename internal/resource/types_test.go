@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -632,3 +633,47 @@ func TestKind_GVR(t *testing.T) {
 		t.Errorf("Kind.GVR() = %q, want %q", got, want)
 	}
 }
+
+func TestPatchableObject(t *testing.T) {
+	obj := map[string]any{
+		"metadata": map[string]any{
+			"name":            "web",
+			"resourceVersion": "42",
+			"uid":             "u1",
+			"generation":      int64(3),
+			"labels":          map[string]any{"app": "web"},
+		},
+		"spec":   map[string]any{"replicas": int64(2)},
+		"status": map[string]any{"readyReplicas": int64(2)},
+	}
+	got := PatchableObject(obj)
+	want := map[string]any{
+		"metadata": map[string]any{"name": "web", "labels": map[string]any{"app": "web"}},
+		"spec":     map[string]any{"replicas": int64(2)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PatchableObject = %v, want %v", got, want)
+	}
+	if obj["metadata"].(map[string]any)["resourceVersion"] != "42" || obj["status"] == nil {
+		t.Error("PatchableObject modified its argument")
+	}
+}
+
+func TestWithoutServerManagedPaths(t *testing.T) {
+	got := WithoutServerManagedPaths([]string{
+		"metadata.generation",
+		"metadata.generationLabel",
+		"metadata.labels.app",
+		"metadata.managedFields[manager=kubectl]",
+		"metadata.resourceVersion",
+		"spec.replicas",
+		"status.observedGeneration",
+	})
+	want := []string{"metadata.generationLabel", "metadata.labels.app", "spec.replicas", "status.observedGeneration"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithoutServerManagedPaths = %q, want %q", got, want)
+	}
+	if got := WithoutServerManagedPaths([]string{"metadata.resourceVersion"}); len(got) != 0 {
+		t.Errorf("WithoutServerManagedPaths = %q, want none", got)
+	}
+}
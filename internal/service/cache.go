@@ -1,6 +1,7 @@
 package service
 
 import (
+	"container/list"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,10 +11,21 @@ import (
 )
 
 const (
-	cacheSweepEvery   = 10 * time.Second // janitor wake-up
-	ttlBase           = 40 * time.Second // cold entry expires after this
-	ttlHitBonus       = 4 * time.Second  // each extra read adds this much TTL
-	maxTrackedEntries = 100_000          // hard memory cap (~few 100 MB)
+	cacheSweepEvery    = 10 * time.Second // janitor wake-up
+	ttlBase            = 40 * time.Second // cold entry expires after this
+	ttlHitBonus        = 4 * time.Second  // each extra read adds this much TTL
+	DefaultCacheMemory = 256 << 20        // default byte budget (256 MiB)
+	cacheEntryOverhead = 128              // trackerState + list element + map slot
+)
+
+// Rough per-value heap costs used by approxSize. They don't need to be exact,
+// only proportional, so a 2 MB CRD weighs ~1000x a small ConfigMap.
+const (
+	sizeInterface = 16 // interface header stored in a map slot or []any
+	sizeMapHeader = 48 // hmap header
+	sizeMapEntry  = 16 // bucket overhead per key (tophash, key string header)
+	sizeSlice     = 24 // slice header
+	sizeScalar    = 8  // boxed number/bool payload
 )
 
 // trackerState is a cache entry for a tracked object.
@@ -22,20 +34,55 @@ type trackerState struct {
 	rev      store.RevisionID
 	lastRead int64 // unix-nsec; atomic
 	hitCount uint32
+
+	// guarded by stateCache.mu
+	uid  string
+	size int64
+	elem *list.Element
 }
 
-// stateCache is a cache of trackerState objects.
+// CacheStats is a point-in-time view of the hot-state cache counters, meant
+// for tuning --cache-memory.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // entries dropped to stay within the byte budget
+	Expired   uint64 // entries dropped by the TTL janitor
+	Entries   int
+	Bytes     int64 // approximate bytes held
+	Budget    int64
+}
+
+// stateCache is a byte-budgeted LRU cache of trackerState objects. Sizes are
+// approximated from the decoded object so one huge CRD costs as much as many
+// small ConfigMaps. When an insert would exceed the budget, the least recently
+// used entries are evicted instead of refusing the new key.
 type stateCache struct {
-	mu        sync.RWMutex
-	data      map[string]*trackerState
+	mu     sync.Mutex
+	data   map[string]*trackerState
+	lru    *list.List // front = most recently used
+	used   int64
+	budget int64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	expired   atomic.Uint64
+
 	stopCh    chan struct{}
 	closeOnce sync.Once
 }
 
-// newStateCache returns a new state cache with a janitor that evicts cold entries.
-func newStateCache() *stateCache {
+// newStateCache returns a new state cache holding roughly budget bytes, with a
+// janitor that evicts cold entries. A budget <= 0 uses DefaultCacheMemory.
+func newStateCache(budget int64) *stateCache {
+	if budget <= 0 {
+		budget = DefaultCacheMemory
+	}
 	c := &stateCache{
 		data:   make(map[string]*trackerState, 1024),
+		lru:    list.New(),
+		budget: budget,
 		stopCh: make(chan struct{}),
 	}
 	go c.janitor()
@@ -51,6 +98,8 @@ func (c *stateCache) close() {
 			e.obj = nil
 		}
 		c.data = nil
+		c.lru.Init()
+		c.used = 0
 		c.mu.Unlock()
 	})
 }
@@ -59,11 +108,12 @@ func (c *stateCache) evictCold() {
 	now := time.Now()
 
 	c.mu.Lock()
-	for k, e := range c.data {
+	for _, e := range c.data {
 		age := now.Sub(time.Unix(0, atomic.LoadInt64(&e.lastRead)))
 		ttl := ttlBase + time.Duration(atomic.LoadUint32(&e.hitCount))*ttlHitBonus
 		if age > ttl {
-			delete(c.data, k)
+			c.removeLocked(e)
+			c.expired.Add(1)
 		} else {
 			// decay hit counter so "old" popularity fades
 			if hc := atomic.LoadUint32(&e.hitCount); hc > 0 {
@@ -91,34 +141,209 @@ func (c *stateCache) janitor() {
 
 // get returns nil on a miss.
 func (c *stateCache) get(uid string) *trackerState {
-	c.mu.RLock()
+	c.mu.Lock()
 	entry := c.data[uid]
-	c.mu.RUnlock()
+	if entry != nil {
+		c.lru.MoveToFront(entry.elem)
+	}
+	c.mu.Unlock()
 
 	if entry == nil {
+		c.misses.Add(1)
 		return nil
 	}
+	c.hits.Add(1)
 
 	atomic.AddUint32(&entry.hitCount, 1)
 	atomic.StoreInt64(&entry.lastRead, time.Now().UnixNano())
 	return entry
 }
 
-// set overwrites (or creates) the entry.
-// If the cache is at capacity, existing entries can still be updated but
-// new entries are dropped.
+// peek returns the entry without counting a hit or refreshing its recency, for
+// readers that don't commit. Returns nil on a miss.
+func (c *stateCache) peek(uid string) *trackerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[uid]
+}
+
+// set overwrites (or creates) the entry and re-measures its size. Callers
+// that mutate a cached state in place must call set, or resize with the
+// change in size, so the budget reflects the new size. Least recently used
+// entries are evicted until the cache fits its budget; an object larger than
+// the whole budget is not cached.
 func (c *stateCache) set(uid string, ts *trackerState) {
 	// Stamp lastRead before publishing the entry. If it stayed 0 until after
 	// the map insert, a janitor tick landing in that window would compute a
 	// decades-long age and evict the just-inserted hot entry.
 	atomic.StoreInt64(&ts.lastRead, time.Now().UnixNano())
+	size := cacheEntryOverhead + int64(len(uid)) + approxSize(ts.obj)
+
 	c.mu.Lock()
-	_, exists := c.data[uid]
-	if !exists && len(c.data) >= maxTrackedEntries {
-		// At capacity and this is a new key; drop it.
-		c.mu.Unlock()
+	defer c.mu.Unlock()
+	if c.data == nil {
+		return // closed
+	}
+
+	if old, exists := c.data[uid]; exists {
+		c.removeLocked(old)
+	}
+	if size > c.budget {
+		c.evictions.Add(1)
 		return
 	}
+
+	for c.used+size > c.budget {
+		back := c.lru.Back()
+		if back == nil {
+			break
+		}
+		c.removeLocked(back.Value.(*trackerState))
+		c.evictions.Add(1)
+	}
+
+	ts.uid = uid
+	ts.size = size
+	ts.elem = c.lru.PushFront(ts)
 	c.data[uid] = ts
+	c.used += size
+}
+
+// resize adds [delta] bytes to the size of the entry [ts] of [uid] after a
+// patch changed it in place, sparing a commit from measuring the whole object
+// again (see [applySized]). Least recently used entries are evicted until
+// the cache fits its budget. An entry that isn't cached (anymore) is set.
+func (c *stateCache) resize(uid string, ts *trackerState, delta int64) {
+	atomic.StoreInt64(&ts.lastRead, time.Now().UnixNano())
+
+	c.mu.Lock()
+	if c.data == nil {
+		c.mu.Unlock()
+		return // closed
+	}
+	if c.data[uid] != ts {
+		c.mu.Unlock()
+		c.set(uid, ts)
+		return
+	}
+	defer c.mu.Unlock()
+
+	ts.size += delta
+	c.used += delta
+	c.lru.MoveToFront(ts.elem)
+	if ts.size > c.budget {
+		c.removeLocked(ts)
+		c.evictions.Add(1)
+		return
+	}
+	for c.used > c.budget {
+		back := c.lru.Back()
+		if back == nil || back == ts.elem {
+			break
+		}
+		c.removeLocked(back.Value.(*trackerState))
+		c.evictions.Add(1)
+	}
+}
+
+// remove drops the entry of [uid], if cached, without counting a lookup.
+func (c *stateCache) remove(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.data[uid]; e != nil {
+		c.removeLocked(e)
+	}
+}
+
+// removeLocked unlinks e from the map, the LRU list and the byte count.
+// c.mu must be held.
+func (c *stateCache) removeLocked(e *trackerState) {
+	if c.data[e.uid] == e {
+		delete(c.data, e.uid)
+	}
+	if e.elem != nil {
+		c.lru.Remove(e.elem)
+		e.elem = nil
+	}
+	c.used -= e.size
+	e.size = 0
+}
+
+// stats returns a snapshot of the cache counters.
+func (c *stateCache) stats() CacheStats {
+	c.mu.Lock()
+	entries, used := len(c.data), c.used
 	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Expired:   c.expired.Load(),
+		Entries:   entries,
+		Bytes:     used,
+		Budget:    c.budget,
+	}
+}
+
+// applySized applies the change-set [chg] to [obj] like [diffmap.Apply] and
+// returns by how much that changed [approxSize] of [obj]. Nested change-sets
+// are followed into their maps, so only the values [chg] replaces are
+// measured, not the whole object.
+func applySized(obj, chg diffmap.DiffMap) int64 {
+	var delta int64
+	for k, c := range chg {
+		if sub, isMap := obj[k].(map[string]any); isMap {
+			if nested, isChg := c.(diffmap.DiffMap); isChg && nested != nil {
+				if _, directive := nested[diffmap.DirectiveKey]; !directive {
+					delta += applySized(sub, nested)
+					continue
+				}
+			}
+		}
+		delta -= entrySize(obj, k)
+		diffmap.Apply(obj, diffmap.DiffMap{k: c})
+		delta += entrySize(obj, k)
+	}
+	return delta
+}
+
+// entrySize is the share of [approxSize] of [obj] taken by its key [k].
+func entrySize(obj map[string]any, k string) int64 {
+	v, ok := obj[k]
+	if !ok {
+		return 0
+	}
+	return sizeMapEntry + sizeInterface + int64(len(k)) + approxSize(v)
+}
+
+// approxSize estimates the heap footprint of a decoded object in bytes: string
+// payloads plus fixed overheads for map entries, slices and boxed scalars.
+func approxSize(v any) int64 {
+	switch val := v.(type) {
+	case map[string]any:
+		n := int64(sizeMapHeader)
+		for k, sub := range val {
+			n += sizeMapEntry + sizeInterface + int64(len(k)) + approxSize(sub)
+		}
+		return n
+	case []any:
+		n := int64(sizeSlice)
+		for _, sub := range val {
+			n += sizeInterface + approxSize(sub)
+		}
+		return n
+	case []map[string]any:
+		n := int64(sizeSlice)
+		for _, sub := range val {
+			n += approxSize(sub)
+		}
+		return n
+	case string:
+		return int64(len(val))
+	case nil:
+		return 0
+	default:
+		return sizeScalar
+	}
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func cmState(payload int) *trackerState {
+	return &trackerState{obj: diffmap.DiffMap{
+		"kind": "ConfigMap",
+		"data": diffmap.DiffMap{"v": strings.Repeat("x", payload)},
+	}}
+}
+
+func TestStateCache_EvictsLeastRecentlyUsedOverBudget(t *testing.T) {
+	one := cacheEntryOverhead + approxSize(cmState(1000).obj) + 8
+	c := newStateCache(3 * one)
+	t.Cleanup(c.close)
+
+	for i := range 3 {
+		c.set("uid-"+strconv.Itoa(i), cmState(1000))
+	}
+	// Touch uid-0 so uid-1 becomes the least recently used entry.
+	if c.get("uid-0") == nil {
+		t.Fatal("uid-0 missing before eviction")
+	}
+	c.set("uid-3", cmState(1000))
+
+	if c.get("uid-1") != nil {
+		t.Fatal("uid-1 should have been evicted as least recently used")
+	}
+	for _, uid := range []string{"uid-0", "uid-2", "uid-3"} {
+		if c.get(uid) == nil {
+			t.Fatalf("%s unexpectedly evicted", uid)
+		}
+	}
+
+	st := c.stats()
+	if st.Evictions != 1 || st.Entries != 3 {
+		t.Fatalf("stats = %+v, want 1 eviction and 3 entries", st)
+	}
+	if st.Bytes > st.Budget {
+		t.Fatalf("cache holds %d bytes over its %d budget", st.Bytes, st.Budget)
+	}
+}
+
+func TestStateCache_SizeWeightsEntries(t *testing.T) {
+	small := cacheEntryOverhead + approxSize(cmState(10).obj) + 8
+	c := newStateCache(40 * small)
+	t.Cleanup(c.close)
+
+	for i := range 30 {
+		c.set("small-"+strconv.Itoa(i), cmState(10))
+	}
+	// One object the size of the whole budget pushes every small one out.
+	big := cmState(int(40*small) - 400)
+	c.set("big", big)
+	if c.get("big") == nil {
+		t.Fatal("big entry should fit the budget once small ones are evicted")
+	}
+	if st := c.stats(); st.Entries != 1 {
+		t.Fatalf("want only the big entry left, got %d entries", st.Entries)
+	}
+
+	// Larger than the whole budget: not cached, nothing else is dropped.
+	c.set("huge", cmState(int(80*small)))
+	if c.get("huge") != nil {
+		t.Fatal("object larger than the budget must not be cached")
+	}
+	if c.get("big") == nil {
+		t.Fatal("oversized insert must not evict existing entries")
+	}
+}
+
+func TestStateCache_ResetRemeasures(t *testing.T) {
+	c := newStateCache(DefaultCacheMemory)
+	t.Cleanup(c.close)
+
+	ts := cmState(10)
+	c.set("uid", ts)
+	before := c.stats().Bytes
+
+	ts.obj["data"].(diffmap.DiffMap)["v"] = strings.Repeat("x", 10_000)
+	c.set("uid", ts)
+	after := c.stats()
+	if after.Entries != 1 || after.Bytes < before+9_000 {
+		t.Fatalf("re-set should re-measure in place: before=%d after=%+v", before, after)
+	}
+}
+
+func TestStateCache_HitMissCounters(t *testing.T) {
+	c := newStateCache(0)
+	t.Cleanup(c.close)
+
+	c.set("a", cmState(1))
+	c.get("a")
+	c.get("a")
+	c.get("b")
+
+	st := c.stats()
+	if st.Hits != 2 || st.Misses != 1 {
+		t.Fatalf("hits/misses = %d/%d, want 2/1", st.Hits, st.Misses)
+	}
+	if st.Budget != DefaultCacheMemory {
+		t.Fatalf("zero budget should default to %d, got %d", DefaultCacheMemory, st.Budget)
+	}
+}
+
+// Adjusting by the changed parts ends up where measuring it all would.
+func TestApplySized_MatchesRemeasure(t *testing.T) {
+	obj := diffmap.DiffMap{
+		"metadata": diffmap.DiffMap{"name": "web", "resourceVersion": "1", "labels": diffmap.DiffMap{"app": "web"}},
+		"spec": diffmap.DiffMap{"containers": []any{
+			diffmap.DiffMap{"name": "app", "image": "nginx:1"},
+			diffmap.DiffMap{"name": "sidecar", "image": "envoy:1"},
+		}},
+		"data": "old",
+	}
+	next := diffmap.DiffMap{
+		"metadata": diffmap.DiffMap{"name": "web", "resourceVersion": "22", "annotations": diffmap.DiffMap{"a": "b"}},
+		"spec": diffmap.DiffMap{"containers": []any{
+			diffmap.DiffMap{"name": "app", "image": "nginx:1.27"},
+		}},
+		"status": diffmap.DiffMap{"ready": true},
+	}
+	size := approxSize(obj)
+	if got, want := size+applySized(obj, diffmap.Diff(obj, next)), approxSize(next); got != want {
+		t.Fatalf("adjusted size = %d, re-measured %d", got, want)
+	}
+	if !reflect.DeepEqual(obj, next) {
+		t.Fatalf("applySized applied %v, want %v", obj, next)
+	}
+}
+
+func TestStateCache_Resize(t *testing.T) {
+	one := cacheEntryOverhead + approxSize(cmState(1000).obj) + 8
+	c := newStateCache(3 * one)
+	t.Cleanup(c.close)
+
+	for i := range 3 {
+		c.set("uid-"+strconv.Itoa(i), cmState(1000))
+	}
+	// uid-2 grows into the room of the least recently used entry.
+	ts := c.peek("uid-2")
+	size := ts.size
+	c.resize("uid-2", ts, 500)
+	st := c.stats()
+	if c.peek("uid-0") != nil || c.peek("uid-2") == nil || st.Entries != 2 || st.Bytes > st.Budget {
+		t.Fatalf("stats = %+v, want uid-0 evicted for the grown uid-2", st)
+	}
+	if ts.size != size+500 {
+		t.Errorf("size = %d, want %d", ts.size, size+500)
+	}
+
+	// An entry evicted meanwhile is measured and cached again.
+	gone := cmState(10)
+	c.resize("uid-9", gone, 1)
+	if c.peek("uid-9") != gone {
+		t.Fatal("resize of an uncached entry should cache it")
+	}
+}
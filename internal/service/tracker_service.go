@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -38,8 +39,35 @@ type lockWrap struct {
 	lastUse int64 // unix-nanosec; atomic
 }
 
-// NewTrackerService creates a new TrackerService instance.
+// Options controls how the TrackerService behaves.
+type Options struct {
+	// SnapshotEvery creates a full snapshot after this many patches.
+	// Zero means 8.
+	SnapshotEvery uint64
+
+	// Cache enables the in-memory hot-state cache that lets Commit diff
+	// against the latest state without restoring it from the store.
+	Cache bool
+
+	// CacheMemory is the approximate byte budget of the hot-state cache.
+	// Least recently used objects are evicted once it is exceeded. Zero
+	// means DefaultCacheMemory. Ignored when Cache is false.
+	CacheMemory int64
+}
+
+// NewTrackerService creates a new TrackerService instance. For full control
+// use [NewTrackerServiceWithOptions].
 func NewTrackerService(rps store.ResourcePatchStore, snapshotEvery uint64, withCache bool) *TrackerService {
+	return NewTrackerServiceWithOptions(rps, Options{
+		SnapshotEvery: snapshotEvery,
+		Cache:         withCache,
+	})
+}
+
+// NewTrackerServiceWithOptions creates a new TrackerService instance with
+// control over snapshot rotation and the cache budget.
+func NewTrackerServiceWithOptions(rps store.ResourcePatchStore, opts Options) *TrackerService {
+	snapshotEvery := opts.SnapshotEvery
 	if snapshotEvery == 0 {
 		snapshotEvery = 8
 	}
@@ -50,13 +78,22 @@ func NewTrackerService(rps store.ResourcePatchStore, snapshotEvery uint64, withC
 		commitLocks:           make(map[string]*lockWrap),
 		stopCommitLockJanitor: make(chan struct{}),
 	}
-	if withCache {
-		t.cache = newStateCache()
+	if opts.Cache {
+		t.cache = newStateCache(opts.CacheMemory)
 	}
 	go t.lockJanitor()
 	return t
 }
 
+// CacheStats returns the hot-state cache counters. The zero value is returned
+// when the cache is disabled.
+func (t *TrackerService) CacheStats() CacheStats {
+	if t == nil || t.cache == nil {
+		return CacheStats{}
+	}
+	return t.cache.stats()
+}
+
 // Close closes the TrackerService and releases any resources it holds.
 // After you call Close, the TrackerService should not be used anymore.
 func (t *TrackerService) Close() error {
@@ -79,6 +116,7 @@ func (t *TrackerService) Close() error {
 }
 
 // DuplicateResourceVersionError is thrown when a Kubernetes object was committed that is already in the rps
+// (or an older state of it, e.g. a change replayed when resuming a capture)
 type DuplicateResourceVersionError struct {
 	rev             store.RevisionID
 	resourceVersion string
@@ -123,7 +161,7 @@ func (t *TrackerService) Commit(
 		}
 
 		// we have a valid revision, so we can use it to create a new tracker state
-		snapshot, err := t.Restore(ctx, objID, latest)
+		snapshot, err := t.restoreForward(ctx, objID, latest)
 		if err != nil {
 			return 0, err
 		}
@@ -135,7 +173,7 @@ func (t *TrackerService) Commit(
 	}
 
 	lastRevisionResourceVersion, ok := util.ExtractResourceVersion(ts.obj)
-	if ok && lastRevisionResourceVersion == newObject.GetResourceVersion() {
+	if ok && !newerResourceVersion(newObject.GetResourceVersion(), lastRevisionResourceVersion) {
 		return 0, DuplicateResourceVersionError{
 			rev:             ts.rev,
 			resourceVersion: lastRevisionResourceVersion,
@@ -152,30 +190,89 @@ func (t *TrackerService) Commit(
 		}
 		ts.obj = resource.CloneMap(newObject.Object)
 		ts.rev = snapshot.ID
+		if t.cache != nil {
+			t.cache.set(objID, ts) // re-measure against the budget
+		}
 		return snapshot.ID, nil
 	}
 
 	diff := diffmap.Diff(ts.obj, newObject.Object)
 	p := newPatch(ts.rev, diff)
+	p.Reverse = diffmap.Invert(ts.obj, diff)
 	err := t.rps.SetPatch(ctx, objID, &p)
 	if err != nil {
 		return 0, err
 	}
 	// Apply only the diff to the cached state instead of copying the
-	// entire object. This is O(changed keys) rather than O(all keys).
+	// entire object, and re-measure only the parts it changed. Both are
+	// O(changed keys) rather than O(all keys).
+	// The diff is cloned because it shares subtrees with newObject, and later
+	// patches (list items in particular) are applied to the cache in place.
 	if ts.obj == nil {
 		ts.obj = make(diffmap.DiffMap)
 	}
-	diffmap.Apply(ts.obj, diff)
+	grown := applySized(ts.obj, resource.CloneMap(diff))
 	ts.rev = p.ID
+	if t.cache != nil {
+		t.cache.resize(objID, ts, grown)
+	}
 
 	return p.ID, nil
 }
 
+// ErrAlreadyDeleted is returned by Delete when the latest revision of the
+// object is a tombstone already.
+var ErrAlreadyDeleted = errors.New("object is already deleted")
+
+// Delete records that the object was deleted: a tombstone revision without
+// changes (see [store.Patch.Deleted]). It returns the tombstone's ID, or
+// [store.ErrNotFound] for an object that was never committed.
+func (t *TrackerService) Delete(ctx context.Context, objID string) (store.RevisionID, error) {
+	lw := t.lockObject(objID)
+	defer lw.mu.Unlock()
+
+	latest, err := t.rps.GetLatestRevision(ctx, objID)
+	if err != nil {
+		return 0, err
+	}
+	_, prev, err := t.rps.Get(ctx, objID, latest)
+	if err != nil {
+		return 0, err
+	}
+	if prev != nil && prev.Deleted {
+		return latest, ErrAlreadyDeleted
+	}
+
+	p := newPatch(latest, diffmap.DiffMap{})
+	p.Deleted = true
+	if err := t.rps.SetPatch(ctx, objID, &p); err != nil {
+		return 0, err
+	}
+	if t.cache != nil {
+		// Nothing commits to a deleted object; don't let its state hold
+		// the front of the cache.
+		t.cache.remove(objID)
+	}
+	return p.ID, nil
+}
+
+// newerResourceVersion reports whether resourceVersion [rv] is newer than
+// [last]. Resource versions are opaque, but those of etcd are increasing
+// integers; others only compare equal.
+func newerResourceVersion(rv, last string) bool {
+	n, errN := strconv.ParseUint(rv, 10, 64)
+	l, errL := strconv.ParseUint(last, 10, 64)
+	if errN != nil || errL != nil {
+		return rv != last
+	}
+	return n > l
+}
+
 func newPatch(previousID store.RevisionID, diff diffmap.DiffMap) store.Patch {
 	return store.Patch{
 		PreviousID: previousID,
 		Patch:      diff,
+		Format:     diffmap.CurrentFormat,
 		Time:       time.Now(),
 	}
 }
@@ -190,11 +287,95 @@ func newSnapshot(newObject *unstructured.Unstructured, previousID store.Revision
 	}
 }
 
-// Restore brings back the object state at *rev*.
+// Restore brings back the object state at *rev*. It either walks back to the
+// nearest older snapshot and re-applies patches forward, or, when the latest
+// state is cached and fewer steps away, undoes patches backward from there.
 func (t *TrackerService) Restore(
 	ctx context.Context,
 	objID string,
 	revision store.RevisionID,
+) (*store.Snapshot, error) {
+	snapshot, ok, err := t.restoreBackward(ctx, objID, revision)
+	if err != nil || ok {
+		return snapshot, err
+	}
+	return t.restoreForward(ctx, objID, revision)
+}
+
+// restoreBackward restores *revision* by undoing the reverse patches between
+// it and the cached latest state. ok is false when that is not possible or not
+// cheaper than restoring forward, and the caller should fall back.
+func (t *TrackerService) restoreBackward(
+	ctx context.Context,
+	objID string,
+	revision store.RevisionID,
+) (snapshot *store.Snapshot, ok bool, err error) {
+	if t.cache == nil {
+		return nil, false, nil
+	}
+
+	lw := t.lockObject(objID)
+	ts := t.cache.peek(objID)
+	if ts == nil || ts.rev <= revision || !t.backwardIsCheaper(revision, ts.rev) {
+		lw.mu.Unlock()
+		return nil, false, nil
+	}
+	state := resource.CloneMap(ts.obj)
+	currentRevision := ts.rev
+	lw.mu.Unlock()
+
+	target, targetPatch, err := t.rps.Get(ctx, objID, revision)
+	if err != nil {
+		return nil, false, err
+	}
+	if target != nil {
+		return target, true, nil
+	}
+
+	for currentRevision > revision {
+		snap, p, err := t.rps.Get(ctx, objID, currentRevision)
+		if err != nil {
+			return nil, false, err
+		}
+		if snap != nil || p == nil || (p.Reverse == nil && p.Patch != nil) {
+			// A snapshot or a patch without a recorded inverse can't be
+			// stepped over backward.
+			return nil, false, nil
+		}
+		if p.PreviousID >= currentRevision {
+			return nil, false, fmt.Errorf(
+				"corrupted patch chain for %s: revision %d points back to %d",
+				objID, currentRevision, p.PreviousID)
+		}
+		diffmap.Unapply(state, p.Reverse)
+		currentRevision = p.PreviousID
+	}
+
+	return &store.Snapshot{
+		ID:     revision,
+		Object: state,
+		Time:   targetPatch.Time,
+	}, true, nil
+}
+
+// backwardIsCheaper reports whether undoing patches from latest down to
+// revision takes fewer steps than applying them from the snapshot before
+// revision. Snapshots are written every snapshotEvery revisions, so both must
+// lie in the same snapshot period.
+func (t *TrackerService) backwardIsCheaper(revision, latest store.RevisionID) bool {
+	period := store.RevisionID(t.snapshotEvery)
+	if revision/period != latest/period {
+		return false
+	}
+	return latest-revision < revision%period
+}
+
+// restoreForward walks back to the nearest older snapshot and applies the
+// patches from there.
+func (t *TrackerService) restoreForward(
+	ctx context.Context,
+	objID string,
+	revision store.RevisionID,
 ) (*store.Snapshot, error) {
 	var patchChain []*store.Patch
 	currentRevision := revision
@@ -209,16 +390,25 @@ func (t *TrackerService) Restore(
 		if snapshot != nil {
 			// we have found the base snapshot, so we can use the chain to reconstruct the object state
 			state := snapshot.Object
+			// Fold the chain into one change-set first, so fields rewritten
+			// by every patch (resourceVersion, status) are applied only once
+			// and no intermediate state is built.
+			var chg diffmap.DiffMap
 			for i := len(patchChain) - 1; i >= 0; i-- {
 				currentPatch := patchChain[i]
-				diffmap.Apply(state, currentPatch.Patch)
+				chg = diffmap.Compose(chg, diffmap.Upgrade(currentPatch.Patch, currentPatch.Format))
+			}
+			diffmap.Apply(state, chg)
+			// the time of the revision restored, not of its base snapshot
+			at := snapshot.Time
+			if len(patchChain) > 0 {
+				at = patchChain[0].Time
 			}
-			// we have the final state, so we can cache it
 
 			return &store.Snapshot{
 				ID:     revision,
 				Object: state,
-				Time:   snapshot.Time,
+				Time:   at,
 			}, nil
 		}
 
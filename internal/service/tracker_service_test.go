@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -15,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/loog-project/loog/internal/service"
+	"github.com/loog-project/loog/internal/store"
 	bboltStore "github.com/loog-project/loog/internal/store/bbolt"
 	"github.com/loog-project/loog/pkg/diffmap"
 )
@@ -100,6 +102,172 @@ func TestCommitRestore_RotationAndDiff(t *testing.T) {
 	}
 }
 
+func TestRestore_ExplicitNullRoundTrips(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := mustNewSvc(t, 8, true, false)
+
+	uid := "uid-null"
+	obj := newCM(uid)
+	_, _ = svc.Commit(ctx, uid, obj.DeepCopy())
+
+	// The API server returns "creationTimestamp: null" in templates; a field
+	// changing to null must not be confused with a removed key.
+	obj.Object["data"].(diffmap.DiffMap)["val"] = nil
+	obj.Object["metadata"].(diffmap.DiffMap)["name"] = "cm2"
+	rev, _ := svc.Commit(ctx, uid, obj.DeepCopy())
+
+	snap, err := svc.Restore(ctx, uid, rev)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !reflect.DeepEqual(snap.Object, obj.Object) {
+		t.Fatalf("restored %v, want %v", snap.Object, obj.Object)
+	}
+}
+
+func TestRestore_AppliesLegacyPatches(t *testing.T) {
+	ctx := context.Background()
+	svc, raw := mustNewSvc(t, 8, true, false)
+
+	uid := "uid-legacy"
+	_, _ = svc.Commit(ctx, uid, newCM(uid).DeepCopy())
+
+	// A patch from a capture written before change-sets carried a format:
+	// nil deletes the key.
+	legacy := store.Patch{
+		PreviousID: 0,
+		Patch:      diffmap.DiffMap{"data": diffmap.DiffMap{"val": nil}},
+	}
+	if err := raw.SetPatch(ctx, uid, &legacy); err != nil {
+		t.Fatalf("set patch: %v", err)
+	}
+
+	snap, err := svc.Restore(ctx, uid, legacy.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data := snap.Object["data"].(diffmap.DiffMap); len(data) != 0 {
+		t.Fatalf("legacy nil should delete the key, got data=%v", data)
+	}
+}
+
+func TestRestore_BackwardMatchesForward(t *testing.T) {
+	ctx := context.Background()
+	withCache, raw := mustNewSvc(t, 8, true, true)
+	noCache := service.NewTrackerService(raw, 8, false)
+	t.Cleanup(func() { _ = noCache.Close() })
+
+	uid := "uid-back"
+	obj := newCM(uid)
+	var want []diffmap.DiffMap
+	for i := range 8 {
+		data := obj.Object["data"].(diffmap.DiffMap)
+		data["val"] = "v" + strconv.Itoa(i)
+		if i%3 == 0 {
+			data["k"+strconv.Itoa(i)] = nil
+		} else {
+			delete(data, "k"+strconv.Itoa(i-1))
+		}
+		if _, err := withCache.Commit(ctx, uid, obj.DeepCopy()); err != nil {
+			t.Fatalf("commit %d: %v", i, err)
+		}
+		want = append(want, obj.DeepCopy().Object)
+	}
+
+	_, p, _ := raw.Get(ctx, uid, 7)
+	if p == nil || p.Reverse == nil {
+		t.Fatalf("patch rev7 should carry its inverse, got %#v", p)
+	}
+
+	// Newest-first, as when scrolling back through history.
+	for rev := 7; rev >= 0; rev-- {
+		for name, svc := range map[string]*service.TrackerService{"cached": withCache, "uncached": noCache} {
+			snap, err := svc.Restore(ctx, uid, store.RevisionID(rev))
+			if err != nil {
+				t.Fatalf("%s restore rev%d: %v", name, rev, err)
+			}
+			if !reflect.DeepEqual(snap.Object, want[rev]) {
+				t.Fatalf("%s restore rev%d:\ngot  %v\nwant %v", name, rev, snap.Object, want[rev])
+			}
+			// The time of the revision itself, whichever way it was restored.
+			target, p, _ := raw.Get(ctx, uid, store.RevisionID(rev))
+			var wantTime time.Time
+			if target != nil {
+				wantTime = target.Time
+			} else {
+				wantTime = p.Time
+			}
+			if !snap.Time.Equal(wantTime) {
+				t.Errorf("%s restore rev%d: time %v, want %v", name, rev, snap.Time, wantTime)
+			}
+		}
+	}
+}
+
+// Replayed older states of an object are not committed again.
+func TestCommit_SkipsOlderResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := mustNewSvc(t, 4, true, true)
+
+	uid := "uid-old"
+	for _, rv := range []string{"9", "10"} {
+		obj := newCM(uid)
+		obj.SetResourceVersion(rv)
+		if _, err := svc.Commit(ctx, uid, obj); err != nil {
+			t.Fatalf("commit %s: %v", rv, err)
+		}
+	}
+	for _, rv := range []string{"9", "10"} {
+		obj := newCM(uid)
+		obj.SetResourceVersion(rv)
+		var dupErr service.DuplicateResourceVersionError
+		if _, err := svc.Commit(ctx, uid, obj); !errors.As(err, &dupErr) {
+			t.Errorf("commit of %s after 10: %v, want DuplicateResourceVersionError", rv, err)
+		}
+	}
+}
+
+func TestDelete_Tombstone(t *testing.T) {
+	ctx := context.Background()
+	svc, raw := mustNewSvc(t, 4, true, true)
+
+	if _, err := svc.Delete(ctx, "uid-none"); err != store.ErrNotFound {
+		t.Fatalf("delete of an unknown object: %v, want ErrNotFound", err)
+	}
+
+	uid := "uid-del"
+	obj := newCM(uid)
+	obj.SetResourceVersion("1")
+	if _, err := svc.Commit(ctx, uid, obj); err != nil {
+		t.Fatal(err)
+	}
+	cached := svc.CacheStats()
+	tomb, err := svc.Delete(ctx, uid)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// The deleted object's state leaves the cache, and is no cache hit.
+	if st := svc.CacheStats(); st.Entries != cached.Entries-1 || st.Hits != cached.Hits {
+		t.Errorf("cache after delete = %+v, before %+v", st, cached)
+	}
+	_, p, err := raw.Get(ctx, uid, tomb)
+	if err != nil || p == nil || !p.Deleted || len(p.Patch) != 0 {
+		t.Fatalf("tombstone = %+v, %v; want an empty deleted patch", p, err)
+	}
+	if again, err := svc.Delete(ctx, uid); err != service.ErrAlreadyDeleted || again != tomb {
+		t.Errorf("second delete = %v, %v; want %v, ErrAlreadyDeleted", again, err, tomb)
+	}
+
+	// The tombstone keeps the last state.
+	snap, err := svc.Restore(ctx, uid, tomb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap.Object, obj.Object) {
+		t.Errorf("restored tombstone = %v, want %v", snap.Object, obj.Object)
+	}
+}
+
 func TestHotCache_FastPath(t *testing.T) {
 	ctx := context.Background()
 	svc, _ := mustNewSvc(t, 8, true, true)
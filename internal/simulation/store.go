@@ -24,6 +24,8 @@ type Store struct {
 	clusterResourceTypes []resource.Kind
 	totalRevisions       int    // cached count of all revisions across resources
 	nextID               uint64 // monotonic source of unique revision IDs
+	// keptKinds are unwatched kinds whose resources were kept.
+	keptKinds map[string]bool
 }
 
 // Compile-time check that Store implements both interfaces.
@@ -146,7 +148,7 @@ func (s *Store) FilterTimeline(expr string, starredOnly bool) []resource.Timelin
 		if starredOnly && !e.Resource.Starred {
 			continue
 		}
-		if expr != "" && !resource.MatchesSubstring(lower, e.Resource) {
+		if expr != "" && !resource.MatchesTimelineEntry(lower, e) {
 			continue
 		}
 		result = append(result, e)
@@ -182,7 +184,9 @@ func (s *Store) WatchedKinds() []string {
 func (s *Store) watchedKindsLocked() []string {
 	kindSet := make(map[string]bool)
 	for _, rd := range s.resources {
-		kindSet[rd.Resource.Kind] = true
+		if !s.keptKinds[rd.Resource.Kind] {
+			kindSet[rd.Resource.Kind] = true
+		}
 	}
 	kinds := make([]string, 0, len(kindSet))
 	for k := range kindSet {
@@ -192,6 +196,28 @@ func (s *Store) watchedKindsLocked() []string {
 	return kinds
 }
 
+// Annotations returns nil: simulated resources have no annotations.
+func (s *Store) Annotations(bool) []resource.TimelineAnnotation {
+	return nil
+}
+
+// Gaps returns nil: simulated data has no recording gaps.
+func (s *Store) Gaps() []resource.Gap {
+	return nil
+}
+
+func (s *Store) WatchedKind(kind string) resource.Kind {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rk := range s.clusterResourceTypes {
+		if rk.Kind == kind {
+			return rk
+		}
+	}
+	return resource.Kind{Kind: kind}
+}
+
 func (s *Store) ResourceCountByKind(kind string) int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -240,6 +266,8 @@ func (s *Store) AddWatchKind(rk resource.Kind) []*resource.Data {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	delete(s.keptKinds, rk.Kind)
+
 	now := time.Now()
 	var created []*resource.Data
 
@@ -283,10 +311,19 @@ func (s *Store) AddWatchKind(rk resource.Kind) []*resource.Data {
 	return created
 }
 
-func (s *Store) RemoveWatchKind(kind string) {
+func (s *Store) RemoveWatchKind(rk resource.Kind, keepData bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	kind := rk.Kind
+	if keepData {
+		if s.keptKinds == nil {
+			s.keptKinds = make(map[string]bool)
+		}
+		s.keptKinds[kind] = true
+		return
+	}
+
 	var toRemove []string
 	for uid, rd := range s.resources {
 		if rd.Resource.Kind == kind {
@@ -336,6 +373,9 @@ func (s *Store) AddRevision(resourceUID string, rev resource.Revision) {
 	if !ok {
 		return
 	}
+	if rev.ChangedPaths == nil {
+		rev.ChangedPaths = resource.ChangedPaths(rd.LatestRevision(), rev)
+	}
 	rd.Revisions = append(rd.Revisions, rev)
 	s.totalRevisions++
 
@@ -398,8 +438,14 @@ func (s *Store) ScheduleNextTick(epoch uint64) tea.Cmd {
 		return nil
 	}
 	uids := make([]string, 0, len(s.resources))
-	for uid := range s.resources {
-		uids = append(uids, uid)
+	for uid, rd := range s.resources {
+		// Kept resources of unwatched kinds don't change anymore.
+		if !s.keptKinds[rd.Resource.Kind] {
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) == 0 {
+		return nil
 	}
 	return func() tea.Msg {
 		delay := time.Duration(3+rand.Intn(3)) * time.Second
@@ -1,7 +1,6 @@
 package bbolt
 
 import (
-	"bytes"
 	"encoding/binary"
 	"sync"
 
@@ -49,13 +48,16 @@ func putKeyBuf(bp *[]byte) {
 	keyPool.Put(bp)
 }
 
+// splitObjectRevisionKey splits a key written by keyObjectRevision. The
+// revision is always the last 8 bytes, so object IDs may contain '|' (they
+// embed kubeconfig context names when several clusters are recorded).
 func splitObjectRevisionKey(key []byte) (string, store.RevisionID) {
-	before, after, ok := bytes.Cut(key, []byte{'|'})
-	if !ok || len(after) < 8 {
+	sep := len(key) - 9
+	if sep < 0 || key[sep] != '|' {
 		return "", 0
 	}
-	objectUID := string(before)
-	id := binary.BigEndian.Uint64(after)
+	objectUID := string(key[:sep])
+	id := binary.BigEndian.Uint64(key[sep+1:])
 	return objectUID, store.RevisionID(id)
 }
 
@@ -1,6 +1,7 @@
 package bbolt
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"sync"
@@ -145,3 +146,102 @@ func (s *Store) WalkObjectRevisions(yield func(string, store.RevisionID, *store.
 		return nil
 	})
 }
+
+// AddGap records a gap, keyed by its start time.
+func (s *Store) AddGap(_ context.Context, gap *store.Gap) error {
+	payload, err := s.codec.Marshal(gap)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(gap.Start.UnixNano()))
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketGaps).Put(key, payload)
+	})
+}
+
+// Gaps returns the recorded gaps, oldest first. Captures written before
+// gaps were recorded have none.
+func (s *Store) Gaps() ([]store.Gap, error) {
+	var gaps []store.Gap
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketGaps)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var gap store.Gap
+			if err := s.codec.Unmarshal(v, &gap); err != nil {
+				return err
+			}
+			gaps = append(gaps, gap)
+			return nil
+		})
+	})
+	return gaps, err
+}
+
+// AddAnnotation records an annotation of an object, replacing the one with
+// the same ID.
+func (s *Store) AddAnnotation(_ context.Context, objectID string, a *store.Annotation) error {
+	payload, err := s.codec.Marshal(a)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 0, len(objectID)+1+len(a.ID))
+	key = append(append(append(key, objectID...), 0), a.ID...)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketAnnotations).Put(key, payload)
+	})
+}
+
+// WalkAnnotations calls yield for every annotation, grouped by object.
+// Captures written before annotations were recorded have none.
+func (s *Store) WalkAnnotations(yield func(string, *store.Annotation) bool) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketAnnotations)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			objectID, _, ok := bytes.Cut(k, []byte{0})
+			if !ok {
+				continue
+			}
+			var a store.Annotation
+			if err := s.codec.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			if !yield(string(objectID), &a) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// SetBookmark records the resourceVersion a watch is in sync at, replacing
+// the previous one.
+func (s *Store) SetBookmark(_ context.Context, key, resourceVersion string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketBookmarks).Put([]byte(key), []byte(resourceVersion))
+	})
+}
+
+// Bookmarks returns the recorded resourceVersions by watch key. Captures
+// written before bookmarks were recorded have none.
+func (s *Store) Bookmarks() (map[string]string, error) {
+	bookmarks := make(map[string]string)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketBookmarks)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			bookmarks[string(k)] = string(v)
+			return nil
+		})
+	})
+	return bookmarks, err
+}
@@ -20,8 +20,11 @@ const (
 )
 
 var (
-	bucketSnapshots = []byte("snapshots") // <obj>|rev  -> type_byte + payload
-	bucketLatest    = []byte("latest")    // <obj>      -> uint64(nextRevisionCounter)
+	bucketSnapshots   = []byte("snapshots")   // <obj>|rev  -> type_byte + payload
+	bucketLatest      = []byte("latest")      // <obj>      -> uint64(nextRevisionCounter)
+	bucketGaps        = []byte("gaps")        // uint64(start unix nanos) -> gap
+	bucketAnnotations = []byte("annotations") // <obj>\x00<annotation id> -> annotation
+	bucketBookmarks   = []byte("bookmarks")   // <watch key> -> resourceVersion
 )
 
 // Options controls how the store behaves.
@@ -64,7 +67,12 @@ type Store struct {
 	closeOnce sync.Once
 }
 
-var _ store.ResourcePatchStore = (*Store)(nil)
+var (
+	_ store.ResourcePatchStore = (*Store)(nil)
+	_ store.GapStore           = (*Store)(nil)
+	_ store.AnnotationStore    = (*Store)(nil)
+	_ store.BookmarkStore      = (*Store)(nil)
+)
 
 // New opens (or creates) a BoltDB-backed store. For full control use [NewWithOptions].
 func New(path string, codec store.Codec, durable bool) (*Store, error) {
@@ -102,7 +110,7 @@ func NewWithOptions(path string, opts Options) (*Store, error) {
 	// exist in the file we're opening to browse.
 	if !opts.ReadOnly {
 		err = db.Update(func(tx *bbolt.Tx) error {
-			for _, b := range [][]byte{bucketSnapshots, bucketLatest} {
+			for _, b := range [][]byte{bucketSnapshots, bucketLatest, bucketGaps, bucketAnnotations, bucketBookmarks} {
 				if _, e := tx.CreateBucketIfNotExists(b); e != nil {
 					return e
 				}
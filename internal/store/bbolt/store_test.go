@@ -846,6 +846,128 @@ func TestWalkMatchesGet(t *testing.T) {
 	}
 }
 
+// Object IDs of several clusters embed the kubeconfig context, which may
+// contain '|' and '/'; the walk must still split keys correctly.
+func TestWalk_ClusterObjectIDs(t *testing.T) {
+	s := openStore(t, Options{})
+	ids := []string{
+		store.ObjectID("arn:aws:eks:eu-west-1:1:cluster/prod", "uid-1"),
+		store.ObjectID("odd|context", "uid-1"),
+		store.ObjectID("", "uid-1"),
+	}
+	for _, objID := range ids {
+		if err := s.SetSnapshot(ctx, objID, &store.Snapshot{Object: diffmap.DiffMap{"id": objID}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var walked []string
+	err := s.WalkObjectRevisions(func(objID string, _ store.RevisionID, snap *store.Snapshot, _ *store.Patch) bool {
+		walked = append(walked, objID)
+		if snap == nil || snap.Object["id"] != objID {
+			t.Errorf("%s: got snapshot %+v", objID, snap)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(walked) != len(ids) {
+		t.Fatalf("walked %v, want %v", walked, ids)
+	}
+	if cluster, uid := store.SplitObjectID(ids[0]); cluster != "arn:aws:eks:eu-west-1:1:cluster/prod" || uid != "uid-1" {
+		t.Errorf("SplitObjectID = %q, %q", cluster, uid)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gaps.bb")
+	s, err := NewWithOptions(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later := store.Gap{Start: base.Add(time.Hour), End: base.Add(2 * time.Hour), CaughtUp: 3}
+	earlier := store.Gap{Start: base, End: base.Add(time.Minute), Discarded: 7}
+	for _, gap := range []store.Gap{later, earlier} {
+		if err := s.AddGap(ctx, &gap); err != nil {
+			t.Fatalf("add gap: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := NewWithOptions(path, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ro.Close() })
+	gaps, err := ro.Gaps()
+	if err != nil {
+		t.Fatalf("gaps: %v", err)
+	}
+	if len(gaps) != 2 {
+		t.Fatalf("got %d gaps, want 2", len(gaps))
+	}
+	for i, want := range []store.Gap{earlier, later} {
+		got := gaps[i]
+		if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) || got.Discarded != want.Discarded || got.CaughtUp != want.CaughtUp {
+			t.Errorf("gap %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	s := openStore(t, Options{})
+	now := time.Now().UTC()
+	pod := store.ObjectID("odd|context", "uid-1")
+	for _, a := range []struct {
+		objectID string
+		ann      store.Annotation
+	}{
+		{pod, store.Annotation{ID: "ev-1/1", Time: now, Type: "Warning", Reason: "BackOff", Count: 1}},
+		{pod, store.Annotation{ID: "ev-1/1", Time: now, Type: "Warning", Reason: "BackOff", Message: "again", Count: 1}},
+		{pod, store.Annotation{ID: "ev-1/2", Time: now.Add(time.Minute), Type: "Warning", Reason: "BackOff", Count: 2}},
+		{"uid-2", store.Annotation{ID: "ev-2/1", Time: now, Reason: "Scheduled"}},
+	} {
+		if err := s.AddAnnotation(ctx, a.objectID, &a.ann); err != nil {
+			t.Fatalf("add annotation: %v", err)
+		}
+	}
+
+	got := map[string][]store.Annotation{}
+	err := s.WalkAnnotations(func(objectID string, a *store.Annotation) bool {
+		got[objectID] = append(got[objectID], *a)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got[pod]) != 2 || len(got["uid-2"]) != 1 {
+		t.Fatalf("got %+v, want 2 annotations of %s and 1 of uid-2", got, pod)
+	}
+	if first := got[pod][0]; first.ID != "ev-1/1" || first.Message != "again" || !first.Time.Equal(now) {
+		t.Errorf("first annotation = %+v, want the replaced ev-1/1", first)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	s := openStore(t, Options{})
+	for _, bm := range [][2]string{{"apps/v1/deployments", "100"}, {"east/v1/pods", "7"}, {"apps/v1/deployments", "120"}} {
+		if err := s.SetBookmark(ctx, bm[0], bm[1]); err != nil {
+			t.Fatalf("set bookmark: %v", err)
+		}
+	}
+	got, err := s.Bookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["apps/v1/deployments"] != "120" || got["east/v1/pods"] != "7" {
+		t.Errorf("bookmarks = %v", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Edge cases: empty maps, nil values, very long UIDs, missing revisions.
 // ---------------------------------------------------------------------------
@@ -2,6 +2,7 @@ package store
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/loog-project/loog/pkg/diffmap"
@@ -23,7 +24,17 @@ type Patch struct {
 	// Patch is the diff between the previous revision and this revision.
 	// See [diffmap.Diff] for more details.
 	Patch diffmap.DiffMap `msgpack:"s" json:"patch,omitempty"`
-	Time  time.Time       `msgpack:"t" json:"time"`
+	// Format is the change-set encoding Patch was written in. Patches from
+	// captures that predate the field decode as [diffmap.FormatLegacy].
+	Format diffmap.Format `msgpack:"f,omitempty" json:"format,omitempty"`
+	// Reverse is the inverse of Patch (see [diffmap.Invert]), turning this
+	// revision back into PreviousID. It lets restores walk backward from a
+	// later state. Empty for patches written before it was recorded.
+	Reverse diffmap.DiffMap `msgpack:"r,omitempty" json:"reverse,omitempty"`
+	Time    time.Time       `msgpack:"t" json:"time"`
+	// Deleted marks a tombstone: the object was deleted at Time. Its Patch
+	// is empty.
+	Deleted bool `msgpack:"x,omitempty" json:"deleted,omitempty"`
 }
 
 type Snapshot struct {
@@ -36,3 +47,54 @@ type Snapshot struct {
 	Object diffmap.DiffMap `msgpack:"o" json:"object,omitempty"`
 	Time   time.Time       `msgpack:"t" json:"time"`
 }
+
+// Gap is a span of time in which recording was paused: changes made then
+// are missing from the capture, or only their end state was written when
+// recording resumed.
+type Gap struct {
+	Start time.Time `msgpack:"s" json:"start"`
+	End   time.Time `msgpack:"e" json:"end"`
+	// Discarded is the number of events received during the gap that were
+	// not written.
+	Discarded int `msgpack:"d,omitempty" json:"discarded,omitempty"`
+	// CaughtUp is the number of objects written with their latest state when
+	// recording resumed.
+	CaughtUp int `msgpack:"c,omitempty" json:"caughtUp,omitempty"`
+}
+
+// Annotation is a note about an object recorded alongside its revisions,
+// such as a Kubernetes Event involving it.
+type Annotation struct {
+	// ID identifies the annotation among those of its object; recording an
+	// annotation with the same ID again replaces it.
+	ID   string    `msgpack:"i" json:"ID"`
+	Time time.Time `msgpack:"t" json:"time"`
+	// Type is the severity, e.g. "Normal" or "Warning".
+	Type    string `msgpack:"y,omitempty" json:"type,omitempty"`
+	Reason  string `msgpack:"r,omitempty" json:"reason,omitempty"`
+	Message string `msgpack:"m,omitempty" json:"message,omitempty"`
+	// Count is how often the Event occurred so far.
+	Count int `msgpack:"c,omitempty" json:"count,omitempty"`
+	// Source is the component that reported it.
+	Source string `msgpack:"s,omitempty" json:"source,omitempty"`
+}
+
+// ObjectID returns the ID an object is stored under: its UID, qualified by
+// the cluster it was recorded from when loog watches several clusters.
+// Captures of a single cluster use the bare UID.
+func ObjectID(cluster, uid string) string {
+	if cluster == "" {
+		return uid
+	}
+	return cluster + "/" + uid
+}
+
+// SplitObjectID splits an [ObjectID] into cluster and UID. Kubeconfig
+// context names may contain slashes, UIDs don't.
+func SplitObjectID(objectID string) (cluster, uid string) {
+	i := strings.LastIndexByte(objectID, '/')
+	if i < 0 {
+		return "", objectID
+	}
+	return objectID[:i], objectID[i+1:]
+}
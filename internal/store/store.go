@@ -23,3 +23,26 @@ type ResourcePatchStore interface {
 	WalkObjectRevisions(yield func(string, RevisionID, *Snapshot, *Patch) bool) error
 	Close() error
 }
+
+// AnnotationStore is implemented by stores that can record annotations of
+// objects.
+type AnnotationStore interface {
+	AddAnnotation(ctx context.Context, objectID string, a *Annotation) error
+	WalkAnnotations(yield func(objectID string, a *Annotation) bool) error
+}
+
+// BookmarkStore is implemented by stores that can record where the watches
+// of a capture were last in sync, so a later run can resume them.
+type BookmarkStore interface {
+	// SetBookmark records the resourceVersion the watch [key] is in sync at.
+	SetBookmark(ctx context.Context, key, resourceVersion string) error
+	// Bookmarks returns the recorded resourceVersions by watch key.
+	Bookmarks() (map[string]string, error)
+}
+
+// GapStore is implemented by stores that can record the gaps of a capture.
+type GapStore interface {
+	AddGap(ctx context.Context, gap *Gap) error
+	// Gaps returns the recorded gaps, oldest first.
+	Gaps() ([]Gap, error)
+}
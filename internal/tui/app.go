@@ -78,7 +78,14 @@ type App struct {
 
 	// External callbacks for watch kind management (production mode wiring)
 	onWatchKindAdded   func(rk resource.Kind) // called when user adds a watch kind
-	onWatchKindRemoved func(kind string)      // called when user removes a watch kind
+	onWatchKindRemoved func(rk resource.Kind) // called when user removes a watch kind
+
+	// onPauseToggled is called when the user pauses or resumes recording.
+	onPauseToggled func(paused bool)
+
+	// droppedEvents reports how many watch events were dropped because the
+	// recorder fell behind; polled every tick. Nil outside recording.
+	droppedEvents func() uint64
 }
 
 // pendingRevision holds a revision that arrived during freeze.
@@ -111,13 +118,29 @@ func WithRecording() AppOption {
 
 // WithWatchCallbacks sets callbacks invoked when the user adds or removes a watched kind.
 // In production mode, these trigger mux.Add() / mux.Remove() on the dynamic informer.
-func WithWatchCallbacks(onAdd func(rk resource.Kind), onRemove func(kind string)) AppOption {
+func WithWatchCallbacks(onAdd func(rk resource.Kind), onRemove func(rk resource.Kind)) AppOption {
 	return func(a *App) {
 		a.onWatchKindAdded = onAdd
 		a.onWatchKindRemoved = onRemove
 	}
 }
 
+// WithPauseCallback sets a callback invoked when the user pauses or resumes
+// recording. In production mode it holds back the collector.
+func WithPauseCallback(onToggle func(paused bool)) AppOption {
+	return func(a *App) {
+		a.onPauseToggled = onToggle
+	}
+}
+
+// WithDroppedEvents sets a counter of watch events dropped because the
+// recorder fell behind. The status bar shows it once it is non-zero.
+func WithDroppedEvents(count func() uint64) AppOption {
+	return func(a *App) {
+		a.droppedEvents = count
+	}
+}
+
 // NewApp creates the root application model.
 func NewApp(store Store, opts ...AppOption) *App {
 	theme := CatppuccinMocha
@@ -219,6 +242,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tickMsg:
 		a.header.Tick()
+		if a.droppedEvents != nil {
+			a.statusBar.SetDropped(a.droppedEvents())
+		}
 		if a.statusText != "" && time.Since(a.statusTime) > 3*time.Second {
 			a.statusText = ""
 			a.statusBar.SetStatus("", false)
@@ -496,19 +522,19 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case RemoveWatchKindMsg:
-		count := a.store.ResourceCountByKind(msg.Kind)
+		count := a.store.ResourceCountByKind(msg.Kind.Kind)
 		// Check if the currently selected resource is of this kind
 		selectedUID := a.explorer.tree.SelectedUID()
 		selectedIsOfKind := false
-		if rd := a.store.GetResource(selectedUID); rd != nil && rd.Resource.Kind == msg.Kind {
+		if rd := a.store.GetResource(selectedUID); rd != nil && rd.Resource.Kind == msg.Kind.Kind && !msg.KeepData {
 			selectedIsOfKind = true
 		}
 
-		a.store.RemoveWatchKind(msg.Kind)
-		// Notify external watcher (e.g. mux.Remove) so it stops watching this kind
+		// Stop the watch first so its late events don't bring the kind back.
 		if a.onWatchKindRemoved != nil {
 			a.onWatchKindRemoved(msg.Kind)
 		}
+		a.store.RemoveWatchKind(msg.Kind, msg.KeepData)
 		a.store.RebuildKindGroups()
 		a.refreshExplorerGroups()
 		a.refreshTimeline()
@@ -520,7 +546,11 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if selectedIsOfKind {
 			a.explorer.SetResource(nil)
 		}
-		a.setStatus(fmt.Sprintf("Unwatched: %s (%d resources removed)", msg.Kind, count), false)
+		if msg.KeepData {
+			a.setStatus(fmt.Sprintf("Unwatched: %s (%d resources kept)", msg.Kind.Kind, count), false)
+		} else {
+			a.setStatus(fmt.Sprintf("Unwatched: %s (%d resources removed)", msg.Kind.Kind, count), false)
+		}
 		// Keep the watch manager in sync if it's still open.
 		if a.watchManager.IsVisible() {
 			a.watchManager.Refresh(a.store, a.store.UnwatchedKinds())
@@ -535,6 +565,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case CopyToClipboardMsg:
 		return a, copyToClipboardCmd(msg.Resource, msg.RevIndex)
 
+	case ExportPatchMsg:
+		return a, exportPatchCmd(msg)
+
 	case ToggleTimelineStarredMsg:
 		a.timelineStarredOnly = !a.timelineStarredOnly
 		a.timeline.timeline.SetStarredOnly(a.timelineStarredOnly)
@@ -591,6 +624,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.simEpoch++
 		a.header.SetRecording(a.simulating)
 		a.statusBar.SetSimulating(a.simulating)
+		if a.recording && a.onPauseToggled != nil {
+			a.onPauseToggled(!a.simulating)
+		}
 		if a.simulating {
 			a.setStatus("Recording resumed", false)
 			// Schedule a new simulation tick to restart generation (simulation mode only)
@@ -855,6 +891,8 @@ func (a *App) refreshExplorerGroups() {
 // refreshTimeline rebuilds the timeline entries applying starred-only filter.
 func (a *App) refreshTimeline() {
 	entries := a.store.FilterTimeline("", a.timelineStarredOnly)
+	a.timeline.timeline.SetAnnotations(a.store.Annotations(a.timelineStarredOnly))
+	a.timeline.timeline.SetGaps(a.store.Gaps())
 	a.timeline.SetEntries(entries)
 }
 
@@ -945,6 +983,7 @@ func (a *App) updateHint() {
 		hint = strings.Join([]string{
 			a.theme.KeyHint("j/k", "scroll"),
 			a.theme.KeyHint("tab", "switch pane"),
+			a.theme.KeyHint("v", "inline/split"),
 			a.theme.KeyHint("ctrl+d/u", "page"),
 			a.theme.KeyHint("X", "clear compare"),
 		}, "  ")
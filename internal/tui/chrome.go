@@ -138,6 +138,7 @@ type StatusBar struct {
 	windowMode     resource.WindowMode
 	simulating     bool
 	isSimMode      bool
+	dropped        uint64
 }
 
 func NewStatusBar(theme Theme) *StatusBar {
@@ -184,6 +185,11 @@ func (sb *StatusBar) SetSimMode(on bool) {
 	sb.isSimMode = on
 }
 
+// SetDropped sets the number of watch events dropped so far.
+func (sb *StatusBar) SetDropped(n uint64) {
+	sb.dropped = n
+}
+
 func (sb *StatusBar) View() string {
 	if sb.width <= 0 {
 		return ""
@@ -220,6 +226,10 @@ func (sb *StatusBar) View() string {
 
 	// Right side: counts + keybind hints
 	var rightParts []string
+	if sb.dropped > 0 {
+		rightParts = append(rightParts,
+			lipgloss.NewStyle().Foreground(sb.theme.Peach).Render(fmt.Sprintf("⚠ %d dropped", sb.dropped)))
+	}
 	if sb.starredCount > 0 {
 		rightParts = append(rightParts,
 			lipgloss.NewStyle().Foreground(sb.theme.Yellow).Render(fmt.Sprintf("★ %d", sb.starredCount)))
@@ -478,6 +478,7 @@ const (
 // watchedKindEntry represents a kind currently being watched, with resource counts.
 type watchedKindEntry struct {
 	Kind          string
+	Type          resource.Kind // passed on when the kind is unwatched
 	ResourceCount int
 	RevisionCount int
 }
@@ -533,27 +534,7 @@ func (wm *WatchManager) Show(store Store, unwatchedKinds []resource.Kind) tea.Cm
 	wm.addCursor = 0
 	wm.addQueryInput.SetValue("")
 
-	// Build watching entries
-	kinds := store.WatchedKinds()
-	wm.watched = make([]watchedKindEntry, len(kinds))
-	wm.watchNames = make([]string, len(kinds))
-	for i, kind := range kinds {
-		wm.watched[i] = watchedKindEntry{
-			Kind:          kind,
-			ResourceCount: store.ResourceCountByKind(kind),
-			RevisionCount: store.RevisionCountByKind(kind),
-		}
-		wm.watchNames[i] = kind
-	}
-	wm.updateWatchMatches()
-
-	// Build available types
-	wm.available = unwatchedKinds
-	wm.addNames = make([]string, len(unwatchedKinds))
-	for i, rk := range unwatchedKinds {
-		wm.addNames[i] = rk.Kind
-	}
-	wm.updateAddMatches()
+	wm.Refresh(store, unwatchedKinds)
 	return cmd
 }
 
@@ -573,6 +554,7 @@ func (wm *WatchManager) Refresh(store Store, unwatchedKinds []resource.Kind) {
 	for i, kind := range kinds {
 		wm.watched[i] = watchedKindEntry{
 			Kind:          kind,
+			Type:          store.WatchedKind(kind),
 			ResourceCount: store.ResourceCountByKind(kind),
 			RevisionCount: store.RevisionCountByKind(kind),
 		}
@@ -648,14 +630,14 @@ func (wm *WatchManager) updateWatching(msg tea.Msg) tea.Cmd {
 			if wm.watchCursor < len(wm.watchMatches)-1 {
 				wm.watchCursor++
 			}
-		case "enter", "delete":
+		case "enter", "delete", "ctrl+k":
 			if wm.watchCursor < len(wm.watchMatches) {
 				match := wm.watchMatches[wm.watchCursor]
 				if match.Index < len(wm.watched) {
 					entry := wm.watched[match.Index]
 					// Keep the manager open so several kinds can be removed in
 					// one session. The App refreshes our lists afterwards.
-					return Cmd(RemoveWatchKindMsg{Kind: entry.Kind})
+					return Cmd(RemoveWatchKindMsg{Kind: entry.Type, KeepData: msg.String() == "ctrl+k"})
 				}
 			}
 		default:
@@ -750,7 +732,7 @@ func (wm *WatchManager) View() string {
 	// Footer
 	var footerText string
 	if wm.tab == wmTabWatching {
-		footerText = fmt.Sprintf("  %d types watched  Tab:add types  Enter:unwatch  Esc:close",
+		footerText = fmt.Sprintf("  %d types watched  Tab:add types  Enter:unwatch  ^K:unwatch, keep data  Esc:close",
 			len(wm.watchMatches))
 	} else {
 		footerText = fmt.Sprintf("  %d types available  Tab:back  Enter:add  Esc:close",
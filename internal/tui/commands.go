@@ -2,6 +2,7 @@ package tui
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,6 +12,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/pkg/diffmap"
 )
 
 // Command represents an action executable from the command palette.
@@ -70,6 +72,10 @@ func NewCommandRegistry() *CommandRegistry {
 		Name: "View: Changes Mode", Description: "Show only fields changed vs the previous revision", Shortcut: "p",
 		Action: func() tea.Cmd { return Cmd(ViewModeChangedMsg{Mode: PatchMode}) },
 	})
+	cr.Register(Command{
+		Name: "View: Context Mode", Description: "Show the diff with unchanged fields folded away from the changes", Shortcut: "x",
+		Action: func() tea.Cmd { return Cmd(ViewModeChangedMsg{Mode: ContextMode}) },
+	})
 	cr.Register(Command{
 		Name: "View: JSON Mode", Description: "Show raw JSON", Shortcut: "J",
 		Action: func() tea.Cmd { return Cmd(ViewModeChangedMsg{Mode: JSONMode}) },
@@ -198,6 +204,50 @@ func copyToClipboardCmd(rd *resource.Data, revIdx int) tea.Cmd {
 	}
 }
 
+// exportPatchCmd renders the change between two revisions as a JSON Patch or
+// JSON Merge Patch, without the status and server-maintained metadata (see
+// [resource.PatchableObject]), and writes it to a file or the clipboard.
+func exportPatchCmd(msg ExportPatchMsg) tea.Cmd {
+	from, to := resource.PatchableObject(msg.From.Revision.Object), resource.PatchableObject(msg.To.Revision.Object)
+	if from == nil || to == nil {
+		return Cmd(StatusMsg{Text: "Revision has no object data", IsError: true})
+	}
+	return func() tea.Msg {
+		var (
+			patch any
+			name  = "JSON Patch"
+			ext   = "json-patch.json"
+			kind  = "json"
+		)
+		if msg.Format == PatchFormatMerge {
+			patch = diffmap.MergePatch(from, to)
+			name, ext, kind = "merge patch", "merge-patch.json", "merge"
+		} else {
+			patch = diffmap.JSONPatch(from, to)
+		}
+		data, err := json.MarshalIndent(patch, "", "  ")
+		if err != nil {
+			return StatusMsg{Text: fmt.Sprintf("JSON marshal error: %v", err), IsError: true}
+		}
+
+		span := msg.From.Revision.ID.String() + ".." + msg.To.Revision.ID.String()
+		if msg.Clipboard {
+			if err := writeClipboard(data); err != nil {
+				return StatusMsg{Text: fmt.Sprintf("Clipboard error: %v", err), IsError: true}
+			}
+			return StatusMsg{Text: fmt.Sprintf("Copied %s %s to clipboard (kubectl patch --type %s)", name, span, kind)}
+		}
+
+		filename := fmt.Sprintf("loog-patch-%s-%s-%s-%s.%s",
+			msg.To.Resource.Kind, msg.To.Resource.Name,
+			msg.From.Revision.ID.String(), msg.To.Revision.ID.String(), ext)
+		if writeErr := os.WriteFile(filename, data, 0o644); writeErr != nil {
+			return StatusMsg{Text: fmt.Sprintf("Write error: %v", writeErr), IsError: true}
+		}
+		return StatusMsg{Text: fmt.Sprintf("Exported %s %s to %s", name, span, filename)}
+	}
+}
+
 // writeClipboard writes data to the system clipboard using platform-specific tools.
 func writeClipboard(data []byte) error {
 	var cmd *exec.Cmd
@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +13,7 @@ import (
 	"github.com/muesli/termenv"
 
 	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/pkg/diffmap"
 )
 
 func init() {
@@ -113,8 +117,11 @@ func (s *dataStore) TotalRevisionCount() int                              { retu
 func (s *dataStore) FilterResources(string) []*resource.Data              { return s.AllResources() }
 func (s *dataStore) FilterTimeline(string, bool) []resource.TimelineEntry { return s.timeline }
 func (s *dataStore) Timeline() []resource.TimelineEntry                   { return s.timeline }
+func (s *dataStore) Annotations(bool) []resource.TimelineAnnotation       { return nil }
+func (s *dataStore) Gaps() []resource.Gap                                 { return nil }
 func (s *dataStore) KindGroups() []*resource.KindGroup                    { return s.kindGroups }
 func (s *dataStore) WatchedKinds() []string                               { return []string{"Deployment", "Service"} }
+func (s *dataStore) WatchedKind(k string) resource.Kind                   { return resource.Kind{Kind: k} }
 func (s *dataStore) ResourceCountByKind(k string) int {
 	for _, g := range s.kindGroups {
 		if g.Kind == k {
@@ -126,7 +133,7 @@ func (s *dataStore) ResourceCountByKind(k string) int {
 func (s *dataStore) RevisionCountByKind(string) int              { return 0 }
 func (s *dataStore) UnwatchedKinds() []resource.Kind             { return nil }
 func (s *dataStore) AddWatchKind(resource.Kind) []*resource.Data { return nil }
-func (s *dataStore) RemoveWatchKind(string)                      {}
+func (s *dataStore) RemoveWatchKind(resource.Kind, bool)         {}
 func (s *dataStore) ToggleStar(string) bool                      { return false }
 func (s *dataStore) AddRevision(string, resource.Revision)       {}
 func (s *dataStore) RebuildKindGroups()                          {}
@@ -224,6 +231,18 @@ func TestStatusBar_ZeroWidth(t *testing.T) {
 	}
 }
 
+func TestStatusBar_DroppedEvents(t *testing.T) {
+	sb := NewStatusBar(CatppuccinMocha)
+	sb.SetSize(120)
+	if strings.Contains(stripANSI(sb.View()), "dropped") {
+		t.Error("no drop badge expected without drops")
+	}
+	sb.SetDropped(42)
+	if !strings.Contains(stripANSI(sb.View()), "⚠ 42 dropped") {
+		t.Errorf("drop count missing:\n%s", stripANSI(sb.View()))
+	}
+}
+
 func TestStatusBar_LongResourceInfo(t *testing.T) {
 	sb := NewStatusBar(CatppuccinMocha)
 	sb.SetSize(80)
@@ -337,6 +356,119 @@ func TestTimelineView_Fullscreen(t *testing.T) {
 	}
 }
 
+func TestTimelineList_Gaps(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	var entries []resource.TimelineEntry
+	for i, offset := range []time.Duration{10 * time.Minute, 2 * time.Minute, 0} {
+		entries = append(entries, resource.TimelineEntry{
+			Resource: resource.Resource{Kind: "Pod", Name: "web", UID: "uid-" + strconv.Itoa(i)},
+			Revision: resource.Revision{ID: resource.RevisionID(i + 1), EventType: resource.EventModified, Time: base.Add(offset)},
+		})
+	}
+	gap := resource.Gap{Start: base.Add(3 * time.Minute), End: base.Add(8 * time.Minute), Discarded: 4}
+
+	tl := NewTimelineList(CatppuccinMocha)
+	tl.SetSize(120, 20)
+	tl.SetGaps([]resource.Gap{gap})
+	tl.SetEntries(entries)
+
+	gapAt := func() int {
+		for i, item := range tl.flatItems {
+			if item.gap != nil {
+				return i
+			}
+		}
+		return -1
+	}
+	if got := gapAt(); got != 1 || len(tl.flatItems) != 4 {
+		t.Fatalf("gap row at %d of %d items, want 1 of 4", got, len(tl.flatItems))
+	}
+	out := tl.View()
+	if !strings.Contains(out, "recording paused") || !strings.Contains(out, "4 events not recorded") {
+		t.Errorf("gap row not rendered:\n%s", out)
+	}
+	assertDimensions(t, "timeline with gap", out, 120, 20)
+
+	tl.SetFocus(true)
+	tl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if got := gapAt(); got != 2 {
+		t.Errorf("reversed: gap row at %d, want 2", got)
+	}
+}
+
+func TestTimelineList_Annotations(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	pod := resource.Resource{Kind: "Pod", Name: "web", UID: "uid-1"}
+	var entries []resource.TimelineEntry
+	for i, offset := range []time.Duration{10 * time.Minute, 0} { // newest first
+		entries = append(entries, resource.TimelineEntry{
+			Resource: pod,
+			Revision: resource.Revision{ID: resource.RevisionID(2 - i), EventType: resource.EventModified, Time: base.Add(offset)},
+		})
+	}
+	annotations := []resource.TimelineAnnotation{
+		{Resource: pod, Annotation: resource.Annotation{ID: "e1/1", Time: base.Add(5 * time.Minute), Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 3}},
+		{Resource: pod, Annotation: resource.Annotation{ID: "e2/1", Time: base.Add(10 * time.Minute), Type: "Normal", Reason: "Pulled"}},
+	}
+
+	tl := NewTimelineList(CatppuccinMocha)
+	tl.SetSize(120, 20)
+	tl.SetAnnotations(annotations)
+	tl.SetEntries(entries)
+
+	var got []string
+	for _, item := range tl.flatItems {
+		switch {
+		case item.entry != nil:
+			got = append(got, item.entry.Revision.ID.String())
+		case item.annotation != nil:
+			got = append(got, item.annotation.Annotation.Reason)
+		}
+	}
+	// Newest first; an annotation at the time of a revision comes after it.
+	if want := []string{"Pulled", resource.RevisionID(2).String(), "BackOff", resource.RevisionID(1).String()}; !slices.Equal(got, want) {
+		t.Fatalf("rows %v, want %v", got, want)
+	}
+	out := tl.View()
+	if !strings.Contains(out, "BackOff ×3") || !strings.Contains(out, "Back-off restarting") {
+		t.Errorf("annotation row not rendered:\n%s", out)
+	}
+	assertDimensions(t, "timeline with annotations", out, 120, 20)
+
+	tl.StartFilter()
+	tl.filterTextInput.SetValue("backoff")
+	tl.ApplyFilter()
+	if len(tl.flatItems) != 1 || tl.flatItems[0].annotation == nil || tl.flatItems[0].annotation.Annotation.Reason != "BackOff" {
+		t.Errorf("filtered to %d rows, want only the BackOff annotation", len(tl.flatItems))
+	}
+}
+
+func TestRevisionList_Annotations(t *testing.T) {
+	rd := sampleResource("Pod", "web", "default", "uid-1", 3)
+	rd.Annotations = []resource.Annotation{
+		{ID: "e1/1", Time: rd.Revisions[1].Time.Add(time.Second), Type: "Warning", Reason: "Unhealthy"},
+	}
+	rl := NewRevisionList(CatppuccinMocha)
+	rl.SetSize(60, 10)
+	rl.SetResource(rd)
+
+	rows, cursorRow := rl.rows()
+	if len(rows) != 4 || rows[1].annotation == nil || cursorRow != 0 {
+		t.Fatalf("rows %+v, cursor row %d; want the annotation second of 4 and the cursor first", rows, cursorRow)
+	}
+	rl.SetFocus(true)
+	rl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	rl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if _, cursorRow = rl.rows(); rl.cursor != 0 || cursorRow != 3 {
+		t.Errorf("cursor %d at row %d, want 0 at row 3", rl.cursor, cursorRow)
+	}
+	out := rl.View()
+	if !strings.Contains(out, "Unhealthy") {
+		t.Errorf("annotation row not rendered:\n%s", out)
+	}
+	assertDimensions(t, "revisions with annotations", out, 60, 10)
+}
+
 // ---------------------------------------------------------------------------
 // CompareViewComponent tests
 // ---------------------------------------------------------------------------
@@ -365,10 +497,183 @@ func TestCompareView_Fullscreen(t *testing.T) {
 	assertDimensions(t, "compare fullscreen", out, 120, 40)
 }
 
+func TestCompareView_PatchKeys(t *testing.T) {
+	cv := NewCompareViewComponent(CatppuccinMocha)
+	cv.SetSize(120, 40)
+
+	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")}
+	if msg, ok := cv.Update(key)().(StatusMsg); !ok || !msg.IsError {
+		t.Fatalf("without marks want an error status, got %#v", msg)
+	}
+
+	rd := sampleResource("Deployment", "nginx", "default", "uid-1", 3)
+	cv.AddItem(resource.CompareItem{Resource: rd.Resource, Revision: rd.Revisions[0]})
+	cv.AddItem(resource.CompareItem{Resource: rd.Resource, Revision: rd.Revisions[2]})
+
+	msg, ok := cv.Update(key)().(ExportPatchMsg)
+	if !ok {
+		t.Fatalf("want ExportPatchMsg, got %#v", msg)
+	}
+	if msg.From.Revision.ID != 1 || msg.To.Revision.ID != 3 || msg.Format != PatchFormatMerge || !msg.Clipboard {
+		t.Fatalf("unexpected patch request: %+v", msg)
+	}
+}
+
+func TestCompareView_SplitLayoutToggle(t *testing.T) {
+	cv := NewCompareViewComponent(CatppuccinMocha)
+	cv.SetSize(120, 40)
+	rd := sampleResource("Deployment", "nginx", "default", "uid-1", 3)
+	rd.Revisions[2].Object = map[string]any{"kind": "Deployment", "metadata": map[string]any{"name": "nginx-v2"}}
+	cv.AddItem(resource.CompareItem{Resource: rd.Resource, Revision: rd.Revisions[0]})
+	cv.AddItem(resource.CompareItem{Resource: rd.Resource, Revision: rd.Revisions[2]})
+
+	v := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")}
+	cv.Update(v)
+	if !cv.panel.Split() {
+		t.Fatal("v should switch to the side-by-side layout")
+	}
+	out := cv.View()
+	assertDimensions(t, "compare split", out, 120, 40)
+	plain := stripANSI(out)
+	if !strings.Contains(plain, `-   name: "nginx" `) || !strings.Contains(plain, `+   name: "nginx-v2"`) {
+		t.Fatalf("split layout should put both names on one row:\n%s", plain)
+	}
+
+	cv.Update(v)
+	if cv.panel.Split() {
+		t.Fatal("v again should switch back to inline")
+	}
+}
+
+func TestDetailView_SpanComposesPatches(t *testing.T) {
+	rd := &resource.Data{Resource: resource.Resource{Kind: "ConfigMap", Name: "cm", UID: "u"}}
+	prev := map[string]any{"data": map[string]any{"v": "0"}}
+	rd.Revisions = append(rd.Revisions, resource.Revision{ID: 1, Object: prev})
+	for i := 2; i <= 4; i++ {
+		obj := map[string]any{"data": map[string]any{"v": strconv.Itoa(i), "k" + strconv.Itoa(i): "x"}}
+		rd.Revisions = append(rd.Revisions, resource.Revision{
+			ID:         resource.RevisionID(i),
+			PreviousID: resource.RevisionID(i - 1),
+			Object:     obj,
+			Patch:      diffmap.Diff(prev, obj),
+		})
+		prev = obj
+	}
+
+	dv := NewDetailView(CatppuccinMocha)
+	dv.SetSize(80, 30)
+	dv.SetFocus(true)
+	dv.SetRevision(rd, 3)
+	for range 5 { // clamped to the first revision
+		dv.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	}
+	if dv.baseIndex() != 0 {
+		t.Fatalf("base index = %d, want 0", dv.baseIndex())
+	}
+
+	got := resource.CloneMap(rd.Revisions[0].Object)
+	diffmap.Apply(got, dv.spanPatch())
+	if !reflect.DeepEqual(got, rd.Revisions[3].Object) {
+		t.Fatalf("composed patch gives %v, want %v", got, rd.Revisions[3].Object)
+	}
+
+	msg, ok := dv.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Y")})().(ExportPatchMsg)
+	if !ok || msg.From.Revision.ID != 1 || msg.To.Revision.ID != 4 {
+		t.Fatalf("patch export should span from the base revision, got %+v", msg)
+	}
+}
+
+func TestDetailView_ContextModeExpandsBlocks(t *testing.T) {
+	data := func(v string) map[string]any {
+		m := map[string]any{"v": v}
+		for i := range 10 {
+			m["k"+strconv.Itoa(i)] = "x"
+		}
+		return map[string]any{"data": m}
+	}
+	rd := &resource.Data{Resource: resource.Resource{Kind: "ConfigMap", Name: "cm", UID: "u"}}
+	rd.Revisions = []resource.Revision{
+		{ID: 1, Object: data("0")},
+		{ID: 2, PreviousID: 1, Object: data("1")},
+	}
+
+	dv := NewDetailView(CatppuccinMocha)
+	dv.SetSize(80, 30)
+	dv.SetFocus(true)
+	dv.SetRevision(rd, 1)
+	key := func(k string) { dv.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+
+	key("x")
+	plain := stripANSI(dv.View())
+	if !strings.Contains(plain, "… 8 unchanged fields") || strings.Contains(plain, "k0:") {
+		t.Fatalf("context mode should fold the fields away from the change:\n%s", plain)
+	}
+	if !strings.Contains(plain, "k8:") || !strings.Contains(plain, "v:") {
+		t.Fatalf("context mode should keep the change and its neighbours:\n%s", plain)
+	}
+
+	key("z")
+	plain = stripANSI(dv.View())
+	if strings.Contains(plain, "unchanged fields") || !strings.Contains(plain, "k0:") {
+		t.Fatalf("z should expand the folded block:\n%s", plain)
+	}
+
+	key("Z")
+	if plain = stripANSI(dv.View()); !strings.Contains(plain, "… 8 unchanged fields") {
+		t.Fatalf("Z should fold the block again:\n%s", plain)
+	}
+}
+
+func TestDetailView_HideNormalizedToggle(t *testing.T) {
+	rd := &resource.Data{Resource: resource.Resource{Kind: "Pod", Name: "p", UID: "u"}}
+	rd.Revisions = []resource.Revision{
+		{ID: 1, Object: map[string]any{"resources": map[string]any{"limits": map[string]any{"cpu": "1000m"}}, "image": "nginx:1"}},
+		{ID: 2, PreviousID: 1, Object: map[string]any{"resources": map[string]any{"limits": map[string]any{"cpu": "1"}}, "image": "nginx:2"}},
+	}
+	dv := NewDetailView(CatppuccinMocha)
+	dv.SetSize(80, 30)
+	dv.SetFocus(true)
+	dv.SetRevision(rd, 1)
+	dv.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+
+	if plain := stripANSI(dv.View()); !strings.Contains(plain, `# normalized, was "1000m"`) {
+		t.Fatalf("changes view should mark the normalized edit:\n%s", plain)
+	}
+	dv.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	plain := stripANSI(dv.View())
+	if strings.Contains(plain, "cpu") || !strings.Contains(plain, "image") {
+		t.Fatalf("n should hide the normalized edit only:\n%s", plain)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // CommandPalette tests
 // ---------------------------------------------------------------------------
 
+// The same kind of two clusters gets two groups that collapse independently.
+func TestResourceTree_ClusterGroups(t *testing.T) {
+	staging := sampleResource("Deployment", "web", "default", "staging/uid-1", 1)
+	staging.Resource.Cluster = "staging"
+	prod := sampleResource("Deployment", "web", "default", "prod/uid-1", 1)
+	prod.Resource.Cluster = "prod"
+
+	rt := NewResourceTree(CatppuccinMocha)
+	rt.SetSize(60, 10)
+	rt.SetFocus(true)
+	rt.SetGroups(resource.BuildKindGroups([]*resource.Data{staging, prod}))
+
+	out := stripANSI(rt.View())
+	if !strings.Contains(out, "Deployment @prod 1") || !strings.Contains(out, "Deployment @staging 1") {
+		t.Fatalf("expected a group per cluster:\n%s", out)
+	}
+
+	// Collapse the first (prod) group; staging keeps its resource visible.
+	rt.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, total := rt.CursorInfo(); total != 3 {
+		t.Errorf("expected 2 headers and 1 resource after collapsing prod, got %d items", total)
+	}
+}
+
 func TestCommandPalette_HiddenReturnsEmpty(t *testing.T) {
 	cp := NewCommandPalette(CatppuccinMocha, NewCommandRegistry())
 	cp.SetSize(120, 40)
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/loog-project/loog/internal/resource"
+	"github.com/loog-project/loog/pkg/diffmap"
 	"github.com/loog-project/loog/pkg/diffpreview"
 )
 
@@ -67,7 +68,7 @@ type ResourceTree struct {
 	items         []treeItem // flattened visible items
 	selected      string     // UID of the selected resource
 
-	// Persistent expanded state keyed by kind name.
+	// Persistent expanded state keyed by kind group (see KindGroup.Key).
 	// true = expanded, false = collapsed. Kinds not in the map default to expanded.
 	expandState map[string]bool
 
@@ -100,6 +101,7 @@ const (
 type treeItem struct {
 	Type     treeItemType
 	Kind     string         // for both types
+	Group    string         // key of the kind group, for both types
 	Resource *resource.Data // only for treeItemResource
 }
 
@@ -124,7 +126,7 @@ func (rt *ResourceTree) SetGroups(groups []*resource.KindGroup) {
 	// Apply persistent expand state: if the user has previously collapsed a kind,
 	// carry that forward. New kinds default to expanded.
 	for _, g := range groups {
-		if expanded, ok := rt.expandState[g.Kind]; ok {
+		if expanded, ok := rt.expandState[g.Key()]; ok {
 			g.Expanded = expanded
 		}
 	}
@@ -154,7 +156,7 @@ func (rt *ResourceTree) SelectByUID(uid string) {
 			if rd.Resource.UID == uid {
 				if !g.Expanded {
 					g.Expanded = true
-					rt.expandState[g.Kind] = true
+					rt.expandState[g.Key()] = true
 					rt.buildItems()
 				}
 				// Now find it in the rebuilt items
@@ -314,11 +316,11 @@ func (rt *ResourceTree) buildItems() {
 	// rebuild. rt.selected only tracks resources, so a live refresh would
 	// otherwise yank the cursor off a kind header back onto the selected
 	// resource. Capture the header/resource identity before we discard items.
-	var cursorUID, cursorKind string
+	var cursorUID, cursorGroup string
 	hadCursorItem := rt.cursor >= 0 && rt.cursor < len(rt.items)
 	if hadCursorItem {
 		ci := rt.items[rt.cursor]
-		cursorKind = ci.Kind
+		cursorGroup = ci.Group
 		if ci.Type == treeItemResource && ci.Resource != nil {
 			cursorUID = ci.Resource.Resource.UID
 		}
@@ -347,7 +349,7 @@ func (rt *ResourceTree) buildItems() {
 				continue
 			}
 		}
-		rt.items = append(rt.items, treeItem{Type: treeItemKind, Kind: g.Kind})
+		rt.items = append(rt.items, treeItem{Type: treeItemKind, Kind: g.Kind, Group: g.Key()})
 		if g.Expanded {
 			resources := g.Resources
 			if rt.sortByTime {
@@ -362,7 +364,7 @@ func (rt *ResourceTree) buildItems() {
 				if !shouldShow(rd) {
 					continue
 				}
-				rt.items = append(rt.items, treeItem{Type: treeItemResource, Kind: g.Kind, Resource: rd})
+				rt.items = append(rt.items, treeItem{Type: treeItemResource, Kind: g.Kind, Group: g.Key(), Resource: rd})
 			}
 		}
 	}
@@ -377,7 +379,7 @@ func (rt *ResourceTree) buildItems() {
 					rt.cursor = i
 					return
 				}
-			} else if item.Type == treeItemKind && item.Kind == cursorKind {
+			} else if item.Type == treeItemKind && item.Group == cursorGroup {
 				rt.cursor = i
 				return
 			}
@@ -430,9 +432,9 @@ func (rt *ResourceTree) Update(msg tea.Msg) tea.Cmd {
 				item := rt.items[rt.cursor]
 				if item.Type == treeItemKind {
 					for _, g := range rt.groups {
-						if g.Kind == item.Kind {
+						if g.Key() == item.Group {
 							g.Expanded = !g.Expanded
-							rt.expandState[g.Kind] = g.Expanded
+							rt.expandState[g.Key()] = g.Expanded
 							break
 						}
 					}
@@ -596,7 +598,7 @@ func (rt *ResourceTree) View() string {
 			if previewing && item.Type == treeItemKind {
 				hasMatch := false
 				for _, g := range rt.groups {
-					if g.Kind == item.Kind {
+					if g.Key() == item.Group {
 						for _, rd := range g.Resources {
 							if rt.matchesFilter(rd.Resource) {
 								hasMatch = true
@@ -616,7 +618,7 @@ func (rt *ResourceTree) View() string {
 			case treeItemKind:
 				var g *resource.KindGroup
 				for _, grp := range rt.groups {
-					if grp.Kind == item.Kind {
+					if grp.Key() == item.Group {
 						g = grp
 						break
 					}
@@ -637,7 +639,15 @@ func (rt *ResourceTree) View() string {
 					kindStyle = kindStyle.Foreground(rt.theme.Surface2).Bold(false)
 					countStyle = countStyle.Foreground(rt.theme.Surface2)
 				}
-				line = arrowStyle.Render(arrow) + " " + kindStyle.Render(item.Kind) + " " + countStyle.Render(fmt.Sprintf("%d", count))
+				clusterBadge := ""
+				if g != nil && g.Cluster != "" {
+					clusterStyle := lipgloss.NewStyle().Foreground(rt.theme.Mauve)
+					if isDimmed {
+						clusterStyle = clusterStyle.Foreground(rt.theme.Surface2)
+					}
+					clusterBadge = clusterStyle.Render("@"+g.Cluster) + " "
+				}
+				line = arrowStyle.Render(arrow) + " " + kindStyle.Render(item.Kind) + " " + clusterBadge + countStyle.Render(fmt.Sprintf("%d", count))
 
 			case treeItemResource:
 				rd := item.Resource
@@ -879,14 +889,43 @@ func (rl *RevisionList) CursorInfo() (int, int) {
 	return rl.cursor, len(rl.resource.Revisions)
 }
 
+// revisionRow is a row of the revision list: a revision, by slice index, or
+// an annotation of the resource.
+type revisionRow struct {
+	rev        int // -1 on an annotation row
+	annotation *resource.Annotation
+}
+
+// rows returns the display rows, newest first, with the annotations
+// interleaved where they happened in time, and the row of the cursor.
+func (rl *RevisionList) rows() (rows []revisionRow, cursorRow int) {
+	revs := rl.resource.Revisions
+	anns := rl.resource.Annotations
+	rows = make([]revisionRow, 0, len(revs)+len(anns))
+	ai := len(anns) - 1
+	for i := len(revs) - 1; i >= 0; i-- {
+		// An annotation at the time of a revision comes after it.
+		for ; ai >= 0 && !revs[i].Time.After(anns[ai].Time); ai-- {
+			rows = append(rows, revisionRow{rev: -1, annotation: &anns[ai]})
+		}
+		if i == rl.cursor {
+			cursorRow = len(rows)
+		}
+		rows = append(rows, revisionRow{rev: i})
+	}
+	for ; ai >= 0; ai-- {
+		rows = append(rows, revisionRow{rev: -1, annotation: &anns[ai]})
+	}
+	return rows, cursorRow
+}
+
 func (rl *RevisionList) CanScrollUp() bool {
 	if rl.resource == nil {
 		return false
 	}
-	total := len(rl.resource.Revisions)
+	rows, visualCursor := rl.rows()
 	itemHeight := rl.height - 1
-	visualCursor := total - 1 - rl.cursor
-	vp := calcViewport(total, visualCursor, itemHeight, rl.scrollTop)
+	vp := calcViewport(len(rows), visualCursor, itemHeight, rl.scrollTop)
 	return vp.above > 0
 }
 
@@ -894,10 +933,9 @@ func (rl *RevisionList) CanScrollDown() bool {
 	if rl.resource == nil {
 		return false
 	}
-	total := len(rl.resource.Revisions)
+	rows, visualCursor := rl.rows()
 	itemHeight := rl.height - 1
-	visualCursor := total - 1 - rl.cursor
-	vp := calcViewport(total, visualCursor, itemHeight, rl.scrollTop)
+	vp := calcViewport(len(rows), visualCursor, itemHeight, rl.scrollTop)
 	return vp.below > 0
 }
 
@@ -1070,9 +1108,10 @@ func (rl *RevisionList) View() string {
 		return strings.Join(lines, "\n")
 	}
 
-	// Display order: visual row 0 = newest, visual row total-1 = oldest.
-	visualCursor := total - 1 - rl.cursor
-	vp := calcViewport(total, visualCursor, itemHeight, rl.scrollTop)
+	// Display order: visual row 0 = newest, the last row = oldest, with the
+	// annotations between the revisions.
+	rows, visualCursor := rl.rows()
+	vp := calcViewport(len(rows), visualCursor, itemHeight, rl.scrollTop)
 	rl.scrollTop = vp.start
 
 	renderStart := vp.start
@@ -1087,7 +1126,13 @@ func (rl *RevisionList) View() string {
 	}
 
 	for v := renderStart; v < renderEnd; v++ {
-		sliceIdx := total - 1 - v
+		if a := rows[v].annotation; a != nil {
+			line := "   " + lipgloss.NewStyle().Foreground(timeColor(rl.theme, a.Time)).Render(resource.RelativeTime(a.Time)) + " "
+			line += renderAnnotationText(rl.theme, *a, rl.width-lipgloss.Width(line)-1)
+			lines = append(lines, PadRight(line, rl.width))
+			continue
+		}
+		sliceIdx := rows[v].rev
 		rev := revs[sliceIdx]
 		isSelected := sliceIdx == rl.cursor && rl.focused
 		isPassive := sliceIdx == rl.cursor && !rl.focused
@@ -1174,7 +1219,22 @@ type DetailView struct {
 	revIndex      int
 	viewMode      ViewMode
 	content       string
-}
+	// span is how many revisions back the diff, changes and patch views
+	// reach; 1 compares against the previous revision.
+	span int
+	// expanded holds the folded blocks the user opened in context mode. Block
+	// IDs are field paths, so they stay open across revisions.
+	expanded map[string]bool
+	// blocks are the folded blocks of the current context-mode content.
+	blocks []diffpreview.CollapsedBlock
+	// hideNormalized shows edits that only respell a value (1000m → 1) as
+	// unchanged in the diff, changes and context views.
+	hideNormalized bool
+}
+
+// contextFields is how many unchanged fields context mode keeps on either
+// side of a change.
+const contextFields = 2
 
 func NewDetailView(theme Theme) *DetailView {
 	vp := viewport.New(0, 0)
@@ -1182,6 +1242,7 @@ func NewDetailView(theme Theme) *DetailView {
 		theme:    theme,
 		viewport: vp,
 		viewMode: DiffMode,
+		span:     1,
 	}
 }
 
@@ -1231,11 +1292,15 @@ func (dv *DetailView) CurrentHint() string {
 		dv.theme.KeyHint("d", "diff"),
 		dv.theme.KeyHint("o", "object"),
 		dv.theme.KeyHint("p", "changes"),
+		dv.theme.KeyHint("x", "context"),
 		dv.theme.KeyHint("J", "json"),
 		dv.theme.KeyHint("r", "raw"),
 		dv.theme.KeyHint("[/]", "prev/next"),
 		dv.theme.KeyHint("e", "export"),
 		dv.theme.KeyHint("y", "copy"),
+		dv.theme.KeyHint("+/-", "span"),
+		dv.theme.KeyHint("n", "normalized"),
+		dv.theme.KeyHint("Y/M", "copy patch"),
 	}, "  ")
 }
 
@@ -1256,6 +1321,10 @@ func (dv *DetailView) renderContent() {
 		dv.theme.EventTypeStyle(rev.EventType).Render(rev.EventType.Symbol()),
 		lipgloss.NewStyle().Foreground(dv.theme.Overlay1).Render(resource.FormatTimestamp(rev.Time)),
 	)
+	if base := dv.baseIndex(); base >= 0 && dv.revIndex-base > 1 {
+		title += lipgloss.NewStyle().Foreground(dv.theme.Peach).Render(
+			fmt.Sprintf("  Δ %d revisions since %s", dv.revIndex-base, dv.resource.Revisions[base].ID.String()))
+	}
 	titleLine := lipgloss.NewStyle().Bold(true).Render(title)
 
 	sepW := dv.width
@@ -1265,6 +1334,7 @@ func (dv *DetailView) renderContent() {
 	separator := lipgloss.NewStyle().Foreground(dv.theme.Surface1).Render(strings.Repeat("─", sepW))
 
 	var body string
+	dv.blocks = nil
 	switch dv.viewMode {
 	case DiffMode:
 		body = dv.renderDiff(rev)
@@ -1272,6 +1342,8 @@ func (dv *DetailView) renderContent() {
 		body = RenderYAMLObject(rev.Object, dv.theme, 2)
 	case PatchMode:
 		body = dv.renderChanges(rev)
+	case ContextMode:
+		body = dv.renderContext(rev)
 	case JSONMode:
 		body = RenderJSONObject(rev.Object, dv.theme)
 	case RawMode:
@@ -1288,11 +1360,7 @@ func (dv *DetailView) renderDiff(rev resource.Revision) string {
 		return dv.theme.MutedStyle().Render("(no object data)")
 	}
 
-	var prevObj map[string]any
-	if dv.revIndex > 0 {
-		prevObj = dv.resource.Revisions[dv.revIndex-1].Object
-	}
-
+	prevObj := dv.baseObject()
 	if prevObj == nil {
 		return RenderYAMLObject(rev.Object, dv.theme, 2)
 	}
@@ -1303,34 +1371,88 @@ func (dv *DetailView) renderDiff(rev resource.Revision) string {
 	return diffpreview.RenderYAML(node, dpTheme, diffpreview.RenderOptions{
 		IndentSize:                2,
 		EnableBackgroundHighlight: true,
+		HideNormalized:            dv.hideNormalized,
 	})
 }
 
-// renderChanges shows only the fields that changed versus the previous
-// revision, computed from the objects. Unlike the stored patch, this works for
+// renderChanges shows only the fields that changed versus the base revision
+// (see baseIndex), computed from the objects. Unlike the stored patch, this works for
 // snapshot revisions too (they have no stored patch).
 func (dv *DetailView) renderChanges(rev resource.Revision) string {
 	if rev.Object == nil {
 		return dv.theme.MutedStyle().Render("(no object data)")
 	}
 
-	var prevObj map[string]any
-	if dv.revIndex > 0 {
-		prevObj = dv.resource.Revisions[dv.revIndex-1].Object
-	}
-
-	node := diffpreview.Diff(prevObj, rev.Object)
+	node := diffpreview.Diff(dv.baseObject(), rev.Object)
 	out := diffpreview.RenderYAML(node, dv.theme.DiffPreviewTheme(), diffpreview.RenderOptions{
 		IndentSize:                2,
 		EnableBackgroundHighlight: true,
 		ChangesOnly:               true,
+		HideNormalized:            dv.hideNormalized,
 	})
 	if strings.TrimSpace(out) == "" {
-		return dv.theme.MutedStyle().Render("(no changes from previous revision)")
+		return dv.theme.MutedStyle().Render("(no changes from base revision)")
 	}
 	return out
 }
 
+// renderContext is the diff with unchanged fields folded, except for
+// contextFields around each change and the blocks the user expanded.
+func (dv *DetailView) renderContext(rev resource.Revision) string {
+	if rev.Object == nil {
+		return dv.theme.MutedStyle().Render("(no object data)")
+	}
+
+	node := diffpreview.Diff(dv.baseObject(), rev.Object)
+	out, blocks := diffpreview.RenderYAMLBlocks(node, dv.theme.DiffPreviewTheme(), diffpreview.RenderOptions{
+		IndentSize:                2,
+		EnableBackgroundHighlight: true,
+		Collapse:                  true,
+		CollapseContext:           contextFields,
+		Expanded:                  dv.expanded,
+		HideNormalized:            dv.hideNormalized,
+	})
+	dv.blocks = blocks
+	return out
+}
+
+// expandBlock opens the first folded block at or below the top of the
+// viewport, keeping the scroll position.
+func (dv *DetailView) expandBlock() tea.Cmd {
+	if dv.viewMode != ContextMode {
+		return nil
+	}
+	// The body starts after the title and separator lines.
+	const headerLines = 2
+	top := dv.viewport.YOffset
+	for _, b := range dv.blocks {
+		line := b.Line + headerLines
+		if line < top {
+			continue
+		}
+		if line >= top+max(dv.viewport.Height, 1) {
+			break
+		}
+		if dv.expanded == nil {
+			dv.expanded = make(map[string]bool)
+		}
+		dv.expanded[b.ID] = true
+		dv.renderContent()
+		dv.viewport.SetYOffset(top)
+		return nil
+	}
+	return Cmd(StatusMsg{Text: "No folded block in view"})
+}
+
+// collapseAll folds every block the user expanded again.
+func (dv *DetailView) collapseAll() {
+	if dv.viewMode != ContextMode || len(dv.expanded) == 0 {
+		return
+	}
+	dv.expanded = nil
+	dv.renderContent()
+}
+
 // renderRaw shows the revision as a raw database record.
 func (dv *DetailView) renderRaw(rev resource.Revision) string {
 	var lines []string
@@ -1350,6 +1472,9 @@ func (dv *DetailView) renderRaw(rev resource.Revision) string {
 		lines = append(lines, keyStyle.Render("Kind:         ")+valStyle.Render(r.Kind))
 		lines = append(lines, keyStyle.Render("Name:         ")+valStyle.Render(r.Name))
 		lines = append(lines, keyStyle.Render("Namespace:    ")+valStyle.Render(r.Namespace))
+		if r.Cluster != "" {
+			lines = append(lines, keyStyle.Render("Cluster:      ")+valStyle.Render(r.Cluster))
+		}
 		lines = append(lines, keyStyle.Render("Starred:      ")+valStyle.Render(fmt.Sprintf("%v", r.Starred)))
 	}
 	lines = append(lines, "")
@@ -1368,10 +1493,15 @@ func (dv *DetailView) renderRaw(rev resource.Revision) string {
 	}
 	lines = append(lines, "")
 
-	lines = append(lines, headerStyle.Render("── Patch (raw JSON) ──"))
+	patch, patchTitle := rev.Patch, "── Patch (raw JSON) ──"
+	if base := dv.baseIndex(); base >= 0 && dv.revIndex-base > 1 {
+		patch = dv.spanPatch()
+		patchTitle = fmt.Sprintf("── Patch (composed over %d revisions) ──", dv.revIndex-base)
+	}
+	lines = append(lines, headerStyle.Render(patchTitle))
 	lines = append(lines, "")
-	if rev.Patch != nil {
-		raw, err := json.MarshalIndent(rev.Patch, "", "  ")
+	if patch != nil {
+		raw, err := json.MarshalIndent(patch, "", "  ")
 		if err != nil {
 			lines = append(lines, mutedStyle.Render("(error marshaling: "+err.Error()+")"))
 		} else {
@@ -1417,6 +1547,20 @@ func (dv *DetailView) Update(msg tea.Msg) tea.Cmd {
 		case "p":
 			dv.SetViewMode(PatchMode)
 			return Cmd(ViewModeChangedMsg{Mode: PatchMode})
+		case "x":
+			dv.SetViewMode(ContextMode)
+			return Cmd(ViewModeChangedMsg{Mode: ContextMode})
+		case "z":
+			return dv.expandBlock()
+		case "Z":
+			dv.collapseAll()
+		case "n":
+			dv.hideNormalized = !dv.hideNormalized
+			dv.renderContent()
+			if dv.hideNormalized {
+				return Cmd(StatusMsg{Text: "Hiding normalized edits"})
+			}
+			return Cmd(StatusMsg{Text: "Showing normalized edits"})
 		case "J":
 			dv.SetViewMode(JSONMode)
 			return Cmd(ViewModeChangedMsg{Mode: JSONMode})
@@ -1435,6 +1579,16 @@ func (dv *DetailView) Update(msg tea.Msg) tea.Cmd {
 			if dv.resource != nil {
 				return Cmd(CopyToClipboardMsg{Resource: dv.resource, RevIndex: dv.revIndex})
 			}
+		case "+":
+			return dv.setSpan(dv.span + 1)
+		case "-":
+			return dv.setSpan(dv.span - 1)
+		case "E":
+			return dv.patchFromBase(PatchFormatJSON, false)
+		case "Y":
+			return dv.patchFromBase(PatchFormatJSON, true)
+		case "M":
+			return dv.patchFromBase(PatchFormatMerge, true)
 		case "t":
 			if dv.resource != nil && dv.revIndex < len(dv.resource.Revisions) {
 				rev := dv.resource.Revisions[dv.revIndex]
@@ -1466,6 +1620,73 @@ func (dv *DetailView) Update(msg tea.Msg) tea.Cmd {
 	return nil
 }
 
+// baseIndex returns the index of the revision the current one is compared
+// against: span revisions back, clamped to the first. It is -1 when there is
+// no earlier revision.
+func (dv *DetailView) baseIndex() int {
+	if dv.resource == nil || dv.revIndex <= 0 || dv.revIndex >= len(dv.resource.Revisions) {
+		return -1
+	}
+	return max(dv.revIndex-dv.span, 0)
+}
+
+func (dv *DetailView) baseObject() map[string]any {
+	if base := dv.baseIndex(); base >= 0 {
+		return dv.resource.Revisions[base].Object
+	}
+	return nil
+}
+
+// spanPatch composes the stored patches from the base revision to the current
+// one into a single change-set. Snapshot revisions carry no patch, and a
+// filtered history may skip revisions; the objects are diffed directly then.
+func (dv *DetailView) spanPatch() diffmap.DiffMap {
+	base := dv.baseIndex()
+	revs := dv.resource.Revisions
+	var chg diffmap.DiffMap
+	for i := base + 1; i <= dv.revIndex; i++ {
+		if revs[i].Patch == nil || revs[i].PreviousID != revs[i-1].ID {
+			return diffmap.Diff(revs[base].Object, revs[dv.revIndex].Object)
+		}
+		chg = diffmap.Compose(chg, revs[i].Patch)
+	}
+	return chg
+}
+
+// setSpan changes how many revisions back the detail view compares.
+func (dv *DetailView) setSpan(span int) tea.Cmd {
+	span = max(span, 1)
+	if dv.resource != nil {
+		span = min(span, max(len(dv.resource.Revisions)-1, 1))
+	}
+	if span == dv.span {
+		return nil
+	}
+	dv.span = span
+	dv.renderContent()
+	if span == 1 {
+		return Cmd(StatusMsg{Text: "Comparing with the previous revision"})
+	}
+	return Cmd(StatusMsg{Text: fmt.Sprintf("Comparing across %d revisions", span)})
+}
+
+// patchFromBase requests the patch from the base revision to the current one.
+func (dv *DetailView) patchFromBase(format PatchFormat, clipboard bool) tea.Cmd {
+	if dv.resource == nil || dv.revIndex >= len(dv.resource.Revisions) {
+		return nil
+	}
+	base := dv.baseIndex()
+	if base < 0 {
+		return Cmd(StatusMsg{Text: "No previous revision to diff against", IsError: true})
+	}
+	return Cmd(ExportPatchMsg{
+		From:      resource.CompareItem{Resource: dv.resource.Resource, Revision: dv.resource.Revisions[base]},
+		To:        resource.CompareItem{Resource: dv.resource.Resource, Revision: dv.resource.Revisions[dv.revIndex]},
+		Format:    format,
+		Clipboard: clipboard,
+	})
+}
+
 func (dv *DetailView) View() string {
 	return dv.viewport.View()
 }
@@ -1476,7 +1697,9 @@ type TimelineList struct {
 	theme         Theme
 	focused       bool
 	entries       []resource.TimelineEntry
-	groups        []any // resource.TimelineEntry or resource.BurstGroup
+	annotations   []resource.TimelineAnnotation // oldest first, shown as rows among the entries
+	gaps          []resource.Gap                // recording pauses, shown as rows among the entries
+	groups        []any                         // resource.TimelineEntry or resource.BurstGroup
 	cursor        int
 	flatItems     []timelineFlatItem
 	scrollTop     int
@@ -1507,7 +1730,11 @@ type TimelineList struct {
 }
 
 type timelineFlatItem struct {
-	entry        *resource.TimelineEntry
+	entry *resource.TimelineEntry
+	// annotation is set instead of entry on the row of an annotation.
+	annotation *resource.TimelineAnnotation
+	// gap is set instead of entry on the row of a recording pause.
+	gap          *resource.Gap
 	isBurstStart bool
 	isBurstEnd   bool
 	isBurstMid   bool
@@ -1586,15 +1813,15 @@ func (tl *TimelineList) FilterCounts() (int, int) {
 	}
 	match := 0
 	for _, e := range tl.entries {
-		if tl.matchesFilter(e.Resource) {
+		if tl.matchesFilter(e) {
 			match++
 		}
 	}
 	return match, len(tl.entries)
 }
 
-func (tl *TimelineList) matchesFilter(r resource.Resource) bool {
-	return resource.MatchesSubstring(strings.ToLower(tl.filterTextInput.Value()), r)
+func (tl *TimelineList) matchesFilter(e resource.TimelineEntry) bool {
+	return resource.MatchesTimelineEntry(strings.ToLower(tl.filterTextInput.Value()), e)
 }
 
 func (tl *TimelineList) handleFilterMsg(msg tea.Msg) tea.Cmd {
@@ -1630,6 +1857,18 @@ func (tl *TimelineList) SetWindowAnchor(t time.Time) {
 	}
 }
 
+// SetGaps sets the recording pauses to show among the entries. They are
+// applied by the next rebuild, e.g. SetEntries.
+func (tl *TimelineList) SetGaps(gaps []resource.Gap) {
+	tl.gaps = gaps
+}
+
+// SetAnnotations sets the annotations to show among the entries, oldest
+// first. They are applied by the next rebuild, e.g. SetEntries.
+func (tl *TimelineList) SetAnnotations(annotations []resource.TimelineAnnotation) {
+	tl.annotations = annotations
+}
+
 func (tl *TimelineList) SetEntries(entries []resource.TimelineEntry) {
 	tl.entries = entries
 	tl.rebuild()
@@ -1654,7 +1893,7 @@ func (tl *TimelineList) rebuild() {
 	if tl.filterApplied && !tl.filterEditing && tl.filterTextInput.Value() != "" {
 		var matched []resource.TimelineEntry
 		for _, e := range filtered {
-			if tl.matchesFilter(e.Resource) {
+			if tl.matchesFilter(e) {
 				matched = append(matched, e)
 			}
 		}
@@ -1772,6 +2011,8 @@ func (tl *TimelineList) buildFlatItems() {
 			}
 		}
 	}
+	tl.insertAnnotations()
+	tl.insertGaps()
 
 	// Flag entries whose order relative to an adjacent entry is inferred
 	// because they fall within the near-simultaneous window. Ordering across
@@ -1793,6 +2034,93 @@ func (tl *TimelineList) buildFlatItems() {
 	}
 }
 
+// inWindow reports whether the time span from start to end overlaps the
+// time window, if any.
+func (tl *TimelineList) inWindow(start, end time.Time) bool {
+	if tl.windowMode == resource.WindowAll || tl.windowAnchor.IsZero() {
+		return true
+	}
+	halfDur := resource.WindowHalfDuration(tl.windowMode)
+	return !end.Before(tl.windowAnchor.Add(-halfDur)) && !start.After(tl.windowAnchor.Add(halfDur))
+}
+
+// insertAnnotations adds a row for each annotation within the time window
+// and the applied filter among the entry rows, where it happened in time.
+func (tl *TimelineList) insertAnnotations() {
+	query := ""
+	if tl.filterApplied && !tl.filterEditing {
+		query = strings.ToLower(tl.filterTextInput.Value())
+	}
+	var rows []timelineFlatItem
+	for i := range tl.annotations {
+		a := &tl.annotations[i]
+		if !tl.inWindow(a.Annotation.Time, a.Annotation.Time) ||
+			(query != "" && !resource.MatchesTimelineAnnotation(query, *a)) {
+			continue
+		}
+		rows = append(rows, timelineFlatItem{annotation: a})
+	}
+	// An annotation at the time of a revision comes after it.
+	tl.insertRows(rows, func(row, item timelineFlatItem) bool {
+		if tl.reversed {
+			return item.time().After(row.annotation.Annotation.Time)
+		}
+		return !item.time().After(row.annotation.Annotation.Time)
+	})
+}
+
+// insertGaps adds a row for each recording pause within the time window
+// among the entry rows, where it happened in time.
+func (tl *TimelineList) insertGaps() {
+	var rows []timelineFlatItem
+	for i := range tl.gaps {
+		if g := &tl.gaps[i]; tl.inWindow(g.Start, g.End) {
+			rows = append(rows, timelineFlatItem{gap: g})
+		}
+	}
+	tl.insertRows(rows, func(row, item timelineFlatItem) bool {
+		if tl.reversed {
+			return item.time().After(row.gap.Start)
+		}
+		return item.time().Before(row.gap.End)
+	})
+}
+
+// insertRows merges rows, given oldest first, among the flat items: each
+// goes before the first item [before] reports it precedes in display order.
+func (tl *TimelineList) insertRows(rows []timelineFlatItem, before func(row, item timelineFlatItem) bool) {
+	if len(rows) == 0 {
+		return
+	}
+	if !tl.reversed {
+		slices.Reverse(rows) // newest first, like the entries
+	}
+	items := make([]timelineFlatItem, 0, len(tl.flatItems)+len(rows))
+	ri := 0
+	for _, item := range tl.flatItems {
+		for ri < len(rows) && before(rows[ri], item) {
+			items = append(items, rows[ri])
+			ri++
+		}
+		items = append(items, item)
+	}
+	tl.flatItems = append(items, rows[ri:]...)
+}
+
+// time returns when the row's entry or annotation happened, or when its
+// recording pause started.
+func (item timelineFlatItem) time() time.Time {
+	switch {
+	case item.entry != nil:
+		return item.entry.Revision.Time
+	case item.annotation != nil:
+		return item.annotation.Annotation.Time
+	case item.gap != nil:
+		return item.gap.Start
+	}
+	return time.Time{}
+}
+
 // CurrentHint returns a context-sensitive hint for the status bar.
 func (tl *TimelineList) CurrentHint() string {
 	if tl.cursor >= len(tl.flatItems) || tl.cursor < 0 {
@@ -1958,6 +2286,12 @@ func (tl *TimelineList) View() string {
 	for i := dataStart; i < dataEnd; i++ {
 		item := tl.flatItems[i]
 		if item.entry == nil {
+			switch {
+			case item.annotation != nil:
+				lines = append(lines, tl.renderAnnotation(*item.annotation, i == tl.cursor))
+			case item.gap != nil:
+				lines = append(lines, tl.renderGap(*item.gap, i == tl.cursor))
+			}
 			continue
 		}
 
@@ -1966,7 +2300,7 @@ func (tl *TimelineList) View() string {
 		e := item.entry
 
 		previewing := tl.filterEditing && tl.filterTextInput.Value() != ""
-		isDimmed := previewing && !tl.matchesFilter(e.Resource)
+		isDimmed := previewing && !tl.matchesFilter(*e)
 
 		dimColor := tl.theme.Surface2
 
@@ -1998,22 +2332,27 @@ func (tl *TimelineList) View() string {
 			}
 		}
 
+		clusterPrefix := ""
+		if e.Resource.Cluster != "" {
+			clusterPrefix = e.Resource.Cluster + " "
+		}
 		kindPrefix := e.Resource.Kind + "/"
 		nameStr := e.Resource.Name
-		combined := kindPrefix + nameStr
+		combined := clusterPrefix + kindPrefix + nameStr
 		// Use display width (rune-aware) rather than byte length so multibyte
 		// names aren't over-truncated or dropped entirely.
 		if lipgloss.Width(combined) > nameMaxW {
-			nameStr = Truncate(nameStr, nameMaxW-lipgloss.Width(kindPrefix))
+			nameStr = Truncate(nameStr, nameMaxW-lipgloss.Width(clusterPrefix+kindPrefix))
 		}
 		var kindName string
 		if isDimmed {
-			kindName = lipgloss.NewStyle().Foreground(dimColor).Render(kindPrefix + nameStr)
+			kindName = lipgloss.NewStyle().Foreground(dimColor).Render(clusterPrefix + kindPrefix + nameStr)
 		} else {
 			kc := tl.theme.KindColor(e.Resource.Kind)
+			clusterPart := lipgloss.NewStyle().Foreground(tl.theme.Mauve).Render(clusterPrefix)
 			kindPart := lipgloss.NewStyle().Foreground(kc).Render(kindPrefix)
 			namePart := lipgloss.NewStyle().Foreground(tl.theme.Text).Render(nameStr)
-			kindName = kindPart + namePart
+			kindName = clusterPart + kindPart + namePart
 		}
 
 		etStr := ""
@@ -2051,6 +2390,18 @@ func (tl *TimelineList) View() string {
 
 		line := burstPrefix + anchorMark + timeStr + " " + compareBadge + star + kindName + " " + etStr + approxMark
 
+		// Changed-paths column, filling whatever width the row leaves.
+		if paths := resource.WithoutServerManagedPaths(e.Revision.ChangedPaths); len(paths) > 0 {
+			if room := tl.width - lipgloss.Width(line) - 3; room >= 8 {
+				fg := tl.theme.Overlay1
+				if isDimmed {
+					fg = dimColor
+				}
+				line += "  " + lipgloss.NewStyle().Foreground(fg).
+					Render(Truncate(strings.Join(paths, ", "), room))
+			}
+		}
+
 		padded := PadRight(line, tl.width)
 		if isSelected {
 			padded = lipgloss.NewStyle().
@@ -2081,6 +2432,72 @@ func (tl *TimelineList) View() string {
 	return strings.Join(lines, "\n")
 }
 
+// renderAnnotation renders the row of an annotation: when, of which
+// resource, and what it says.
+func (tl *TimelineList) renderAnnotation(a resource.TimelineAnnotation, atCursor bool) string {
+	timeStr := lipgloss.NewStyle().Foreground(timeColor(tl.theme, a.Annotation.Time)).
+		Render(resource.FormatTimestamp(a.Annotation.Time))
+	clusterPrefix := ""
+	if a.Resource.Cluster != "" {
+		clusterPrefix = a.Resource.Cluster + " "
+	}
+	kindName := lipgloss.NewStyle().Foreground(tl.theme.Mauve).Render(clusterPrefix) +
+		lipgloss.NewStyle().Foreground(tl.theme.KindColor(a.Resource.Kind)).Render(a.Resource.Kind+"/") +
+		lipgloss.NewStyle().Foreground(tl.theme.Text).Render(Truncate(a.Resource.Name, max(tl.width-26, 8)))
+
+	line := "   " + timeStr + " " + kindName + " "
+	line += renderAnnotationText(tl.theme, a.Annotation, tl.width-lipgloss.Width(line)-1)
+	padded := PadRight(line, tl.width)
+	if atCursor {
+		padded = lipgloss.NewStyle().Background(tl.theme.Surface0).Render(padded)
+	}
+	return padded
+}
+
+// renderAnnotationText renders an annotation's marker, reason, count and
+// message in at most [width] cells. Warnings stand out.
+func renderAnnotationText(theme Theme, a resource.Annotation, width int) string {
+	marker, fg := "◆", theme.Sky
+	if a.Type == "Warning" {
+		marker, fg = "▲", theme.Peach
+	}
+	head := marker + " " + a.Reason
+	if a.Count > 1 {
+		head += fmt.Sprintf(" ×%d", a.Count)
+	}
+	head = Truncate(head, width)
+	text := lipgloss.NewStyle().Foreground(fg).Render(head)
+	if room := width - lipgloss.Width(head) - 2; room >= 8 && a.Message != "" {
+		text += "  " + lipgloss.NewStyle().Foreground(theme.Overlay1).Render(Truncate(a.Message, room))
+	}
+	return text
+}
+
+// renderGap renders the row of a recording pause: when it was, and what
+// happened to the changes made meanwhile.
+func (tl *TimelineList) renderGap(gap resource.Gap, atCursor bool) string {
+	text := fmt.Sprintf("⏸ recording paused %s – %s (%s)",
+		resource.FormatTimestamp(gap.Start), resource.FormatTimestamp(gap.End),
+		gap.End.Sub(gap.Start).Round(time.Second))
+	var details []string
+	if gap.Discarded > 0 {
+		details = append(details, fmt.Sprintf("%d events not recorded", gap.Discarded))
+	}
+	if gap.CaughtUp > 0 {
+		details = append(details, fmt.Sprintf("%d objects caught up", gap.CaughtUp))
+	}
+	if len(details) > 0 {
+		text += " · " + strings.Join(details, ", ")
+	}
+
+	line := "  " + lipgloss.NewStyle().Foreground(tl.theme.Peach).Render(Truncate(text, tl.width-2))
+	padded := PadRight(line, tl.width)
+	if atCursor {
+		padded = lipgloss.NewStyle().Background(tl.theme.Surface0).Render(padded)
+	}
+	return padded
+}
+
 // renderFilterBar renders the bottom filter/status line.
 func (tl *TimelineList) renderFilterBar() string {
 	matchCount, totalCount := tl.FilterCounts()
@@ -2102,6 +2519,11 @@ type ComparePanel struct {
 	leftVP        viewport.Model
 	rightVP       viewport.Model
 	focusLeft     bool
+
+	// split lays both revisions out side by side in splitVP, with rows
+	// aligned and scrolling together, instead of the inline diff.
+	split   bool
+	splitVP viewport.Model
 }
 
 func NewComparePanel(theme Theme) *ComparePanel {
@@ -2109,6 +2531,7 @@ func NewComparePanel(theme Theme) *ComparePanel {
 		theme:   theme,
 		leftVP:  viewport.New(0, 0),
 		rightVP: viewport.New(0, 0),
+		splitVP: viewport.New(0, 0),
 	}
 }
 
@@ -2120,9 +2543,22 @@ func (cp *ComparePanel) SetSize(w, h int) {
 	cp.leftVP.Height = h - 2
 	cp.rightVP.Width = halfW
 	cp.rightVP.Height = h - 2
+	cp.splitVP.Width = 2*halfW + 1
+	cp.splitVP.Height = h - 2
 	cp.renderContent()
 }
 
+// Split reports whether the panel shows the side-by-side layout.
+func (cp *ComparePanel) Split() bool {
+	return cp.split
+}
+
+// splitActive reports whether the side-by-side layout is on screen; it
+// needs both revisions.
+func (cp *ComparePanel) splitActive() bool {
+	return cp.split && cp.left != nil && cp.right != nil
+}
+
 func (cp *ComparePanel) SetItems(left, right *resource.CompareItem) {
 	cp.left = left
 	cp.right = right
@@ -2131,6 +2567,9 @@ func (cp *ComparePanel) SetItems(left, right *resource.CompareItem) {
 
 // CanScrollUp returns true if the active viewport has content above.
 func (cp *ComparePanel) CanScrollUp() bool {
+	if cp.splitActive() {
+		return !cp.splitVP.AtTop()
+	}
 	if cp.focusLeft {
 		return !cp.leftVP.AtTop()
 	}
@@ -2139,6 +2578,9 @@ func (cp *ComparePanel) CanScrollUp() bool {
 
 // CanScrollDown returns true if the active viewport has content below.
 func (cp *ComparePanel) CanScrollDown() bool {
+	if cp.splitActive() {
+		return !cp.splitVP.AtBottom()
+	}
 	if cp.focusLeft {
 		return !cp.leftVP.AtBottom()
 	}
@@ -2159,6 +2601,12 @@ func (cp *ComparePanel) renderContent() {
 		// Show the diff on both sides: left = old, right = new (diff shows both)
 		cp.leftVP.SetContent(RenderYAMLObject(cp.left.Revision.Object, cp.theme, 2))
 		cp.rightVP.SetContent(diffRendered)
+		cp.splitVP.SetContent(diffpreview.RenderSideBySide(node, dpTheme, diffpreview.SideBySideOptions{
+			Width:                     cp.splitVP.Width,
+			IndentSize:                2,
+			EnableBackgroundHighlight: true,
+			Separator:                 lipgloss.NewStyle().Foreground(cp.theme.Surface1).Render("│"),
+		}))
 	} else {
 		if cp.left != nil {
 			cp.leftVP.SetContent(RenderYAMLObject(cp.left.Revision.Object, cp.theme, 2))
@@ -2181,9 +2629,22 @@ func (cp *ComparePanel) Update(msg tea.Msg) tea.Cmd {
 		switch msg.String() {
 		case "tab":
 			cp.focusLeft = !cp.focusLeft
+		case "v":
+			cp.split = !cp.split
 		case "X":
 			return Cmd(CompareClearMsg{})
+		case "E":
+			return cp.patch(PatchFormatJSON, false)
+		case "Y":
+			return cp.patch(PatchFormatJSON, true)
+		case "M":
+			return cp.patch(PatchFormatMerge, true)
 		default:
+			if cp.splitActive() {
+				var cmd tea.Cmd
+				cp.splitVP, cmd = cp.splitVP.Update(msg)
+				return cmd
+			}
 			if cp.focusLeft {
 				var cmd tea.Cmd
 				cp.leftVP, cmd = cp.leftVP.Update(msg)
@@ -2197,6 +2658,14 @@ func (cp *ComparePanel) Update(msg tea.Msg) tea.Cmd {
 	return nil
 }
 
+// patch requests the patch that turns the left revision into the right one.
+func (cp *ComparePanel) patch(format PatchFormat, clipboard bool) tea.Cmd {
+	if cp.left == nil || cp.right == nil {
+		return Cmd(StatusMsg{Text: "Mark two revisions with 'c' first", IsError: true})
+	}
+	return Cmd(ExportPatchMsg{From: *cp.left, To: *cp.right, Format: format, Clipboard: clipboard})
+}
+
 func (cp *ComparePanel) View() string {
 	halfW := max(cp.width/2-1, 5)
 
@@ -2219,6 +2688,10 @@ func (cp *ComparePanel) View() string {
 
 	sep := sepStyle.Render(strings.Repeat("─", cp.width))
 
+	if cp.splitActive() {
+		return header + "\n" + sep + "\n" + cp.splitVP.View()
+	}
+
 	leftContent := cp.leftVP.View()
 	rightContent := cp.rightVP.View()
 
@@ -127,6 +127,7 @@ func (h *HelpOverlay) View() string {
 			{"type...", "Preview: non-matches dimmed"},
 			{"Enter", "Apply: hide non-matches"},
 			{"Esc", "Clear filter and exit"},
+			{"cluster:…", "Only resources of a cluster (e.g. cluster:prod web)"},
 		}},
 		{Title: "Lists (Resources / Revisions / Timeline)", Bindings: []helpBinding{
 			{"j / k", "Move down / up"},
@@ -146,10 +147,14 @@ func (h *HelpOverlay) View() string {
 			{"R", "Reverse sort direction"},
 			{"w", "Cycle time window around selected"},
 			{"≈", "Order inferred (events <1s apart; sorted by resourceVersion)"},
+			{"/path:…", "Filter by changed field path (e.g. path:containers[name=app])"},
 		}},
 		{Title: "Compare View", Bindings: []helpBinding{
 			{"Tab", "Switch left / right pane"},
+			{"v", "Toggle inline / side-by-side layout"},
 			{"X", "Clear compare selection"},
+			{"E", "Export JSON Patch (left → right) to file"},
+			{"Y / M", "Copy JSON Patch / merge patch (left → right)"},
 			{"j / k", "Scroll diff up / down"},
 			{"ctrl+d / pgdn", "Page down in diff"},
 			{"ctrl+u / pgup", "Page up in diff"},
@@ -158,11 +163,17 @@ func (h *HelpOverlay) View() string {
 			{"d", "Diff mode (YAML with highlighting)"},
 			{"o", "Object mode (full YAML)"},
 			{"p", "Changes mode (only fields changed vs previous)"},
+			{"x", "Context mode (diff with unchanged fields folded)"},
+			{"z / Z", "Expand the folded block in view / fold all again"},
 			{"J", "JSON mode"},
 			{"r", "Raw mode (database record)"},
 			{"[ / ]", "Previous / next revision"},
 			{"e", "Export YAML to file"},
 			{"y", "Copy YAML to clipboard"},
+			{"+ / -", "Diff across more / fewer revisions"},
+			{"n", "Hide / show normalized edits (1000m → 1, 60s → 1m)"},
+			{"E", "Export JSON Patch from base revision to file"},
+			{"Y / M", "Copy JSON Patch / merge patch from base revision"},
 			{"t", "Jump to timeline"},
 		}},
 		{Title: "Symbols", Bindings: []helpBinding{
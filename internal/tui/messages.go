@@ -46,7 +46,11 @@ type ShowDevConsoleMsg struct{}
 // Watch management messages
 
 type AddWatchKindMsg struct{ Kind resource.Kind }
-type RemoveWatchKindMsg struct{ Kind string }
+type RemoveWatchKindMsg struct {
+	Kind resource.Kind
+	// KeepData keeps the recorded resources of the kind in the TUI.
+	KeepData bool
+}
 
 // Action messages
 
@@ -67,6 +71,22 @@ type CopyToClipboardMsg struct {
 	RevIndex int
 }
 
+// PatchFormat selects the format of an exported patch.
+type PatchFormat int
+
+const (
+	PatchFormatJSON  PatchFormat = iota // RFC 6902, kubectl patch --type json
+	PatchFormatMerge                    // RFC 7386, kubectl patch --type merge
+)
+
+// ExportPatchMsg requests the change from one revision to another as a patch,
+// written to a file or copied to the system clipboard.
+type ExportPatchMsg struct {
+	From, To  resource.CompareItem
+	Format    PatchFormat
+	Clipboard bool
+}
+
 // Analysis and simulation messages
 
 type AnalysisCompleteMsg struct {
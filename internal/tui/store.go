@@ -34,12 +34,23 @@ type Store interface {
 	// Timeline returns all timeline entries (newest first).
 	Timeline() []resource.TimelineEntry
 
+	// Annotations returns the annotations of all resources, or only of
+	// starred ones, oldest first.
+	Annotations(starredOnly bool) []resource.TimelineAnnotation
+
+	// Gaps returns the spans in which recording was paused, oldest first.
+	Gaps() []resource.Gap
+
 	// KindGroups returns the current kind groups for tree display.
 	KindGroups() []*resource.KindGroup
 
 	// WatchedKinds returns a sorted list of kind names currently being watched.
 	WatchedKinds() []string
 
+	// WatchedKind returns the resource type of a watched kind name. Only
+	// Kind is set when the store doesn't know its API resource.
+	WatchedKind(kind string) resource.Kind
+
 	// ResourceCountByKind returns the number of tracked resources of a given kind.
 	ResourceCountByKind(kind string) int
 
@@ -52,8 +63,9 @@ type Store interface {
 	// AddWatchKind starts watching a resource type, returning any newly created resources.
 	AddWatchKind(rk resource.Kind) []*resource.Data
 
-	// RemoveWatchKind removes all resources of a given kind from active watching.
-	RemoveWatchKind(kind string)
+	// RemoveWatchKind stops watching a resource type and, unless keepData is
+	// set, removes all its resources.
+	RemoveWatchKind(rk resource.Kind, keepData bool)
 
 	// ToggleStar toggles the starred flag on a resource, returning whether it is now starred.
 	ToggleStar(uid string) bool
@@ -74,7 +86,7 @@ type Store interface {
 type Simulator interface {
 	// ScheduleNextTick returns a tea.Cmd that, after a delay, sends a
 	// SimulationTickMsg (tagged with epoch) for a random resource.
-	// Returns nil if no resources exist.
+	// Returns nil if no watched resources exist.
 	ScheduleNextTick(epoch uint64) tea.Cmd
 
 	// GenerateRevision creates a new simulated revision for the given resource.
@@ -38,11 +38,12 @@ func AllViews() []ViewID {
 type ViewMode int
 
 const (
-	DiffMode   ViewMode = iota // Full object YAML with diff highlighting
-	ObjectMode                 // Full YAML, no diff annotations
-	PatchMode                  // Only the fields that changed vs the previous revision
-	JSONMode                   // Raw JSON
-	RawMode                    // Raw database representation (debug)
+	DiffMode    ViewMode = iota // Full object YAML with diff highlighting
+	ObjectMode                  // Full YAML, no diff annotations
+	PatchMode                   // Only the fields that changed vs the previous revision
+	ContextMode                 // Diff with unchanged fields folded away from the changes
+	JSONMode                    // Raw JSON
+	RawMode                     // Raw database representation (debug)
 )
 
 func (m ViewMode) String() string {
@@ -53,6 +54,8 @@ func (m ViewMode) String() string {
 		return "Object"
 	case PatchMode:
 		return "Changes"
+	case ContextMode:
+		return "Context"
 	case JSONMode:
 		return "JSON"
 	case RawMode:
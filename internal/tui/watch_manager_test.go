@@ -27,9 +27,12 @@ func TestWatchManager_UnwatchStaysOpen(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected RemoveWatchKindMsg, got %T", msg)
 	}
-	if rm.Kind == "" {
+	if rm.Kind.Kind == "" {
 		t.Error("RemoveWatchKindMsg has empty kind")
 	}
+	if rm.KeepData {
+		t.Error("Enter should remove the kind's data")
+	}
 	if !wm.IsVisible() {
 		t.Error("watch manager should stay open after unwatch")
 	}
@@ -104,3 +107,20 @@ func TestWatchManager_RefreshPreservesTabAndFilter(t *testing.T) {
 		t.Errorf("Refresh did not update available kinds: %v", wm.addNames)
 	}
 }
+
+// TestWatchManager_UnwatchKeepData verifies ctrl+k unwatches a type but
+// keeps its recorded resources.
+func TestWatchManager_UnwatchKeepData(t *testing.T) {
+	wm := NewWatchManager(CatppuccinMocha)
+	wm.SetSize(120, 40)
+	wm.Show(newDataStore(), nil)
+
+	msg := runCmd(wm.updateWatching(tea.KeyMsg{Type: tea.KeyCtrlK}))
+	rm, ok := msg.(RemoveWatchKindMsg)
+	if !ok {
+		t.Fatalf("expected RemoveWatchKindMsg, got %T", msg)
+	}
+	if !rm.KeepData || rm.Kind.Kind == "" {
+		t.Errorf("got %+v, want a kind with KeepData", rm)
+	}
+}
@@ -13,6 +13,9 @@ import (
 type EventEntryEnv struct {
 	Event  watch.Event
 	Object *unstructured.Unstructured
+	// ClusterName is the kubeconfig context the object comes from when several
+	// clusters are recorded, else empty.
+	ClusterName string
 }
 
 func (e EventEntryEnv) All() bool {
@@ -34,6 +37,19 @@ func (e EventEntryEnv) Namespace(vals ...string) bool {
 	return e.Namespaces(vals...)
 }
 
+// Clusters matches objects of the given kubeconfig contexts. It always
+// matches when a single cluster is recorded.
+func (e EventEntryEnv) Clusters(vals ...string) bool {
+	if e.ClusterName == "" || len(vals) == 0 {
+		return true
+	}
+	return slices.Contains(vals, e.ClusterName)
+}
+
+func (e EventEntryEnv) Cluster(vals ...string) bool {
+	return e.Clusters(vals...)
+}
+
 func (e EventEntryEnv) Names(vals ...string) bool {
 	if e.Object == nil || len(vals) == 0 {
 		return true
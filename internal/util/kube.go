@@ -2,11 +2,114 @@ package util
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// ResourceArg is a resource argument of the loog command: a GVR, optionally
+// followed by options for its watch as a query string, e.g.
+// "v1/pods?labelSelector=app=api",
+// "apps/v1/deployments?fieldSelector=metadata.name=x&labelSelector=tier" or
+// "v1/secrets?metadataOnly=true". Like kubectl, "TYPE/NAME" (e.g.
+// "deploy/api") stands for the object of that name only.
+type ResourceArg struct {
+	GVR           schema.GroupVersionResource
+	LabelSelector string
+	FieldSelector string
+	// MetadataOnly records only the objects' metadata, not their bodies.
+	MetadataOnly bool
+}
+
+// IsResourcePattern reports whether the resource name of an argument needs
+// discovery to resolve: "all", a wildcard such as "apps/*", or a short
+// name, resource or kind without a version such as "deploy" or
+// "Deployment.apps".
+func IsResourcePattern(name string) bool {
+	return name == "all" || strings.Contains(name, "*") || !strings.Contains(name, "/")
+}
+
+// versionPattern matches API versions such as "v1" or "v2beta1".
+var versionPattern = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]+)?$`)
+
+// SplitObjectName splits a "TYPE/NAME" resource name, such as
+// "deploy/api", into the resource type and the object name; ok is false for
+// names such as "v1/pods" or "apps/*". A group and resource such as
+// "apps/deployments" splits too: only discovery tells whether TYPE is a
+// resource.
+func SplitObjectName(name string) (typ, objName string, ok bool) {
+	typ, objName, found := strings.Cut(name, "/")
+	if !found || typ == "" || objName == "" || strings.Contains(objName, "/") ||
+		strings.Contains(name, "*") || versionPattern.MatchString(typ) {
+		return "", "", false
+	}
+	return typ, objName, true
+}
+
+// SelectName restricts the argument to the object named [name], like
+// "TYPE/NAME" does.
+func (ra *ResourceArg) SelectName(name string) error {
+	sel := "metadata.name=" + name
+	if ra.FieldSelector != "" {
+		sel += "," + ra.FieldSelector
+	}
+	if _, err := fields.ParseSelector(sel); err != nil {
+		return fmt.Errorf("invalid object name %q: %w", name, err)
+	}
+	ra.FieldSelector = sel
+	return nil
+}
+
+// ParseResourceSelectors splits a resource argument into its resource name
+// and its validated options. The GVR of the returned ResourceArg is left
+// empty.
+func ParseResourceSelectors(arg string) (string, ResourceArg, error) {
+	name, query, hasQuery := strings.Cut(arg, "?")
+	var ra ResourceArg
+	if hasQuery {
+		if err := parseResourceOptions(query, &ra); err != nil {
+			return "", ResourceArg{}, err
+		}
+	}
+	return name, ra, nil
+}
+
+func parseResourceOptions(query string, ra *ResourceArg) error {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("invalid selectors %q: %w", query, err)
+	}
+	for key, vals := range values {
+		if len(vals) != 1 {
+			return fmt.Errorf("%s given %d times", key, len(vals))
+		}
+		switch key {
+		case "labelSelector":
+			if _, err := labels.Parse(vals[0]); err != nil {
+				return fmt.Errorf("invalid labelSelector: %w", err)
+			}
+			ra.LabelSelector = vals[0]
+		case "fieldSelector":
+			if _, err := fields.ParseSelector(vals[0]); err != nil {
+				return fmt.Errorf("invalid fieldSelector: %w", err)
+			}
+			ra.FieldSelector = vals[0]
+		case "metadataOnly":
+			if ra.MetadataOnly, err = strconv.ParseBool(vals[0]); err != nil {
+				return fmt.Errorf("invalid metadataOnly: %w", err)
+			}
+		default:
+			return fmt.Errorf("unknown option %q (want labelSelector, fieldSelector or metadataOnly)", key)
+		}
+	}
+	return nil
+}
+
 func ParseGroupVersionResource(gv string) (schema.GroupVersionResource, error) {
 	parts := strings.Split(gv, "/")
 	if len(parts) == 2 {
@@ -2,40 +2,68 @@ package diffmap
 
 // Apply mutates [dst] so that, after the call, [dst] equals
 // the map that originally produced the given change-set `chg`.
+// The change-set must be in [CurrentFormat], as returned by [Diff].
 //
 //	dst := map[string]any{"a": 1, "b": map[string]any{"c": false}}
 //	chg := map[string]any{"b": map[string]any{"c": true}}
 //	diffmap.Apply(dst, chg) // dst is now {"a":1,"b":{"c":true}}
 func Apply(dst, chg DiffMap) {
+	ApplyFormat(dst, chg, CurrentFormat)
+}
+
+// ApplyFormat is like [Apply] for a change-set written in format [f], such as
+// a patch read back from an older capture.
+func ApplyFormat(dst, chg DiffMap, f Format) {
 	if dst == nil || chg == nil || len(chg) == 0 {
 		return
 	}
-	applyRecursive(dst, chg)
+	applyRecursive(dst, chg, f)
 }
 
 // applyRecursive recursively applies the change-set to the destination map.
-func applyRecursive(dst, chg DiffMap) {
+func applyRecursive(dst, chg DiffMap, f Format) {
 	for keyChange, valueChange := range chg {
-		switch value := valueChange.(type) {
-		case nil: // deletion
+		if IsDeleted(valueChange, f) {
 			delete(dst, keyChange)
+			continue
+		}
 
-		case DiffMap: // nested change-set
+		switch value := valueChange.(type) {
+		case nil: // explicit null
+			dst[keyChange] = nil
+
+		case DiffMap: // nested change-set or list patch
 			if value == nil {
-				delete(dst, keyChange)
+				dst[keyChange] = nil
 				continue
 			}
-
-			subDst, ok := dst[keyChange].(DiffMap)
-			if !ok {
-				// Either key absent or not a map -> allocate once
-				subDst = make(DiffMap, len(value))
-				dst[keyChange] = subDst
-			}
-			applyRecursive(subDst, value)
+			dst[keyChange] = applyValue(dst[keyChange], value, f)
 
 		default: // scalar add / replace
 			dst[keyChange] = value
 		}
 	}
 }
+
+// applyValue returns [old] with the change-set value [chg] applied: list
+// patches rebuild the list, seq directives apply their steps, nested
+// change-sets are applied to [old] in place and anything else replaces [old].
+func applyValue(old, chg any, f Format) any {
+	value, ok := chg.(DiffMap)
+	if !ok {
+		return chg
+	}
+	if isListPatch(value) {
+		return applyList(old, value, f)
+	}
+	if isSeq(value) {
+		return applySeq(old, value, f)
+	}
+	subDst, ok := old.(DiffMap)
+	if !ok {
+		// Either key absent or not a map -> allocate once
+		subDst = make(DiffMap, len(value))
+	}
+	applyRecursive(subDst, value, f)
+	return subDst
+}
@@ -7,22 +7,33 @@ import (
 	"github.com/loog-project/loog/pkg/diffmap"
 )
 
-// deepCopy returns an independent deep copy of m so that nested maps are not shared.
+// deepCopy returns an independent deep copy of m so that nested maps and lists
+// are not shared.
 func deepCopy(m map[string]any) map[string]any {
 	if m == nil {
 		return nil
 	}
 	out := make(map[string]any, len(m))
 	for k, v := range m {
-		if sub, ok := v.(map[string]any); ok {
-			out[k] = deepCopy(sub)
-		} else {
-			out[k] = v
-		}
+		out[k] = deepCopyValue(v)
 	}
 	return out
 }
 
+func deepCopyValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		return deepCopy(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = deepCopyValue(item)
+		}
+		return out
+	}
+	return v
+}
+
 func TestApplyRoundTrip(t *testing.T) {
 	a := map[string]any{"a": 1, "b": map[string]any{"c": false}}
 	b := map[string]any{"a": 1, "b": map[string]any{"c": true}}
@@ -59,7 +70,7 @@ func TestApply_AddNestedMap(t *testing.T) {
 
 func TestApply_DeleteKey(t *testing.T) {
 	dst := map[string]any{"a": 1, "b": 2}
-	chg := map[string]any{"b": nil}
+	chg := map[string]any{"b": diffmap.Deleted()}
 
 	diffmap.Apply(dst, chg)
 
@@ -69,6 +80,30 @@ func TestApply_DeleteKey(t *testing.T) {
 	}
 }
 
+func TestApply_ExplicitNull(t *testing.T) {
+	dst := map[string]any{"a": 1, "b": 2}
+	chg := map[string]any{"b": nil}
+
+	diffmap.Apply(dst, chg)
+
+	want := map[string]any{"a": 1, "b": nil}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("got %v, want %v", dst, want)
+	}
+}
+
+func TestApplyFormat_LegacyNilDeletes(t *testing.T) {
+	dst := map[string]any{"a": 1, "b": 2, "c": map[string]any{"d": 3}}
+	chg := map[string]any{"b": nil, "c": map[string]any{"d": nil}}
+
+	diffmap.ApplyFormat(dst, chg, diffmap.FormatLegacy)
+
+	want := map[string]any{"a": 1, "c": map[string]any{}}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("got %v, want %v", dst, want)
+	}
+}
+
 func BenchmarkApply_Small(b *testing.B) {
 	a := map[string]any{"a": 1, "b": map[string]any{"c": false}}
 	bb := map[string]any{"a": 1, "b": map[string]any{"c": true}}
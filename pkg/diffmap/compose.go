@@ -0,0 +1,218 @@
+package diffmap
+
+import "strconv"
+
+// opSeq is a value directive that applies its "steps" in order. Compose falls
+// back to it when two value changes cannot be merged without knowing the base,
+// e.g. a keyed list patch followed by a positional one.
+const (
+	opSeq         = "seq"
+	seqStepsField = "steps"
+)
+
+// Compose returns one change-set equivalent to applying [p1] and then [p2]:
+// Apply(Apply(x, p1), p2) equals Apply(x, Compose(p1, p2)) for the state x
+// that [p1] was computed against. Both must be in [CurrentFormat] (see
+// [Upgrade]). Neither input is modified, but the result may share values with
+// them. It returns nil if both are empty.
+func Compose(p1, p2 DiffMap) DiffMap {
+	if len(p1) == 0 {
+		return p2
+	}
+	if len(p2) == 0 {
+		return p1
+	}
+	out := make(DiffMap, len(p1)+len(p2))
+	for k, v1 := range p1 {
+		if v2, ok := p2[k]; ok {
+			out[k] = composeValue(v1, v2)
+		} else {
+			out[k] = v1
+		}
+	}
+	for k, v2 := range p2 {
+		if _, ok := p1[k]; !ok {
+			out[k] = v2
+		}
+	}
+	return out
+}
+
+// composeValue merges two consecutive change-set values for the same key.
+func composeValue(v1, v2 any) any {
+	if IsDeleted(v2, CurrentFormat) {
+		return v2
+	}
+	m2, ok := v2.(DiffMap)
+	if !ok || m2 == nil {
+		return v2 // replacement wins
+	}
+
+	m1, isMap1 := v1.(DiffMap)
+	if !isMap1 {
+		// v1 set a full scalar or list value. A list patch on top of it can
+		// be evaluated right away; the result replaces whatever was there.
+		if isListPatch(m2) {
+			return applyList(cloneValue(v1), m2, CurrentFormat)
+		}
+		return seq(v1, v2)
+	}
+
+	switch {
+	case isChangeSet(m1) && isChangeSet(m2):
+		return Compose(m1, m2)
+	case isListPatch(m1) && isListPatch(m2):
+		if lp := composeList(m1, m2); lp != nil {
+			return lp
+		}
+	}
+	return seq(v1, v2)
+}
+
+// composeList merges two list patches of the same shape. It returns nil for
+// a keyed and a positional patch, or patches keyed on different fields.
+func composeList(lp1, lp2 DiffMap) DiffMap {
+	key1, keyed1 := lp1[listKeyField].(string)
+	key2, keyed2 := lp2[listKeyField].(string)
+	if keyed1 != keyed2 || key1 != key2 {
+		return nil
+	}
+	patch1, _ := lp1[listPatchField].(DiffMap)
+	add1, _ := lp1[listAddField].(DiffMap)
+	patch2, _ := lp2[listPatchField].(DiffMap)
+	add2, _ := lp2[listAddField].(DiffMap)
+
+	patch := make(DiffMap)
+	add := make(DiffMap)
+	merge := func(id string) {
+		if v, ok := add2[id]; ok {
+			add[id] = v
+			return
+		}
+		c2, has2 := patch2[id]
+		if v, ok := add1[id]; ok {
+			if has2 {
+				v = applyValue(cloneValue(v), c2, CurrentFormat)
+			}
+			add[id] = v
+			return
+		}
+		c1, has1 := patch1[id]
+		switch {
+		case has1 && has2:
+			patch[id] = composeValue(c1, c2)
+		case has1:
+			patch[id] = c1
+		case has2:
+			patch[id] = c2
+		}
+	}
+
+	kept := make(map[string]struct{})
+	out := DiffMap{DirectiveKey: opList}
+	if keyed2 {
+		order, _ := lp2[listOrderField].([]any)
+		for _, o := range order {
+			id, _ := o.(string)
+			merge(id)
+			kept[id] = struct{}{}
+		}
+		out[listKeyField] = key2
+		out[listOrderField] = order
+	} else {
+		n, _ := asInt(lp2[listLenField])
+		for i := range n {
+			idx := strconv.Itoa(i)
+			merge(idx)
+			kept[idx] = struct{}{}
+		}
+		out[listLenField] = n
+	}
+
+	// Removed from the base: whatever p1 removed, plus what p2 removed that
+	// p1 hadn't just added, minus anything present again at the end.
+	var remove []any
+	seen := make(map[string]struct{})
+	collect := func(ids any, skip DiffMap) {
+		list, _ := ids.([]any)
+		for _, o := range list {
+			id, _ := o.(string)
+			if _, added := skip[id]; added || hasKey(kept, id) || hasKey(seen, id) {
+				continue
+			}
+			seen[id] = struct{}{}
+			remove = append(remove, id)
+		}
+	}
+	collect(lp1[listRemoveField], nil)
+	collect(lp2[listRemoveField], add1)
+
+	setListFields(out, patch, add, remove)
+	return out
+}
+
+// seq returns a directive applying v1 and then v2, flattening nested ones.
+func seq(v1, v2 any) DiffMap {
+	var steps []any
+	for _, v := range []any{v1, v2} {
+		if m, ok := v.(DiffMap); ok && isSeq(m) {
+			inner, _ := m[seqStepsField].([]any)
+			steps = append(steps, inner...)
+		} else {
+			steps = append(steps, v)
+		}
+	}
+	return DiffMap{DirectiveKey: opSeq, seqStepsField: steps}
+}
+
+func isSeq(m DiffMap) bool {
+	op, _ := m[DirectiveKey].(string)
+	return op == opSeq
+}
+
+// isChangeSet reports whether m is a plain nested change-set rather than a
+// directive.
+func isChangeSet(m DiffMap) bool {
+	_, directive := m[DirectiveKey]
+	return !directive
+}
+
+// applySeq applies the steps of a seq directive to old in order.
+func applySeq(old any, s DiffMap, f Format) any {
+	steps, _ := s[seqStepsField].([]any)
+	cur := old
+	for _, step := range steps {
+		if IsDeleted(step, f) {
+			cur = nil
+			continue
+		}
+		cur = applyValue(cur, step, f)
+	}
+	return cur
+}
+
+// Upgrade returns [chg], written in format [f], converted to [CurrentFormat]
+// so it can be passed to [Compose] or [Invert]. Change-sets already in the
+// current format are returned as is; otherwise nested change-sets are copied
+// and leaf values shared.
+func Upgrade(chg DiffMap, f Format) DiffMap {
+	if f == CurrentFormat || chg == nil {
+		return chg
+	}
+	out := make(DiffMap, len(chg))
+	for k, v := range chg {
+		switch val := v.(type) {
+		case nil:
+			out[k] = Deleted()
+		case DiffMap:
+			if val == nil {
+				out[k] = Deleted()
+			} else {
+				out[k] = Upgrade(val, f)
+			}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
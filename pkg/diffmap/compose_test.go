@@ -0,0 +1,54 @@
+package diffmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func TestComposeExamples(t *testing.T) {
+	p1 := map[string]any{"a": 2, "b": map[string]any{"c": "x"}, "d": diffmap.Deleted()}
+	p2 := map[string]any{"a": 3, "b": map[string]any{"e": true}, "f": "new"}
+
+	got := diffmap.Compose(p1, p2)
+	want := diffmap.DiffMap{
+		"a": 3,
+		"b": diffmap.DiffMap{"c": "x", "e": true},
+		"d": diffmap.Deleted(),
+		"f": "new",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got := diffmap.Compose(nil, p2); !reflect.DeepEqual(got, diffmap.DiffMap(p2)) {
+		t.Fatalf("Compose(nil, p2) = %v, want p2", got)
+	}
+}
+
+func TestCompose_DeleteThenRecreateMap(t *testing.T) {
+	// The recreated map must replace the old one, not merge into it.
+	base := map[string]any{"m": map[string]any{"old": 1}}
+	mid := map[string]any{}
+	last := map[string]any{"m": map[string]any{"new": 2}}
+
+	chg := diffmap.Compose(diffmap.Diff(base, mid), diffmap.Diff(mid, last))
+	diffmap.Apply(base, chg)
+	if !reflect.DeepEqual(base, last) {
+		t.Fatalf("got %v, want %v", base, last)
+	}
+}
+
+func TestUpgrade_LegacyNilBecomesDeleted(t *testing.T) {
+	legacy := map[string]any{"a": nil, "b": map[string]any{"c": nil, "d": 1}}
+
+	got := diffmap.Upgrade(legacy, diffmap.FormatLegacy)
+	want := diffmap.DiffMap{"a": diffmap.Deleted(), "b": diffmap.DiffMap{"c": diffmap.Deleted(), "d": 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if legacy["a"] != nil {
+		t.Fatal("Upgrade must not modify its input")
+	}
+}
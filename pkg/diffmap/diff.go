@@ -1,8 +1,16 @@
 // Package diffmap computes the change-set that would turn map [a] into map [b].
 //
 // A change-set is itself a [map[string]any] that contains only the keys that
-// differ. Added keys get their new value, removed keys get a nil value, and
-// modified nested-maps are expressed recursively.
+// differ. Added keys get their new value, removed keys get the [Deleted]
+// marker, and modified nested-maps are expressed recursively. A nil value is
+// an explicit null. Change-sets written in [FormatLegacy] used nil for
+// removal instead; apply those with [ApplyFormat].
+//
+// Lists of objects are expressed as list patches (see [DirectiveKey]): items
+// are matched by a merge key such as "name" or "type" when every item has a
+// unique one, and by position otherwise, so changing one container image does
+// not store the whole containers list again. Scalar lists are stored whole.
+// Change-sets written before list patches existed still apply unchanged.
 package diffmap
 
 import "reflect"
@@ -31,7 +39,7 @@ func diffRecursive(a, b DiffMap, out DiffMap) {
 	for keyA, valueA := range a {
 		valueBFromKeyA, hasAInB := b[keyA]
 		if !hasAInB {
-			out[keyA] = nil
+			out[keyA] = Deleted()
 			continue
 		}
 
@@ -40,17 +48,9 @@ func diffRecursive(a, b DiffMap, out DiffMap) {
 		}
 
 		// Both present but not equal.
-		if valueAAsMap, okA := valueA.(DiffMap); okA {
-			if valueBFromKeyAAsMap, okB := valueBFromKeyA.(DiffMap); okB {
-				sub := make(DiffMap)
-				diffRecursive(valueAAsMap, valueBFromKeyAAsMap, sub)
-				if len(sub) != 0 {
-					out[keyA] = sub
-				}
-				continue
-			}
+		if v, changed := diffValue(valueA, valueBFromKeyA); changed {
+			out[keyA] = v
 		}
-		out[keyA] = valueBFromKeyA
 	}
 	for k, vb := range b {
 		if _, already := a[k]; !already {
@@ -59,6 +59,28 @@ func diffRecursive(a, b DiffMap, out DiffMap) {
 	}
 }
 
+// diffValue returns the change-set value that turns [a] into [b], which are
+// known to differ: a nested change-set for two maps, a list patch for two
+// lists of objects, and [b] itself otherwise. changed is false only when two
+// maps turn out to have no difference.
+func diffValue(a, b any) (v any, changed bool) {
+	switch va := a.(type) {
+	case DiffMap:
+		if vb, ok := b.(DiffMap); ok {
+			sub := make(DiffMap)
+			diffRecursive(va, vb, sub)
+			return sub, len(sub) != 0
+		}
+	case []any:
+		if vb, ok := b.([]any); ok {
+			if lp := diffList(va, vb); lp != nil {
+				return lp, true
+			}
+		}
+	}
+	return b, true
+}
+
 // equalFast is a tight equality test that avoids reflection for common types.
 func equalFast(a, b any) bool {
 	switch va := a.(type) {
@@ -25,12 +25,17 @@ func TestDiffExamples(t *testing.T) {
 		{
 			map[string]any{"a": 1, "b": map[string]any{"c": false}},
 			map[string]any{"a": 1, "b": map[string]any{"e": true}},
-			map[string]any{"b": map[string]any{"c": nil, "e": true}},
+			map[string]any{"b": map[string]any{"c": diffmap.Deleted(), "e": true}},
 		},
 		{
 			map[string]any{"a": 1, "b": map[string]any{"c": false}},
 			map[string]any{"b": map[string]any{"c": false}},
-			map[string]any{"a": nil},
+			map[string]any{"a": diffmap.Deleted()},
+		},
+		{
+			map[string]any{"a": 1, "b": "x"},
+			map[string]any{"a": 1, "b": nil},
+			map[string]any{"b": nil},
 		},
 	}
 	for i, tc := range cases {
@@ -90,7 +95,7 @@ func TestDiff_NilInputs(t *testing.T) {
 
 	// someMap, nil -> removes everything from someMap
 	d = diffmap.Diff(someMap, nil)
-	want = map[string]any{"a": nil}
+	want = map[string]any{"a": diffmap.Deleted()}
 	if !reflect.DeepEqual(d, want) {
 		t.Fatalf("Diff(someMap, nil) = %v, want %v", d, want)
 	}
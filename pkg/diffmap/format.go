@@ -0,0 +1,41 @@
+package diffmap
+
+// Format identifies how a change-set encodes deletions. Change-sets are
+// persisted, so the format they were written in must be stored next to them
+// and handed back to [ApplyFormat].
+type Format uint8
+
+const (
+	// FormatLegacy is the original encoding: a nil value deletes the key, so a
+	// field that changed to an explicit null could not be expressed.
+	FormatLegacy Format = 0
+	// FormatV1 deletes keys with the [Deleted] marker and stores explicit
+	// nulls as nil.
+	FormatV1 Format = 1
+
+	// CurrentFormat is the format produced by [Diff] and assumed by [Apply].
+	CurrentFormat = FormatV1
+)
+
+const opDelete = "delete"
+
+// Deleted returns the marker value that removes a key in [FormatV1]
+// change-sets: {"$diffmap": "delete"}.
+func Deleted() DiffMap {
+	return DiffMap{DirectiveKey: opDelete}
+}
+
+// IsDeleted reports whether a change-set value removes its key in format [f].
+func IsDeleted(v any, f Format) bool {
+	switch val := v.(type) {
+	case nil:
+		return f == FormatLegacy
+	case DiffMap:
+		if val == nil {
+			return f == FormatLegacy
+		}
+		op, _ := val[DirectiveKey].(string)
+		return op == opDelete
+	}
+	return false
+}
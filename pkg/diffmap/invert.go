@@ -0,0 +1,92 @@
+package diffmap
+
+// Invert returns the change-set that undoes [chg]: applying it to the result
+// of Apply(base, chg) yields [base] again. [base] is the state [chg] was
+// computed against and is not modified. The result is in [CurrentFormat] and
+// may share values with [base]; it is nil if [chg] changes nothing.
+//
+//	base := map[string]any{"a": 1, "b": "x"}
+//	chg := map[string]any{"a": 2, "b": diffmap.Deleted()}
+//	diffmap.Invert(base, chg) // {"a": 1, "b": "x"}
+func Invert(base, chg DiffMap) DiffMap {
+	return InvertFormat(base, chg, CurrentFormat)
+}
+
+// InvertFormat is like [Invert] for a change-set written in format [f].
+func InvertFormat(base, chg DiffMap, f Format) DiffMap {
+	if len(chg) == 0 {
+		return nil
+	}
+	inv := make(DiffMap, len(chg))
+	invertRecursive(base, chg, inv, f)
+	if len(inv) == 0 {
+		return nil
+	}
+	return inv
+}
+
+// Unapply mutates [dst], a state produced by applying some change-set, back
+// to the state before it. [inv] is the inverse [Invert] returned for that
+// change-set.
+func Unapply(dst, inv DiffMap) {
+	ApplyFormat(dst, inv, CurrentFormat)
+}
+
+func invertRecursive(base, chg, out DiffMap, f Format) {
+	for key, value := range chg {
+		old, had := base[key]
+		if IsDeleted(value, f) {
+			if had {
+				out[key] = old
+			}
+			continue
+		}
+		if !had {
+			out[key] = Deleted()
+			continue
+		}
+
+		if sub, ok := value.(DiffMap); ok && sub != nil {
+			if !isChangeSet(sub) {
+				// Directives are evaluated rather than inverted step by step:
+				// the new list is needed anyway to express reordering back.
+				newValue := applyValue(cloneValue(old), sub, f)
+				if v, changed := diffValue(newValue, old); changed {
+					out[key] = v
+				}
+				continue
+			}
+			if oldMap, ok := old.(DiffMap); ok {
+				nested := make(DiffMap, len(sub))
+				invertRecursive(oldMap, sub, nested, f)
+				if len(nested) != 0 {
+					out[key] = nested
+				}
+				continue
+			}
+		}
+
+		// Replaced value: put the old one back.
+		out[key] = old
+	}
+}
+
+// cloneValue deep-copies maps and lists so applying to the copy leaves the
+// original untouched.
+func cloneValue(v any) any {
+	switch val := v.(type) {
+	case DiffMap:
+		out := make(DiffMap, len(val))
+		for k, sub := range val {
+			out[k] = cloneValue(sub)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, sub := range val {
+			out[i] = cloneValue(sub)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package diffmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func TestInvertExamples(t *testing.T) {
+	base := map[string]any{"a": 1, "b": "x", "c": map[string]any{"d": nil, "e": true}}
+	chg := map[string]any{
+		"a": 2,
+		"b": diffmap.Deleted(),
+		"c": map[string]any{"d": "set", "e": diffmap.Deleted()},
+		"n": "new",
+	}
+
+	want := map[string]any{
+		"a": 1,
+		"b": "x",
+		"c": map[string]any{"d": nil, "e": true},
+		"n": diffmap.Deleted(),
+	}
+	if got := diffmap.Invert(base, chg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestInvertFormat_Legacy(t *testing.T) {
+	base := map[string]any{"a": 1, "b": 2}
+	chg := map[string]any{"b": nil} // legacy: delete b
+
+	inv := diffmap.InvertFormat(base, chg, diffmap.FormatLegacy)
+
+	dst := map[string]any{"a": 1}
+	diffmap.Unapply(dst, inv)
+	if !reflect.DeepEqual(dst, base) {
+		t.Fatalf("got %v, want %v", dst, base)
+	}
+}
+
+func TestInvert_NoChange(t *testing.T) {
+	if inv := diffmap.Invert(map[string]any{"a": 1}, nil); inv != nil {
+		t.Fatalf("Invert of empty change-set = %v, want nil", inv)
+	}
+}
@@ -0,0 +1,138 @@
+package diffmap
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operations emitted by [JSONPatch].
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+)
+
+// JSONPatchOp is one operation of an RFC 6902 JSON Patch.
+type JSONPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON omits "value" for remove operations; add and replace always
+// carry it, even when it is null.
+func (o JSONPatchOp) MarshalJSON() ([]byte, error) {
+	if o.Op == JSONPatchRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	type op JSONPatchOp // drop the method to avoid recursion
+	return json.Marshal(op(o))
+}
+
+// JSONPatch returns the RFC 6902 JSON Patch that turns object [a] into object
+// [b], suitable for `kubectl patch --type json`. Swap the arguments to get
+// the patch that reverts the change. Lists are patched by index; operations
+// are ordered so that removals from a list run from the end. The result is
+// empty if the objects are equal.
+func JSONPatch(a, b DiffMap) []JSONPatchOp {
+	ops := []JSONPatchOp{}
+	jsonPatchMap(a, b, "", &ops)
+	return ops
+}
+
+func jsonPatchMap(a, b DiffMap, path string, ops *[]JSONPatchOp) {
+	for _, k := range sortedKeys(a) {
+		if _, ok := b[k]; !ok {
+			*ops = append(*ops, JSONPatchOp{Op: JSONPatchRemove, Path: path + "/" + escapePointer(k)})
+		}
+	}
+	for _, k := range sortedKeys(b) {
+		p := path + "/" + escapePointer(k)
+		va, had := a[k]
+		if !had {
+			*ops = append(*ops, JSONPatchOp{Op: JSONPatchAdd, Path: p, Value: b[k]})
+			continue
+		}
+		jsonPatchValue(va, b[k], p, ops)
+	}
+}
+
+func jsonPatchValue(a, b any, path string, ops *[]JSONPatchOp) {
+	if equalFast(a, b) {
+		return
+	}
+	switch va := a.(type) {
+	case DiffMap:
+		if vb, ok := b.(DiffMap); ok {
+			jsonPatchMap(va, vb, path, ops)
+			return
+		}
+	case []any:
+		if vb, ok := b.([]any); ok {
+			n := min(len(va), len(vb))
+			for i := range n {
+				jsonPatchValue(va[i], vb[i], path+"/"+strconv.Itoa(i), ops)
+			}
+			for i := len(va) - 1; i >= n; i-- {
+				*ops = append(*ops, JSONPatchOp{Op: JSONPatchRemove, Path: path + "/" + strconv.Itoa(i)})
+			}
+			for i := n; i < len(vb); i++ {
+				*ops = append(*ops, JSONPatchOp{Op: JSONPatchAdd, Path: path + "/" + strconv.Itoa(i), Value: vb[i]})
+			}
+			return
+		}
+	}
+	*ops = append(*ops, JSONPatchOp{Op: JSONPatchReplace, Path: path, Value: b})
+}
+
+// MergePatch returns the RFC 7386 JSON Merge Patch that turns object [a] into
+// object [b], suitable for `kubectl patch --type merge`. Swap the arguments to
+// get the patch that reverts the change. The format cannot tell a removed key
+// from one set to null, and it replaces lists whole; a field that changes to
+// an explicit null is removed when the patch is applied. The result is an
+// empty map if the objects are equal.
+func MergePatch(a, b DiffMap) DiffMap {
+	out := make(DiffMap)
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			out[k] = nil
+		}
+	}
+	for k, vb := range b {
+		va, had := a[k]
+		if had && equalFast(va, vb) {
+			continue
+		}
+		if ma, ok := va.(DiffMap); ok && had {
+			if mb, ok := vb.(DiffMap); ok {
+				out[k] = MergePatch(ma, mb)
+				continue
+			}
+		}
+		out[k] = vb
+	}
+	return out
+}
+
+// escapePointer escapes a key for use as an RFC 6901 JSON Pointer token, so
+// keys like "app.kubernetes.io/name" stay one token.
+func escapePointer(k string) string {
+	if !strings.ContainsAny(k, "~/") {
+		return k
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m DiffMap) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,130 @@
+package diffmap_test
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func TestJSONPatchExamples(t *testing.T) {
+	a := map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]any{"app.kubernetes.io/name": "web", "tier": "fe"},
+		},
+		"spec": map[string]any{"replicas": 1.0, "args": []any{"a", "b", "c"}},
+	}
+	b := map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]any{"app.kubernetes.io/name": "api"},
+		},
+		"spec": map[string]any{"replicas": 2.0, "args": []any{"a"}, "paused": nil},
+	}
+
+	got, err := json.Marshal(diffmap.JSONPatch(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[` +
+		`{"op":"remove","path":"/metadata/labels/tier"},` +
+		`{"op":"replace","path":"/metadata/labels/app.kubernetes.io~1name","value":"api"},` +
+		`{"op":"remove","path":"/spec/args/2"},` +
+		`{"op":"remove","path":"/spec/args/1"},` +
+		`{"op":"add","path":"/spec/paused","value":null},` +
+		`{"op":"replace","path":"/spec/replicas","value":2}]`
+	if string(got) != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	if ops := diffmap.JSONPatch(a, a); len(ops) != 0 {
+		t.Fatalf("equal objects should give no ops, got %v", ops)
+	}
+}
+
+func TestMergePatchExamples(t *testing.T) {
+	a := map[string]any{"a": 1.0, "b": map[string]any{"c": "x", "d": "y"}, "l": []any{"p"}}
+	b := map[string]any{"a": 1.0, "b": map[string]any{"c": "z"}, "l": []any{"p", "q"}}
+
+	got := diffmap.MergePatch(a, b)
+	want := diffmap.DiffMap{"b": diffmap.DiffMap{"c": "z", "d": nil}, "l": []any{"p", "q"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := diffmap.MergePatch(a, a); len(got) != 0 {
+		t.Fatalf("equal objects should give an empty patch, got %v", got)
+	}
+}
+
+// TestPatches_ApplyWithReferenceImplementation applies the generated patches
+// with the library kubectl uses and checks both directions.
+func TestPatches_ApplyWithReferenceImplementation(t *testing.T) {
+	r := rand.New(rand.NewPCG(9, 10))
+	for i := range 1000 {
+		a := randObject(r, 3)
+		b := mutate(r, a, 3).(map[string]any)
+		docA, _ := json.Marshal(a)
+		docB, _ := json.Marshal(b)
+
+		for name, pair := range map[string][2][]byte{"forward": {docA, docB}, "revert": {docB, docA}} {
+			from, to := decodeDoc(t, pair[0]), decodeDoc(t, pair[1])
+
+			raw, _ := json.Marshal(diffmap.JSONPatch(from, to))
+			patch, err := jsonpatch.DecodePatch(raw)
+			if err != nil {
+				t.Fatalf("iteration %d %s: decode %s: %v", i, name, raw, err)
+			}
+			out, err := patch.Apply(pair[0])
+			if err != nil {
+				t.Fatalf("iteration %d %s: apply %s: %v", i, name, raw, err)
+			}
+			if got := decodeDoc(t, out); !reflect.DeepEqual(got, to) {
+				t.Fatalf("iteration %d %s: JSON Patch result\ngot  %v\nwant %v\npatch %s", i, name, got, to, raw)
+			}
+
+			if hasNull(to) {
+				continue // merge patches cannot express explicit nulls
+			}
+			raw, _ = json.Marshal(diffmap.MergePatch(from, to))
+			out, err = jsonpatch.MergePatch(pair[0], raw)
+			if err != nil {
+				t.Fatalf("iteration %d %s: merge %s: %v", i, name, raw, err)
+			}
+			if got := decodeDoc(t, out); !reflect.DeepEqual(got, to) {
+				t.Fatalf("iteration %d %s: merge patch result\ngot  %v\nwant %v\npatch %s", i, name, got, to, raw)
+			}
+		}
+	}
+}
+
+func decodeDoc(t *testing.T, doc []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(doc, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func hasNull(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		for _, sub := range val {
+			if hasNull(sub) {
+				return true
+			}
+		}
+	case []any:
+		for _, sub := range val {
+			if hasNull(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package diffmap
+
+import "strconv"
+
+// DirectiveKey marks a change-set value that is an instruction (such as a
+// list patch) rather than a nested change-set. Like the "$patch" key of
+// strategic merge patch, it is reserved: objects that use it as a real field
+// name cannot be diffed faithfully.
+const DirectiveKey = "$diffmap"
+
+// Directive operations and list patch fields.
+const (
+	opList = "list"
+
+	listKeyField    = "key"    // merge key for identity matching; absent when positional
+	listOrderField  = "order"  // keyed: merge-key values of the new list, in order
+	listLenField    = "len"    // positional: length of the new list
+	listPatchField  = "patch"  // element (merge-key value or index) -> change-set
+	listAddField    = "add"    // element (merge-key value or index) -> new element
+	listRemoveField = "remove" // elements of the old list that are gone; informational
+)
+
+// mergeKeys are the element fields, in order of preference, that identify an
+// item of a list of objects (containers and env by "name", conditions by
+// "type", volumeMounts by "mountPath", ...). A key is only used when every
+// element of both lists carries it as a unique string.
+var mergeKeys = []string{"name", "type", "mountPath", "devicePath", "ip", "key"}
+
+// diffList returns a list patch that turns [a] into [b], or nil when the list
+// is better stored whole. Only lists holding objects are patched; scalar
+// lists (finalizers, args, ...) are short and replaced as before.
+//
+// A keyed list patch looks like
+//
+//	{"$diffmap": "list", "key": "name", "order": ["app", "sidecar"],
+//	 "patch": {"app": {"image": "nginx:1.27"}}, "add": {"sidecar": {...}}}
+//
+// and a positional one replaces "key"/"order" with the new length "len" and
+// uses decimal indexes as element names. Elements of [a] that are neither in
+// "order" nor below "len" are removed; "remove" names them so that [Paths] can
+// report removals without the old list, but applying ignores it.
+func diffList(a, b []any) DiffMap {
+	if len(a) == 0 || len(b) == 0 || (!hasMapElement(a) && !hasMapElement(b)) {
+		return nil
+	}
+	if key := listMergeKey(a, b); key != "" {
+		return diffListKeyed(a, b, key)
+	}
+	return diffListPositional(a, b)
+}
+
+func diffListKeyed(a, b []any, key string) DiffMap {
+	byKey := make(map[string]DiffMap, len(a))
+	for _, item := range a {
+		m := item.(DiffMap)
+		byKey[m[key].(string)] = m
+	}
+
+	order := make([]any, len(b))
+	kept := make(map[string]struct{}, len(b))
+	patch := make(DiffMap)
+	add := make(DiffMap)
+	for i, item := range b {
+		m := item.(DiffMap)
+		id := m[key].(string)
+		order[i] = id
+		kept[id] = struct{}{}
+		old, existed := byKey[id]
+		if !existed {
+			add[id] = m
+			continue
+		}
+		sub := make(DiffMap)
+		diffRecursive(old, m, sub)
+		if len(sub) != 0 {
+			patch[id] = sub
+		}
+	}
+
+	var remove []any
+	for _, item := range a {
+		if id := item.(DiffMap)[key].(string); !hasKey(kept, id) {
+			remove = append(remove, id)
+		}
+	}
+
+	out := DiffMap{
+		DirectiveKey:   opList,
+		listKeyField:   key,
+		listOrderField: order,
+	}
+	setListFields(out, patch, add, remove)
+	return out
+}
+
+func diffListPositional(a, b []any) DiffMap {
+	patch := make(DiffMap)
+	add := make(DiffMap)
+	for i, vb := range b {
+		idx := strconv.Itoa(i)
+		if i >= len(a) {
+			add[idx] = vb
+			continue
+		}
+		if equalFast(a[i], vb) {
+			continue
+		}
+		if v, changed := diffValue(a[i], vb); changed {
+			patch[idx] = v
+		}
+	}
+
+	var remove []any
+	for i := len(b); i < len(a); i++ {
+		remove = append(remove, strconv.Itoa(i))
+	}
+
+	out := DiffMap{
+		DirectiveKey: opList,
+		listLenField: len(b),
+	}
+	setListFields(out, patch, add, remove)
+	return out
+}
+
+// setListFields stores the non-empty element fields of a list patch.
+func setListFields(lp, patch, add DiffMap, remove []any) {
+	if len(patch) != 0 {
+		lp[listPatchField] = patch
+	}
+	if len(add) != 0 {
+		lp[listAddField] = add
+	}
+	if len(remove) != 0 {
+		lp[listRemoveField] = remove
+	}
+}
+
+func hasKey(set map[string]struct{}, k string) bool {
+	_, ok := set[k]
+	return ok
+}
+
+// listMergeKey returns the first merge key that every element of both lists
+// carries as a string, uniquely within each list. Returns "" if there is none.
+func listMergeKey(a, b []any) string {
+	for _, key := range mergeKeys {
+		if uniqueStringKey(a, key) && uniqueStringKey(b, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+func uniqueStringKey(list []any, key string) bool {
+	seen := make(map[string]struct{}, len(list))
+	for _, item := range list {
+		m, ok := item.(DiffMap)
+		if !ok {
+			return false
+		}
+		id, ok := m[key].(string)
+		if !ok {
+			return false
+		}
+		if _, dup := seen[id]; dup {
+			return false
+		}
+		seen[id] = struct{}{}
+	}
+	return true
+}
+
+func hasMapElement(list []any) bool {
+	for _, item := range list {
+		if _, ok := item.(DiffMap); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isListPatch reports whether a change-set value is a list patch directive.
+func isListPatch(m DiffMap) bool {
+	op, _ := m[DirectiveKey].(string)
+	return op == opList
+}
+
+// applyList returns the list that results from applying the list patch [lp]
+// to [old]. Elements are patched in place; the returned slice is new. A
+// non-list [old] is treated as an empty list.
+func applyList(old any, lp DiffMap, f Format) []any {
+	src, _ := old.([]any)
+	patch, _ := lp[listPatchField].(DiffMap)
+	add, _ := lp[listAddField].(DiffMap)
+
+	if key, ok := lp[listKeyField].(string); ok {
+		byKey := make(map[string]any, len(src))
+		for _, item := range src {
+			if m, ok := item.(DiffMap); ok {
+				if id, ok := m[key].(string); ok {
+					byKey[id] = m
+				}
+			}
+		}
+		order, _ := lp[listOrderField].([]any)
+		out := make([]any, 0, len(order))
+		for _, o := range order {
+			id, _ := o.(string)
+			if v, ok := add[id]; ok {
+				out = append(out, v)
+				continue
+			}
+			item, ok := byKey[id]
+			if !ok {
+				// The base doesn't hold this element; nothing to patch.
+				continue
+			}
+			if chg, ok := patch[id]; ok {
+				item = applyValue(item, chg, f)
+			}
+			out = append(out, item)
+		}
+		return out
+	}
+
+	n, _ := asInt(lp[listLenField])
+	out := make([]any, 0, n)
+	for i := range n {
+		idx := strconv.Itoa(i)
+		if v, ok := add[idx]; ok {
+			out = append(out, v)
+			continue
+		}
+		var item any
+		if i < len(src) {
+			item = src[i]
+		}
+		if chg, ok := patch[idx]; ok {
+			item = applyValue(item, chg, f)
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// asInt converts any integer or float representation to an int. Change-sets
+// that went through msgpack or JSON come back with sized ints or float64.
+func asInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,161 @@
+package diffmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func podSpec(containers ...map[string]any) map[string]any {
+	list := make([]any, len(containers))
+	for i, c := range containers {
+		list[i] = c
+	}
+	return map[string]any{"spec": map[string]any{"containers": list}}
+}
+
+func container(name, image string) map[string]any {
+	return map[string]any{
+		"name":  name,
+		"image": image,
+		"ports": []any{map[string]any{"containerPort": 8080, "protocol": "TCP"}},
+	}
+}
+
+// roundTrip diffs a into b, applies the change-set to a copy of a and
+// returns both the change-set and the result.
+func roundTrip(t *testing.T, a, b map[string]any) (diffmap.DiffMap, map[string]any) {
+	t.Helper()
+	chg := diffmap.Diff(a, b)
+	got := deepCopy(a)
+	diffmap.Apply(got, chg)
+	if !reflect.DeepEqual(got, b) {
+		t.Fatalf("Apply(a, Diff(a, b)) != b\nchg:  %v\ngot:  %v\nwant: %v", chg, got, b)
+	}
+	return chg, got
+}
+
+func TestDiffList_KeyedOnlyStoresChangedItem(t *testing.T) {
+	a := podSpec(container("app", "nginx:1.26"), container("sidecar", "envoy:1"))
+	b := podSpec(container("app", "nginx:1.27"), container("sidecar", "envoy:1"))
+
+	chg, _ := roundTrip(t, a, b)
+
+	lp := chg["spec"].(diffmap.DiffMap)["containers"].(diffmap.DiffMap)
+	if lp[diffmap.DirectiveKey] != "list" || lp["key"] != "name" {
+		t.Fatalf("want keyed list patch, got %v", lp)
+	}
+	want := diffmap.DiffMap{"app": diffmap.DiffMap{"image": "nginx:1.27"}}
+	if !reflect.DeepEqual(lp["patch"], want) {
+		t.Fatalf("patch = %v, want %v", lp["patch"], want)
+	}
+	if _, ok := lp["add"]; ok {
+		t.Fatalf("unchanged items must not be stored: %v", lp["add"])
+	}
+}
+
+func TestDiffList_KeyedAddRemoveReorder(t *testing.T) {
+	a := podSpec(container("a", "x:1"), container("b", "y:1"), container("c", "z:1"))
+	b := podSpec(container("c", "z:2"), container("d", "w:1"), container("a", "x:1"))
+
+	chg, _ := roundTrip(t, a, b)
+
+	lp := chg["spec"].(diffmap.DiffMap)["containers"].(diffmap.DiffMap)
+	if got, want := lp["order"], []any{"c", "d", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	if _, ok := lp["add"].(diffmap.DiffMap)["d"]; !ok {
+		t.Fatalf("new item d should be stored whole: %v", lp)
+	}
+	if got, want := lp["remove"], []any{"b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("remove = %v, want %v", got, want)
+	}
+}
+
+func TestDiffList_PositionalWithoutMergeKey(t *testing.T) {
+	a := map[string]any{"rules": []any{
+		map[string]any{"verbs": []any{"get"}},
+		map[string]any{"verbs": []any{"list"}},
+	}}
+	b := map[string]any{"rules": []any{
+		map[string]any{"verbs": []any{"get", "watch"}},
+		map[string]any{"verbs": []any{"list"}},
+		map[string]any{"verbs": []any{"create"}},
+	}}
+
+	chg, _ := roundTrip(t, a, b)
+
+	lp := chg["rules"].(diffmap.DiffMap)
+	if _, keyed := lp["key"]; keyed {
+		t.Fatalf("items without a merge key must be matched by position: %v", lp)
+	}
+	if _, ok := lp["patch"].(diffmap.DiffMap)["1"]; ok {
+		t.Fatalf("unchanged index must not be stored: %v", lp)
+	}
+
+	// Shrinking drops the trailing items.
+	roundTrip(t, b, a)
+}
+
+func TestDiffList_DuplicateKeysFallBackToPosition(t *testing.T) {
+	a := map[string]any{"tolerations": []any{
+		map[string]any{"key": "gpu", "effect": "NoSchedule"},
+		map[string]any{"key": "gpu", "effect": "NoExecute"},
+	}}
+	b := map[string]any{"tolerations": []any{
+		map[string]any{"key": "gpu", "effect": "NoSchedule"},
+		map[string]any{"key": "gpu", "effect": "PreferNoSchedule"},
+	}}
+
+	chg, _ := roundTrip(t, a, b)
+	if _, keyed := chg["tolerations"].(diffmap.DiffMap)["key"]; keyed {
+		t.Fatalf("duplicate merge keys must not be used: %v", chg)
+	}
+}
+
+func TestDiffList_ScalarListsStoredWhole(t *testing.T) {
+	a := map[string]any{"finalizers": []any{"a", "b"}}
+	b := map[string]any{"finalizers": []any{"a", "c"}}
+
+	chg, _ := roundTrip(t, a, b)
+	if !reflect.DeepEqual(chg, diffmap.DiffMap{"finalizers": []any{"a", "c"}}) {
+		t.Fatalf("scalar list should be replaced whole, got %v", chg)
+	}
+}
+
+func TestApply_LegacyWholeListPatch(t *testing.T) {
+	// Change-sets recorded before list patches stored lists of objects whole.
+	dst := podSpec(container("app", "nginx:1.26"))
+	legacy := map[string]any{"spec": map[string]any{
+		"containers": []any{container("app", "nginx:1.27"), container("sidecar", "envoy:1")},
+	}}
+
+	diffmap.Apply(dst, legacy)
+
+	want := podSpec(container("app", "nginx:1.27"), container("sidecar", "envoy:1"))
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("legacy patch: got %v, want %v", dst, want)
+	}
+}
+
+func TestApply_ListPatchSurvivesMsgpack(t *testing.T) {
+	a := map[string]any{"rules": []any{map[string]any{"n": "1"}, map[string]any{"n": "2"}}}
+	b := map[string]any{"rules": []any{map[string]any{"n": "1"}}}
+
+	raw, err := msgpack.Marshal(diffmap.Diff(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chg map[string]any
+	if err := msgpack.Unmarshal(raw, &chg); err != nil {
+		t.Fatal(err)
+	}
+
+	diffmap.Apply(a, chg)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("after msgpack round-trip: got %v, want %v", a, b)
+	}
+}
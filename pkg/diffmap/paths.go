@@ -0,0 +1,101 @@
+package diffmap
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Paths returns the field paths a change-set touches, sorted and without
+// duplicates. A path names the deepest field that was set, replaced or
+// deleted, in dotted form; items of a list patch are addressed by merge key
+// when the list was diffed by one, and by index otherwise:
+//
+//	spec.replicas
+//	spec.template.spec.containers[name=app].image
+//	status.conditions[type=Ready].status
+//	metadata.labels["app.kubernetes.io/name"]
+//
+// Keys that are empty or contain '.', '[', ']' or '"' are quoted. A list that
+// was reordered without any item changing is reported by its own path.
+// [chg] must be in [CurrentFormat] (see [Upgrade]).
+func Paths(chg DiffMap) []string {
+	if len(chg) == 0 {
+		return nil
+	}
+	var out []string
+	changeSetPaths(chg, "", &out)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+func changeSetPaths(chg DiffMap, prefix string, out *[]string) {
+	for k, v := range chg {
+		valuePaths(v, joinPath(prefix, k), out)
+	}
+}
+
+func valuePaths(v any, path string, out *[]string) {
+	m, ok := v.(DiffMap)
+	if !ok || len(m) == 0 || IsDeleted(m, CurrentFormat) {
+		*out = append(*out, path)
+		return
+	}
+	switch {
+	case isListPatch(m):
+		listPaths(m, path, out)
+	case isSeq(m):
+		steps, _ := m[seqStepsField].([]any)
+		for _, step := range steps {
+			valuePaths(step, path, out)
+		}
+	default:
+		changeSetPaths(m, path, out)
+	}
+}
+
+func listPaths(lp DiffMap, path string, out *[]string) {
+	key, _ := lp[listKeyField].(string)
+	item := func(id string) string {
+		if key == "" {
+			return path + "[" + id + "]"
+		}
+		return path + "[" + key + "=" + quoteListID(id) + "]"
+	}
+
+	before := len(*out)
+	patch, _ := lp[listPatchField].(DiffMap)
+	for id, chg := range patch {
+		valuePaths(chg, item(id), out)
+	}
+	add, _ := lp[listAddField].(DiffMap)
+	for id := range add {
+		*out = append(*out, item(id))
+	}
+	remove, _ := lp[listRemoveField].([]any)
+	for _, o := range remove {
+		id, _ := o.(string)
+		*out = append(*out, item(id))
+	}
+	if len(*out) == before {
+		*out = append(*out, path)
+	}
+}
+
+// joinPath appends the field [key] to [prefix].
+func joinPath(prefix, key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"`) {
+		return prefix + "[" + strconv.Quote(key) + "]"
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func quoteListID(id string) string {
+	if id == "" || strings.ContainsAny(id, `[]"=`) {
+		return strconv.Quote(id)
+	}
+	return id
+}
@@ -0,0 +1,102 @@
+package diffmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+func TestPaths(t *testing.T) {
+	deploy := func(replicas int, image string, labels map[string]any, containers ...map[string]any) map[string]any {
+		list := make([]any, len(containers))
+		for i, c := range containers {
+			list[i] = c
+		}
+		return map[string]any{
+			"metadata": map[string]any{"labels": labels},
+			"spec": map[string]any{
+				"replicas": replicas,
+				"template": map[string]any{"spec": map[string]any{"containers": list}},
+			},
+			"status": map[string]any{"image": image},
+		}
+	}
+
+	cases := []struct {
+		name string
+		a, b map[string]any
+		want []string
+	}{
+		{
+			name: "equal",
+			a:    deploy(1, "x", nil, container("app", "nginx:1")),
+			b:    deploy(1, "x", nil, container("app", "nginx:1")),
+			want: nil,
+		},
+		{
+			name: "scalar and keyed list item",
+			a:    deploy(1, "x", nil, container("app", "nginx:1"), container("sidecar", "envoy:1")),
+			b:    deploy(3, "x", nil, container("app", "nginx:2"), container("sidecar", "envoy:1")),
+			want: []string{
+				"spec.replicas",
+				"spec.template.spec.containers[name=app].image",
+			},
+		},
+		{
+			name: "added and removed items",
+			a:    deploy(1, "x", nil, container("app", "nginx:1"), container("old", "busybox")),
+			b:    deploy(1, "x", nil, container("app", "nginx:1"), container("new", "busybox")),
+			want: []string{
+				"spec.template.spec.containers[name=new]",
+				"spec.template.spec.containers[name=old]",
+			},
+		},
+		{
+			name: "reorder only",
+			a:    deploy(1, "x", nil, container("a", "x"), container("b", "y")),
+			b:    deploy(1, "x", nil, container("b", "y"), container("a", "x")),
+			want: []string{"spec.template.spec.containers"},
+		},
+		{
+			name: "quoted and deleted keys",
+			a:    deploy(1, "x", map[string]any{"app.kubernetes.io/name": "web", "tier": "fe"}),
+			b:    deploy(1, "x", map[string]any{"app.kubernetes.io/name": "api"}),
+			want: []string{
+				"metadata.labels.tier",
+				`metadata.labels["app.kubernetes.io/name"]`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := diffmap.Paths(diffmap.Diff(tc.a, tc.b))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Paths = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPaths_PositionalAndComposed(t *testing.T) {
+	a := map[string]any{"rules": []any{
+		map[string]any{"verbs": []any{"get"}},
+		map[string]any{"verbs": []any{"list"}},
+		map[string]any{"verbs": []any{"watch"}},
+	}}
+	b := map[string]any{"rules": []any{
+		map[string]any{"verbs": []any{"get"}},
+		map[string]any{"verbs": []any{"create"}},
+	}}
+	c := map[string]any{"rules": []any{
+		map[string]any{"verbs": []any{"get", "list"}},
+	}}
+
+	if got, want := diffmap.Paths(diffmap.Diff(a, b)), []string{"rules[1].verbs", "rules[2]"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Paths(a->b) = %q, want %q", got, want)
+	}
+	composed := diffmap.Compose(diffmap.Diff(a, b), diffmap.Diff(b, c))
+	if got, want := diffmap.Paths(composed), []string{"rules[0].verbs", "rules[1]", "rules[2]"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Paths(a->c) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,216 @@
+package diffmap_test
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/loog-project/loog/pkg/diffmap"
+)
+
+// randObject builds a random object of the shapes Kubernetes uses: nested
+// maps, explicit nulls, scalar lists and lists of objects with and without a
+// merge key. Scalars are string, float64, bool or nil so values survive a
+// msgpack round-trip unchanged.
+func randObject(r *rand.Rand, depth int) map[string]any {
+	m := make(map[string]any)
+	for i := range r.IntN(6) {
+		m["k"+strconv.Itoa(i)] = randValue(r, depth)
+	}
+	return m
+}
+
+func randValue(r *rand.Rand, depth int) any {
+	n := 6
+	if depth > 0 {
+		n = 9
+	}
+	switch r.IntN(n) {
+	case 0:
+		return nil
+	case 1:
+		return "s" + strconv.Itoa(r.IntN(4))
+	case 2:
+		return float64(r.IntN(4))
+	case 3:
+		return r.IntN(2) == 0
+	case 4:
+		return []any{"a", "b" + strconv.Itoa(r.IntN(3))}
+	case 5:
+		return []any{}
+	case 6:
+		return randObject(r, depth-1)
+	case 7:
+		return randNamedList(r, depth-1)
+	default:
+		list := make([]any, r.IntN(4))
+		for i := range list {
+			list[i] = randObject(r, depth-1)
+		}
+		return list
+	}
+}
+
+func randNamedList(r *rand.Rand, depth int) []any {
+	names := r.Perm(5)[:r.IntN(5)]
+	list := make([]any, len(names))
+	for i, n := range names {
+		item := randObject(r, depth)
+		item["name"] = "c" + strconv.Itoa(n)
+		list[i] = item
+	}
+	return list
+}
+
+// mutate returns a changed deep copy of v.
+func mutate(r *rand.Rand, v any, depth int) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := deepCopy(val)
+		for k := range out {
+			switch r.IntN(6) {
+			case 0:
+				delete(out, k)
+			case 1:
+				out[k] = nil
+			case 2:
+				out[k] = randValue(r, depth)
+			case 3:
+				out[k] = mutate(r, out[k], depth)
+			}
+		}
+		if r.IntN(3) == 0 {
+			out["n"+strconv.Itoa(r.IntN(3))] = randValue(r, depth)
+		}
+		return out
+	case []any:
+		out := deepCopyValue(val).([]any)
+		r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+		for i := range out {
+			if r.IntN(2) == 0 {
+				out[i] = mutate(r, out[i], depth)
+			}
+		}
+		if len(out) > 0 && r.IntN(3) == 0 {
+			out = out[:len(out)-1]
+		}
+		if r.IntN(3) == 0 {
+			out = append(out, randValue(r, depth))
+		}
+		return out
+	}
+	if r.IntN(2) == 0 {
+		return nil
+	}
+	return randValue(r, depth)
+}
+
+func TestProperty_ApplyDiffRoundTrips(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range 5000 {
+		a := randObject(r, 3)
+		b := mutate(r, a, 3).(map[string]any)
+		aCopy := deepCopy(a)
+
+		chg := diffmap.Diff(a, b)
+		if !reflect.DeepEqual(a, aCopy) {
+			t.Fatalf("iteration %d: Diff mutated its input", i)
+		}
+
+		got := deepCopy(a)
+		diffmap.Apply(got, chg)
+		if !reflect.DeepEqual(got, b) {
+			t.Fatalf("iteration %d: Apply(a, Diff(a, b)) != b\na:   %v\nb:   %v\nchg: %v\ngot: %v",
+				i, a, b, chg, got)
+		}
+	}
+}
+
+func TestProperty_RoundTripsThroughMsgpack(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for i := range 2000 {
+		a := randObject(r, 3)
+		b := mutate(r, a, 3).(map[string]any)
+
+		raw, err := msgpack.Marshal(diffmap.Diff(a, b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var chg map[string]any
+		if err := msgpack.Unmarshal(raw, &chg); err != nil {
+			t.Fatal(err)
+		}
+
+		got := deepCopy(a)
+		diffmap.Apply(got, chg)
+		if !reflect.DeepEqual(got, b) {
+			t.Fatalf("iteration %d: decoded change-set does not round-trip\na:   %v\nb:   %v\ngot: %v",
+				i, a, b, got)
+		}
+	}
+}
+
+func TestProperty_EqualObjectsHaveNoDiff(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	for i := range 1000 {
+		a := randObject(r, 3)
+		if chg := diffmap.Diff(a, deepCopy(a)); chg != nil {
+			t.Fatalf("iteration %d: Diff(a, a) = %v, want nil", i, chg)
+		}
+	}
+}
+
+func TestProperty_InvertUndoesChange(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+	for i := range 5000 {
+		a := randObject(r, 3)
+		b := mutate(r, a, 3).(map[string]any)
+		aCopy := deepCopy(a)
+
+		chg := diffmap.Diff(a, b)
+		inv := diffmap.Invert(a, chg)
+		if !reflect.DeepEqual(a, aCopy) {
+			t.Fatalf("iteration %d: Invert mutated its base", i)
+		}
+
+		got := deepCopy(a)
+		diffmap.Apply(got, chg)
+		diffmap.Unapply(got, deepCopy(inv))
+		if !reflect.DeepEqual(got, a) {
+			t.Fatalf("iteration %d: Unapply(Apply(a, chg), Invert(a, chg)) != a\na:   %v\nb:   %v\ninv: %v\ngot: %v",
+				i, a, b, inv, got)
+		}
+	}
+}
+
+func TestProperty_ComposeEqualsSequentialApply(t *testing.T) {
+	r := rand.New(rand.NewPCG(11, 12))
+	for i := range 3000 {
+		states := []map[string]any{randObject(r, 3)}
+		for range 1 + r.IntN(4) {
+			states = append(states, mutate(r, states[len(states)-1], 3).(map[string]any))
+		}
+
+		var chg diffmap.DiffMap
+		for j := 1; j < len(states); j++ {
+			chg = diffmap.Compose(chg, diffmap.Diff(states[j-1], states[j]))
+		}
+
+		first, last := states[0], states[len(states)-1]
+		got := deepCopy(first)
+		diffmap.Apply(got, chg)
+		if !reflect.DeepEqual(got, last) {
+			t.Fatalf("iteration %d: Apply(s0, Compose(...)) != s%d\nchg: %v\ngot:  %v\nwant: %v",
+				i, len(states)-1, chg, got, last)
+		}
+
+		diffmap.Unapply(got, diffmap.Invert(first, chg))
+		if !reflect.DeepEqual(got, first) {
+			t.Fatalf("iteration %d: inverting a composed change-set does not restore s0\ngot:  %v\nwant: %v",
+				i, got, first)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package diffmap
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// SemanticEqual reports whether [a] and [b], found at [path] (the field
+// names from the root), are equal once the spellings the API server
+// normalizes are treated alike:
+//
+//   - numbers of different Go types with the same value (1 and 1.0),
+//   - under resource quantity fields (see [isQuantityPath]), quantities with
+//     the same amount ("1000m" and "1", "1Gi" and "1024Mi"), including a
+//     plain number against a quantity string,
+//   - under duration fields (see [durationFields]), durations of the same
+//     length ("1m" and "60s", "1h0m0s" and "1h").
+//
+// Other strings, such as label values, are compared exactly: "1.10" and
+// "1.1" are different versions. Maps and lists are compared element by
+// element. [Diff] itself stays exact, since a change-set must turn [a] into
+// [b] byte for byte; this is for telling a reader that an edit changed
+// nothing but the spelling.
+func SemanticEqual(path []string, a, b any) bool {
+	if equalFast(a, b) {
+		return true
+	}
+	switch va := a.(type) {
+	case DiffMap:
+		vb, ok := b.(DiffMap)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, x := range va {
+			y, ok := vb[k]
+			if !ok || !SemanticEqual(append(path[:len(path):len(path)], k), x, y) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !SemanticEqual(path, va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	quantity := isQuantityPath(path)
+	if x, ok := numberValue(a, quantity); ok {
+		if y, ok := numberValue(b, quantity); ok {
+			return x.Cmp(y) == 0
+		}
+	}
+	sa, aIsString := a.(string)
+	sb, bIsString := b.(string)
+	if aIsString && bIsString && isDurationPath(path) {
+		da, errA := time.ParseDuration(sa)
+		db, errB := time.ParseDuration(sb)
+		if errA == nil && errB == nil && da == db {
+			return true
+		}
+	}
+	return false
+}
+
+// quantityMaps are the maps whose values are resource quantities, e.g.
+// status.capacity of a Node or spec.hard of a ResourceQuota; requests and
+// limits count only under resources.
+var quantityMaps = []string{"capacity", "allocatable", "hard", "used"}
+
+// durationFields are the fields that hold durations such as "1m30s", e.g.
+// the intervals of Flux and Prometheus Operator objects or cert-manager's
+// certificate lifetimes.
+var durationFields = []string{
+	"duration", "evaluationInterval", "interval", "period", "renewBefore",
+	"retryInterval", "scrapeInterval", "scrapeTimeout", "timeout", "ttl",
+}
+
+// isQuantityPath reports whether the value at [path] is a resource
+// quantity: a field of resources.requests, resources.limits or one of
+// [quantityMaps], or a storage size. Nothing under metadata is.
+func isQuantityPath(path []string) bool {
+	if slices.Contains(path, "metadata") || len(path) == 0 {
+		return false
+	}
+	for i, field := range path {
+		if (field == "requests" || field == "limits") && i > 0 && path[i-1] == "resources" ||
+			slices.Contains(quantityMaps, field) {
+			return true
+		}
+	}
+	return path[len(path)-1] == "storage"
+}
+
+// isDurationPath reports whether the value at [path] is one of
+// [durationFields]. Nothing under metadata is.
+func isDurationPath(path []string) bool {
+	return len(path) > 0 && !slices.Contains(path, "metadata") &&
+		slices.Contains(durationFields, path[len(path)-1])
+}
+
+// numberValue returns the amount a number stands for, or with [quantity]
+// also a resource quantity string ("500m", "2Gi", "1e3").
+func numberValue(v any, quantity bool) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case uint64:
+		return new(big.Rat).SetUint64(n), true
+	case float32:
+		r := new(big.Rat).SetFloat64(float64(n)) // nil for NaN and ±Inf
+		return r, r != nil
+	case float64:
+		r := new(big.Rat).SetFloat64(n)
+		return r, r != nil
+	case string:
+		if !quantity || n == "" || strings.TrimSpace(n) != n {
+			return nil, false
+		}
+		q, err := resource.ParseQuantity(n)
+		if err != nil {
+			return nil, false
+		}
+		r, ok := new(big.Rat).SetString(q.AsDec().String())
+		return r, ok
+	}
+	return nil, false
+}
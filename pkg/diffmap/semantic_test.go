@@ -0,0 +1,44 @@
+package diffmap
+
+import "testing"
+
+func TestSemanticEqual(t *testing.T) {
+	cpu := []string{"spec", "containers", "resources", "requests", "cpu"}
+	timeout := []string{"spec", "timeout"}
+	label := []string{"metadata", "labels", "version"}
+	tests := []struct {
+		path []string
+		a, b any
+		want bool
+	}{
+		{cpu, "1000m", "1", true},
+		{[]string{"status", "capacity", "memory"}, "1Gi", "1024Mi", true},
+		{[]string{"spec", "hard", "cpu"}, "0.5", "500m", true},
+		{[]string{"spec", "volumeClaimTemplates", "spec", "resources", "requests", "storage"}, "1Gi", "1024Mi", true},
+		{cpu, float64(2), "2", true},
+		{[]string{"spec", "replicas"}, int64(3), float64(3), true},
+		{timeout, "1m", "60s", true},
+		{timeout, "1h0m0s", "1h", true},
+		{cpu, "1Gi", "1G", false},
+		{cpu, "100m", "1", false},
+		{[]string{"spec", "image"}, "nginx:1", "nginx:1.0", false},
+		{cpu, "1", " 1", false},
+		{cpu, "", "0", false},
+		{cpu, true, "true", false},
+		// Strings elsewhere are compared exactly, even if they would parse.
+		{label, "1.10", "1.1", false},
+		{[]string{"data", "threshold"}, "1e3", "1000", false},
+		{[]string{"spec", "replicas"}, float64(2), "2", false},
+		{[]string{"metadata", "annotations", "timeout"}, "1m", "60s", false},
+		{[]string{"data", "wait"}, "1m", "60s", false},
+		{[]string{"spec", "resources", "requests"}, map[string]any{"cpu": "1000m", "memory": "1Gi"}, map[string]any{"cpu": "1", "memory": "1024Mi"}, true},
+		{[]string{"spec", "resources", "requests"}, map[string]any{"cpu": "1"}, map[string]any{"cpu": "1", "memory": "1Gi"}, false},
+		{[]string{"spec", "interval"}, []any{"1m"}, []any{"60s"}, true},
+		{[]string{"spec", "ports"}, []any{float64(1)}, []any{int64(1)}, true},
+	}
+	for _, tt := range tests {
+		if got := SemanticEqual(tt.path, tt.a, tt.b); got != tt.want {
+			t.Errorf("SemanticEqual(%v, %#v, %#v) = %v, want %v", tt.path, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
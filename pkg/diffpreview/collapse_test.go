@@ -0,0 +1,104 @@
+package diffpreview
+
+import (
+	"strings"
+	"testing"
+)
+
+func collapseFixture() (a, b map[string]any) {
+	spec := func(replicas float64) map[string]any {
+		return map[string]any{
+			"a": "1", "b": "2", "c": "3", "d": "4",
+			"replicas": replicas,
+			"w":        "5", "x": "6", "y": "7",
+			"template": map[string]any{"labels": map[string]any{"app": "web", "tier": "front"}},
+		}
+	}
+	a = map[string]any{
+		"metadata": map[string]any{"name": "web", "namespace": "default"},
+		"spec":     spec(1),
+	}
+	b = map[string]any{
+		"metadata": map[string]any{"name": "web", "namespace": "default"},
+		"spec":     spec(3),
+	}
+	return a, b
+}
+
+// Collapse keeps CollapseContext siblings around a change and folds the rest,
+// including the content of unchanged maps kept as context.
+func TestRender_Collapse(t *testing.T) {
+	a, b := collapseFixture()
+	got, blocks := RenderYAMLBlocks(Diff(a, b), plainTheme, RenderOptions{IndentSize: 2, Collapse: true, CollapseContext: 1})
+
+	if !strings.Contains(got, "  replicas: 3\n") || !strings.Contains(got, "  d: \"4\"\n") {
+		t.Fatalf("missing the change or its context:\n%s", got)
+	}
+	for _, hidden := range []string{`a: "1"`, `c: "3"`, `w: "5"`, "app:", "name:"} {
+		if strings.Contains(got, hidden) {
+			t.Errorf("%q should be folded:\n%s", hidden, got)
+		}
+	}
+	assertContains(t, got, "metadata:\n  … 2 unchanged fields\n")
+	assertContains(t, got, "spec:\n  … 3 unchanged fields\n  d: \"4\"\n")
+	assertContains(t, got, "  template:\n    labels:\n      … 2 unchanged fields\n  … 3 unchanged fields\n")
+
+	wantIDs := []string{"metadata/name", "spec/a", "spec.template.labels/app", "spec/w"}
+	if len(blocks) != len(wantIDs) {
+		t.Fatalf("blocks = %+v, want IDs %v", blocks, wantIDs)
+	}
+	lines := strings.Split(got, "\n")
+	for i, blk := range blocks {
+		if blk.ID != wantIDs[i] {
+			t.Errorf("block %d ID = %q, want %q", i, blk.ID, wantIDs[i])
+		}
+		if !strings.Contains(lines[blk.Line], "unchanged") {
+			t.Errorf("block %q points at line %d %q", blk.ID, blk.Line, lines[blk.Line])
+		}
+	}
+	if blocks[1].Count != 3 {
+		t.Errorf("spec/a count = %d, want 3", blocks[1].Count)
+	}
+}
+
+// Expanding a block shows its fields as context: scalars in full, nested
+// content folded again.
+func TestRender_CollapseExpanded(t *testing.T) {
+	a, b := collapseFixture()
+	opts := RenderOptions{IndentSize: 2, Collapse: true, CollapseContext: 1,
+		Expanded: map[string]bool{"spec/a": true, "spec.template.labels/app": true}}
+	got := RenderYAML(Diff(a, b), plainTheme, opts)
+
+	assertContains(t, got, "spec:\n  a: \"1\"\n  b: \"2\"\n  c: \"3\"\n  d: \"4\"\n")
+	assertContains(t, got, "    labels:\n      app: \"web\"\n      tier: \"front\"\n")
+	assertContains(t, got, "metadata:\n  … 2 unchanged fields\n")
+}
+
+// Unchanged list items fold like fields; a folded map item keeps its "- ".
+func TestRender_CollapseList(t *testing.T) {
+	item := func(name, image string) map[string]any {
+		return map[string]any{"name": name, "image": image, "args": []any{"--x"}}
+	}
+	a := map[string]any{"items": []any{item("a", "1"), item("b", "1"), item("c", "1"), item("d", "1")}}
+	b := map[string]any{"items": []any{item("a", "1"), item("b", "1"), item("c", "1"), item("d", "2")}}
+	got, blocks := RenderYAMLBlocks(Diff(a, b), plainTheme, RenderOptions{IndentSize: 2, Collapse: true, CollapseContext: 1})
+
+	assertContains(t, got, "items:\n  … 2 unchanged items\n  - … 3 unchanged fields\n  - args:\n")
+	assertContains(t, got, "    image: \"2\"\n")
+	if len(blocks) == 0 || blocks[0].ID != "items/[0]" || blocks[1].ID != "items[2]/args" {
+		t.Errorf("blocks = %+v", blocks)
+	}
+}
+
+// Without changes everything folds; ChangesOnly takes precedence.
+func TestRender_CollapseEqualAndChangesOnly(t *testing.T) {
+	m := map[string]any{"a": "1", "b": "2"}
+	got := RenderYAML(Diff(m, m), plainTheme, RenderOptions{IndentSize: 2, Collapse: true})
+	if got != "… 2 unchanged fields\n" {
+		t.Errorf("got %q", got)
+	}
+	got = RenderYAML(Diff(m, m), plainTheme, RenderOptions{IndentSize: 2, Collapse: true, ChangesOnly: true})
+	if strings.TrimSpace(got) != "" {
+		t.Errorf("ChangesOnly should win, got %q", got)
+	}
+}
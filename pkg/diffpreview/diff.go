@@ -1,10 +1,12 @@
 package diffpreview
 
 import (
-	"fmt"
 	"reflect"
 	"slices"
 	"sort"
+	"strings"
+
+	"github.com/loog-project/loog/pkg/diffmap"
 )
 
 type ChangeType int
@@ -22,19 +24,36 @@ const (
 // or a scalar leaf (Value is set). Change records what happened to the node
 // between the old and new versions.
 type AnnotatedNode struct {
-	Value    any
+	Value any
+	// Old is the previous value of a Modified leaf; Value holds the new one.
+	// It may be a map or list when the value changed type.
+	Old      any
 	Change   ChangeType
 	Children map[string]*AnnotatedNode
 	List     []*AnnotatedNode
+	// Lines is the line-by-line diff of a Modified leaf whose old and new
+	// values are strings and at least one spans several lines (ConfigMap
+	// data, scripts, last-applied-configuration), so renderers can highlight
+	// just the lines that changed.
+	Lines []LineDiff
+	// Normalized marks a Modified leaf whose old and new values mean the
+	// same ("1000m" and "1", "60s" and "1m"; see diffmap.SemanticEqual),
+	// the kind of edit the API server makes when it normalizes a field.
+	Normalized bool
 }
 
 // Diff compares two maps and returns a tree where every node is annotated
 // with a ChangeType. Pass the result to RenderYAML to get highlighted output.
+//
+// Lists of objects are matched item by item using the merge keys of the
+// built-in Kubernetes types (see [RegisterMergeKey] for CRDs), falling back
+// to a guess from common identifier fields and finally to positions.
 func Diff(a, b map[string]any) *AnnotatedNode {
-	return diffMaps(a, b)
+	return diffMaps(a, b, nil)
 }
 
-func diffMaps(a, b map[string]any) *AnnotatedNode {
+// diffMaps diffs two maps found at [path], the field names from the root.
+func diffMaps(a, b map[string]any, path []string) *AnnotatedNode {
 	node := &AnnotatedNode{Children: make(map[string]*AnnotatedNode)}
 
 	for _, key := range unionKeys(a, b) {
@@ -47,7 +66,7 @@ func diffMaps(a, b map[string]any) *AnnotatedNode {
 		case !inA && inB:
 			node.Children[key] = buildFullNode(valB, Added)
 		default:
-			node.Children[key] = diffValues(valA, valB)
+			node.Children[key] = diffValues(valA, valB, append(path[:len(path):len(path)], key))
 		}
 	}
 	return node
@@ -55,49 +74,63 @@ func diffMaps(a, b map[string]any) *AnnotatedNode {
 
 // diffValues compares two values of any type and returns the matching node.
 // Maps and lists are diffed recursively; scalars are compared for equality.
-func diffValues(a, b any) *AnnotatedNode {
+func diffValues(a, b any, path []string) *AnnotatedNode {
 	mapA, aIsMap := a.(map[string]any)
 	mapB, bIsMap := b.(map[string]any)
 	if aIsMap && bIsMap {
-		return diffMaps(mapA, mapB)
+		return diffMaps(mapA, mapB, path)
 	}
 
 	listA, aIsList := a.([]any)
 	listB, bIsList := b.([]any)
 	if aIsList && bIsList {
-		return diffLists(listA, listB)
+		return diffLists(listA, listB, path)
 	}
 
 	if reflect.DeepEqual(a, b) {
 		return buildFullNode(a, Unchanged)
 	}
-	return &AnnotatedNode{Value: b, Change: Modified}
+	node := &AnnotatedNode{Value: b, Old: a, Change: Modified, Normalized: diffmap.SemanticEqual(path, a, b)}
+	strA, aIsString := a.(string)
+	strB, bIsString := b.(string)
+	if aIsString && bIsString && (isMultiline(a) || isMultiline(b)) {
+		// A line diff can't show a change of the trailing newline; such
+		// strings are shown replaced whole, where "|" and "|-" tell them
+		// apart.
+		if strings.HasSuffix(strA, "\n") == strings.HasSuffix(strB, "\n") {
+			node.Lines = diffStringLines(strA, strB)
+		}
+	}
+	return node
 }
 
 // diffLists diffs two lists element by element.
-// When both lists contain maps with a shared identifier key (like "name"),
-// elements are matched by that key. Otherwise they're compared by position.
-func diffLists(a, b []any) *AnnotatedNode {
+// Elements are matched by the merge key registered for [path] when it is
+// usable, else by a shared identifier key (like "name") found in both lists.
+// Otherwise they're compared by position.
+func diffLists(a, b []any, path []string) *AnnotatedNode {
 	node := &AnnotatedNode{Change: Unchanged}
 
-	if key := findMatchKey(a, b); key != "" {
-		node.List = diffListByKey(a, b, key)
+	if fields := mergeKeyFor(path); fields != nil && usableMergeKey(a, b, fields) {
+		node.List = diffListByKey(a, b, fields, path)
+	} else if key := findMatchKey(a, b); key != "" {
+		node.List = diffListByKey(a, b, []string{key}, path)
 	} else {
-		node.List = diffListPositional(a, b)
+		node.List = diffListPositional(a, b, path)
 	}
 	return node
 }
 
 // findMatchKey checks a set of common Kubernetes field names and returns the
-// first one that appears in every map element of both lists. Returns "" if
-// no usable key is found.
+// first one that appears, uniquely within each list, in every map element of
+// both lists. Returns "" if no usable key is found.
 func findMatchKey(a, b []any) string {
 	candidates := []string{
 		"name", "type", "containerPort", "port",
 		"host", "key", "path", "kind",
 	}
 	for _, key := range candidates {
-		if allMapsHaveKey(a, key) && allMapsHaveKey(b, key) {
+		if allMapsHaveKey(a, key) && allMapsHaveKey(b, key) && usableMergeKey(a, b, []string{key}) {
 			return key
 		}
 	}
@@ -121,14 +154,14 @@ func allMapsHaveKey(list []any, key string) bool {
 	return found
 }
 
-// diffListByKey pairs elements from a and b by matchKey, then diffs the
-// matched pairs. Elements only in a are marked Removed; elements only in b
-// are marked Added.
-func diffListByKey(a, b []any, matchKey string) []*AnnotatedNode {
+// diffListByKey pairs elements from a and b by the values of the fields
+// [matchKey], then diffs the matched pairs. Elements only in a are marked
+// Removed; elements only in b are marked Added.
+func diffListByKey(a, b []any, matchKey []string, path []string) []*AnnotatedNode {
 	keyOf := func(item any) string {
 		if m, ok := item.(map[string]any); ok {
-			if v, ok := m[matchKey]; ok {
-				return fmt.Sprintf("%v", v)
+			if _, ok := m[matchKey[0]]; ok {
+				return compositeKey(m, matchKey)
 			}
 		}
 		return ""
@@ -157,7 +190,7 @@ func diffListByKey(a, b []any, matchKey string) []*AnnotatedNode {
 			continue
 		}
 		matched[k] = true
-		result = append(result, diffValues(item, bItem))
+		result = append(result, diffValues(item, bItem, path))
 	}
 
 	// Append b elements that had no match in a, in b's original order. This
@@ -172,7 +205,7 @@ func diffListByKey(a, b []any, matchKey string) []*AnnotatedNode {
 	return result
 }
 
-func diffListPositional(a, b []any) []*AnnotatedNode {
+func diffListPositional(a, b []any, path []string) []*AnnotatedNode {
 	n := max(len(b), len(a))
 
 	result := make([]*AnnotatedNode, 0, n)
@@ -183,7 +216,7 @@ func diffListPositional(a, b []any) []*AnnotatedNode {
 		case i >= len(b):
 			result = append(result, buildFullNode(a[i], Removed))
 		default:
-			result = append(result, diffValues(a[i], b[i]))
+			result = append(result, diffValues(a[i], b[i], path))
 		}
 	}
 	return result
@@ -226,6 +259,22 @@ func hasChanges(node *AnnotatedNode) bool {
 	return slices.ContainsFunc(node.List, hasChanges)
 }
 
+// hasSemanticChanges is hasChanges not counting normalized edits.
+func hasSemanticChanges(node *AnnotatedNode) bool {
+	if node.Normalized {
+		return false
+	}
+	if node.Change != Unchanged {
+		return true
+	}
+	for _, child := range node.Children {
+		if hasSemanticChanges(child) {
+			return true
+		}
+	}
+	return slices.ContainsFunc(node.List, hasSemanticChanges)
+}
+
 func unionKeys(a, b map[string]any) []string {
 	seen := make(map[string]struct{}, len(a)+len(b))
 	for k := range a {
@@ -0,0 +1,141 @@
+package diffpreview
+
+import (
+	"slices"
+	"strings"
+)
+
+// LineDiff is one line of a changed multi-line string; see AnnotatedNode.Lines.
+type LineDiff struct {
+	Text   string
+	Change ChangeType // Unchanged, Added or Removed
+}
+
+// maxLineEdits bounds the number of edits diffLines searches for. Strings
+// that differ more than that are shown as removed and added whole, which is
+// what a line diff of them would look like anyway.
+const maxLineEdits = 1000
+
+// isMultiline reports whether v is a string spanning several lines.
+func isMultiline(v any) bool {
+	s, ok := v.(string)
+	return ok && strings.Contains(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// splitLines splits s into lines, dropping one trailing newline the way a
+// YAML "|" block does.
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// blockIndicator returns the YAML block indicator of a multi-line string:
+// "|" when it ends in a newline, else "|-".
+func blockIndicator(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return "|"
+	}
+	return "|-"
+}
+
+// diffStringLines returns the line diff of two strings.
+func diffStringLines(a, b string) []LineDiff {
+	return diffLines(splitLines(a), splitLines(b))
+}
+
+// diffLines returns a shortest line diff of a and b (Myers' O(ND)
+// algorithm). Within each run of changes, removed lines come before added
+// ones.
+func diffLines(a, b []string) []LineDiff {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxLineEdits)
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v[-d-1 .. d+1] as it was before step d.
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // step down: insert b[y]
+			} else {
+				x = v[off+k-1] + 1 // step right: delete a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return groupLineChanges(backtrackLines(a, b, trace))
+			}
+		}
+	}
+
+	out := make([]LineDiff, 0, n+m)
+	for _, l := range a {
+		out = append(out, LineDiff{Text: l, Change: Removed})
+	}
+	for _, l := range b {
+		out = append(out, LineDiff{Text: l, Change: Added})
+	}
+	return out
+}
+
+// backtrackLines walks the edit graph from the end back to the start using
+// the saved frontiers and returns the edits in forward order.
+func backtrackLines(a, b []string, trace [][]int) []LineDiff {
+	var out []LineDiff
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		at := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			out = append(out, LineDiff{Text: a[x], Change: Unchanged})
+		}
+		if x == prevX {
+			y--
+			out = append(out, LineDiff{Text: b[y], Change: Added})
+		} else {
+			x--
+			out = append(out, LineDiff{Text: a[x], Change: Removed})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		out = append(out, LineDiff{Text: a[x], Change: Unchanged})
+	}
+	slices.Reverse(out)
+	return out
+}
+
+// groupLineChanges moves the removed lines of each run of changes before its
+// added lines.
+func groupLineChanges(lines []LineDiff) []LineDiff {
+	out := make([]LineDiff, 0, len(lines))
+	var added []LineDiff
+	for _, l := range lines {
+		switch l.Change {
+		case Removed:
+			out = append(out, l)
+		case Added:
+			added = append(added, l)
+		default:
+			out = append(out, added...)
+			added = added[:0]
+			out = append(out, l)
+		}
+	}
+	return append(out, added...)
+}
@@ -0,0 +1,145 @@
+package diffpreview
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sides rebuilds the old and new lines from a line diff.
+func sides(lines []LineDiff) (a, b []string) {
+	for _, l := range lines {
+		if l.Change != Added {
+			a = append(a, l.Text)
+		}
+		if l.Change != Removed {
+			b = append(b, l.Text)
+		}
+	}
+	return a, b
+}
+
+func countChanged(lines []LineDiff) int {
+	n := 0
+	for _, l := range lines {
+		if l.Change != Unchanged {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"server {", "  listen 80;", "  root /srv;", "}"}
+	b := []string{"server {", "  listen 8080;", "  root /srv;", "  gzip on;", "}"}
+
+	got := diffLines(a, b)
+	want := []LineDiff{
+		{"server {", Unchanged},
+		{"  listen 80;", Removed},
+		{"  listen 8080;", Added},
+		{"  root /srv;", Unchanged},
+		{"  gzip on;", Added},
+		{"}", Unchanged},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("diffLines =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDiffLines_RebuildsBothSides(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randLines := func() []string {
+		out := make([]string, rng.IntN(30))
+		for i := range out {
+			out[i] = strconv.Itoa(rng.IntN(6))
+		}
+		return out
+	}
+	for range 500 {
+		a, b := randLines(), randLines()
+		got := diffLines(a, b)
+		gotA, gotB := sides(got)
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("diffLines(%v, %v) = %v does not rebuild both sides", a, b, got)
+		}
+		// Shortest edit: changed lines = len(a) + len(b) - 2*LCS.
+		if n, want := countChanged(got), len(a)+len(b)-2*lcsLen(a, b); n != want {
+			t.Fatalf("diffLines(%v, %v) made %d edits, want %d", a, b, n, want)
+		}
+	}
+}
+
+func lcsLen(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestDiffLines_TooManyEditsFallsBack(t *testing.T) {
+	a := make([]string, maxLineEdits)
+	b := make([]string, maxLineEdits)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+	got := diffLines(a, b)
+	if len(got) != 2*maxLineEdits || got[0].Change != Removed || got[maxLineEdits].Change != Added {
+		t.Fatalf("want all removed then all added, got %d lines starting %v", len(got), got[0])
+	}
+}
+
+func TestDiff_MultilineStringGetsLineDiff(t *testing.T) {
+	a := map[string]any{"data": map[string]any{"nginx.conf": "a\nb\nc\n", "one": "x"}}
+	b := map[string]any{"data": map[string]any{"nginx.conf": "a\nB\nc\n", "one": "y"}}
+
+	data := Diff(a, b).Children["data"]
+	if got := data.Children["one"].Lines; got != nil {
+		t.Errorf("single-line strings must not get a line diff: %v", got)
+	}
+	conf := data.Children["nginx.conf"]
+	if conf.Change != Modified || countChanged(conf.Lines) != 2 || len(conf.Lines) != 4 {
+		t.Fatalf("nginx.conf lines = %v", conf.Lines)
+	}
+}
+
+func TestRender_MultilineStringHighlightsChangedLines(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = "line " + strconv.Itoa(i)
+	}
+	old := strings.Join(lines, "\n")
+	lines[10] = "line ten"
+	a := map[string]any{"script": old}
+	b := map[string]any{"script": strings.Join(lines, "\n")}
+
+	full := RenderYAML(Diff(a, b), plainTheme, plainOpts)
+	assertContains(t, full, "script: |\n")
+	assertContains(t, full, "  - line 10\n  + line ten\n")
+	assertContains(t, full, "    line 0\n")
+
+	changes := RenderYAML(Diff(a, b), plainTheme, RenderOptions{IndentSize: 2, ChangesOnly: true})
+	want := "script: |\n  ⋯ 8 unchanged lines\n    line 8\n    line 9\n  - line 10\n  + line ten\n    line 11\n    line 12\n  ⋯ 7 unchanged lines\n"
+	if changes != want {
+		t.Fatalf("ChangesOnly:\n%s\nwant:\n%s", changes, want)
+	}
+
+	unified := RenderUnified(Diff(a, b), UnifiedOptions{Context: 1})
+	want = "--- a\n+++ b\n@@ -11,3 +11,3 @@\n   line 9\n-  line 10\n+  line ten\n   line 11\n"
+	if unified != want {
+		t.Fatalf("unified:\n%s\nwant:\n%s", unified, want)
+	}
+}
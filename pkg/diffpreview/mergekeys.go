@@ -0,0 +1,139 @@
+package diffpreview
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// builtinMergeKeys maps list paths of built-in Kubernetes types to the fields
+// that identify their items, following the patchMergeKey (and, where
+// upstream needs more than one field, listMapKeys) tags of the API types.
+//
+// A path is a dotted suffix of field names; list items don't add a segment,
+// so "containers.ports" matches both spec.containers[].ports and
+// spec.template.spec.containers[].ports. The longest matching suffix wins.
+// Several fields are joined with ",".
+var builtinMergeKeys = map[string]string{
+	// Pod spec, at any depth (Pod, templates, CronJob job templates).
+	"containers":                        "name",
+	"initContainers":                    "name",
+	"ephemeralContainers":               "name",
+	"containers.ports":                  "containerPort,protocol",
+	"initContainers.ports":              "containerPort,protocol",
+	"containers.env":                    "name",
+	"initContainers.env":                "name",
+	"containers.volumeMounts":           "mountPath",
+	"initContainers.volumeMounts":       "mountPath",
+	"containers.volumeDevices":          "devicePath",
+	"initContainers.volumeDevices":      "devicePath",
+	"containers.resizePolicy":           "resourceName",
+	"volumes":                           "name",
+	"imagePullSecrets":                  "name",
+	"hostAliases":                       "ip",
+	"topologySpreadConstraints":         "topologyKey,whenUnsatisfiable",
+	"readinessGates":                    "conditionType",
+	"schedulingGates":                   "name",
+	"resourceClaims":                    "name",
+	"status.containerStatuses":          "name",
+	"status.initContainerStatuses":      "name",
+	"status.ephemeralContainerStatuses": "name",
+	"status.podIPs":                     "ip",
+	"status.hostIPs":                    "ip",
+
+	// Tolerations are atomic upstream; matching by key still lines up the
+	// common case of one toleration per taint key.
+	"tolerations": "key,operator,effect",
+
+	// Services.
+	"spec.ports": "port,protocol",
+
+	// Object metadata and status conventions.
+	"metadata.ownerReferences": "uid",
+	"metadata.managedFields":   "manager,operation,subresource",
+	"status.conditions":        "type",
+
+	// Admission webhooks.
+	"webhooks": "name",
+}
+
+var (
+	mergeKeysMu sync.RWMutex
+	mergeKeys   = maps.Clone(builtinMergeKeys)
+)
+
+// RegisterMergeKey declares the fields that identify items of the lists at
+// [path], for CRDs whose lists the built-in table doesn't know. [path] is a
+// dotted suffix of field names (list items add no segment), e.g.
+// "spec.endpoints"; [key] is one field, or several joined with ",". It
+// overrides a built-in entry for the same path.
+func RegisterMergeKey(path, key string) error {
+	path, key = strings.TrimSpace(path), strings.TrimSpace(key)
+	if path == "" || key == "" {
+		return fmt.Errorf("merge key for %q: path and key must not be empty", path)
+	}
+	for _, seg := range strings.Split(path, ".") {
+		if seg == "" {
+			return fmt.Errorf("merge key for %q: empty path segment", path)
+		}
+	}
+	mergeKeysMu.Lock()
+	defer mergeKeysMu.Unlock()
+	mergeKeys[path] = key
+	return nil
+}
+
+// mergeKeyFor returns the merge key fields registered for the list at [path]
+// (its field names from the root), using the longest matching suffix.
+func mergeKeyFor(path []string) []string {
+	mergeKeysMu.RLock()
+	defer mergeKeysMu.RUnlock()
+	for i := range path {
+		if key, ok := mergeKeys[strings.Join(path[i:], ".")]; ok {
+			return strings.Split(key, ",")
+		}
+	}
+	return nil
+}
+
+// usableMergeKey reports whether the lists hold at least one map, every map
+// element carries the first field of [fields], and no two elements of one
+// list share a key. Non-map items are diffed as unkeyed additions/removals.
+func usableMergeKey(a, b []any, fields []string) bool {
+	found := false
+	for _, list := range [][]any{a, b} {
+		seen := make(map[string]struct{}, len(list))
+		for _, item := range list {
+			m, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			found = true
+			if _, has := m[fields[0]]; !has {
+				return false
+			}
+			k := compositeKey(m, fields)
+			if _, dup := seen[k]; dup {
+				return false
+			}
+			seen[k] = struct{}{}
+		}
+	}
+	return found
+}
+
+// compositeKey joins the values of [fields] in [m]; absent fields count as
+// empty, like defaulted list-map keys (protocol) usually are.
+func compositeKey(m map[string]any, fields []string) string {
+	if len(fields) == 1 {
+		return fmt.Sprintf("%v", m[fields[0]])
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if v, ok := m[f]; ok {
+			parts[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
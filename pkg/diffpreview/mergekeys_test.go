@@ -0,0 +1,158 @@
+package diffpreview
+
+import (
+	"slices"
+	"testing"
+)
+
+// changedItems returns the indexes of the list items that have changes.
+func changedItems(list []*AnnotatedNode) []int {
+	var out []int
+	for i, item := range list {
+		if hasChanges(item) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func TestDiff_MergeKey_VolumeMountsByMountPath(t *testing.T) {
+	// No element has "name"-like identity the heuristic could use, so this
+	// used to be diffed by position and every item looked modified.
+	spec := func(mounts ...any) map[string]any {
+		return map[string]any{"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "app", "volumeMounts": mounts},
+		}}}
+	}
+	a := spec(
+		map[string]any{"mountPath": "/cache", "readOnly": false},
+		map[string]any{"mountPath": "/config", "readOnly": true},
+	)
+	b := spec(
+		map[string]any{"mountPath": "/tmp", "readOnly": false},
+		map[string]any{"mountPath": "/cache", "readOnly": false},
+		map[string]any{"mountPath": "/config", "readOnly": true},
+	)
+
+	mounts := Diff(a, b).Children["spec"].Children["containers"].List[0].Children["volumeMounts"]
+	if got := changedItems(mounts.List); !slices.Equal(got, []int{2}) {
+		t.Fatalf("changed items = %v, want only the appended /tmp mount", got)
+	}
+	if mounts.List[2].Change != Added || mounts.List[2].Children["mountPath"].Value != "/tmp" {
+		t.Fatalf("want /tmp added, got %+v", mounts.List[2])
+	}
+}
+
+func TestDiff_MergeKey_PortsWithMixedNames(t *testing.T) {
+	ports := func(items ...any) map[string]any {
+		return map[string]any{"containers": []any{map[string]any{"name": "app", "ports": items}}}
+	}
+	a := ports(
+		map[string]any{"containerPort": 8080, "protocol": "TCP", "name": "http"},
+		map[string]any{"containerPort": 53, "protocol": "UDP"},
+	)
+	b := ports(
+		map[string]any{"containerPort": 53, "protocol": "TCP"},
+		map[string]any{"containerPort": 53, "protocol": "UDP"},
+		map[string]any{"containerPort": 8080, "protocol": "TCP", "name": "web"},
+	)
+
+	list := Diff(a, b).Children["containers"].List[0].Children["ports"].List
+	if len(list) != 3 {
+		t.Fatalf("want 3 items, got %d", len(list))
+	}
+	// 8080/TCP matched despite the reorder; only its name changed.
+	if got := list[0].Children["name"]; got.Change != Modified || got.Value != "web" {
+		t.Fatalf("8080 name = %+v, want Modified to web", got)
+	}
+	if hasChanges(list[1]) {
+		t.Fatal("53/UDP should be unchanged")
+	}
+	if list[2].Change != Added || list[2].Children["protocol"].Value != "TCP" {
+		t.Fatalf("want 53/TCP added, got %+v", list[2])
+	}
+}
+
+func TestDiff_MergeKey_ServicePortsUsePort(t *testing.T) {
+	a := map[string]any{"spec": map[string]any{"ports": []any{
+		map[string]any{"port": 80, "protocol": "TCP", "targetPort": 8080},
+		map[string]any{"port": 443, "protocol": "TCP", "targetPort": 8443},
+	}}}
+	b := map[string]any{"spec": map[string]any{"ports": []any{
+		map[string]any{"port": 443, "protocol": "TCP", "targetPort": 9443},
+		map[string]any{"port": 80, "protocol": "TCP", "targetPort": 8080},
+	}}}
+
+	list := Diff(a, b).Children["spec"].Children["ports"].List
+	if got := changedItems(list); !slices.Equal(got, []int{1}) {
+		t.Fatalf("changed items = %v, want only 443", got)
+	}
+	if list[1].Children["targetPort"].Change != Modified {
+		t.Fatalf("want 443 targetPort modified, got %+v", list[1].Children["targetPort"])
+	}
+}
+
+func TestDiff_MergeKey_DuplicateKeysFallBack(t *testing.T) {
+	// Two items share the registered key: matching by it would drop one, so
+	// the diff falls back to the heuristic and positions.
+	a := map[string]any{"volumes": []any{
+		map[string]any{"name": "data", "emptyDir": map[string]any{}},
+		map[string]any{"name": "data", "emptyDir": map[string]any{}},
+	}}
+	b := map[string]any{"volumes": []any{
+		map[string]any{"name": "data", "emptyDir": map[string]any{}},
+	}}
+
+	list := Diff(a, b).Children["volumes"].List
+	if len(list) != 2 || list[1].Change != Removed {
+		t.Fatalf("want second duplicate removed, got %d items", len(list))
+	}
+}
+
+func TestRegisterMergeKey_CRD(t *testing.T) {
+	t.Cleanup(func() {
+		mergeKeysMu.Lock()
+		delete(mergeKeys, "spec.endpoints")
+		mergeKeysMu.Unlock()
+	})
+	if err := RegisterMergeKey("spec.endpoints", "port"); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range [][2]string{{"", "port"}, {"spec.endpoints", " "}, {"spec..endpoints", "port"}} {
+		if err := RegisterMergeKey(bad[0], bad[1]); err == nil {
+			t.Errorf("RegisterMergeKey(%q, %q) should fail", bad[0], bad[1])
+		}
+	}
+
+	a := map[string]any{"spec": map[string]any{"endpoints": []any{
+		map[string]any{"port": "web", "interval": "30s"},
+		map[string]any{"port": "metrics", "interval": "30s"},
+	}}}
+	b := map[string]any{"spec": map[string]any{"endpoints": []any{
+		map[string]any{"port": "metrics", "interval": "15s"},
+		map[string]any{"port": "web", "interval": "30s"},
+	}}}
+
+	list := Diff(a, b).Children["spec"].Children["endpoints"].List
+	if got := changedItems(list); !slices.Equal(got, []int{1}) {
+		t.Fatalf("changed items = %v, want only metrics", got)
+	}
+}
+
+func TestMergeKeyFor_LongestSuffix(t *testing.T) {
+	tests := []struct {
+		path []string
+		want []string
+	}{
+		{[]string{"spec", "template", "spec", "containers"}, []string{"name"}},
+		{[]string{"spec", "template", "spec", "containers", "ports"}, []string{"containerPort", "protocol"}},
+		{[]string{"spec", "ports"}, []string{"port", "protocol"}},
+		{[]string{"spec", "rules"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := mergeKeyFor(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("mergeKeyFor(%v) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package diffpreview
+
+import (
+	"strings"
+	"testing"
+)
+
+func normalizedFixture() *AnnotatedNode {
+	a := map[string]any{
+		"resources": map[string]any{"requests": map[string]any{"cpu": "1000m", "memory": "1Gi"}},
+		"timeout":   "60s",
+		"replicas":  float64(1),
+		"metadata":  map[string]any{"labels": map[string]any{"version": "1.10"}},
+	}
+	b := map[string]any{
+		"resources": map[string]any{"requests": map[string]any{"cpu": "1", "memory": "1024Mi"}},
+		"timeout":   "1m",
+		"replicas":  float64(2),
+		"metadata":  map[string]any{"labels": map[string]any{"version": "1.1"}},
+	}
+	return Diff(a, b)
+}
+
+func TestDiff_MarksNormalizedEdits(t *testing.T) {
+	node := normalizedFixture()
+	for _, n := range []*AnnotatedNode{
+		node.Children["resources"].Children["requests"].Children["cpu"],
+		node.Children["resources"].Children["requests"].Children["memory"],
+		node.Children["timeout"],
+	} {
+		if n.Change != Modified || !n.Normalized {
+			t.Errorf("%v -> %v: change %v, normalized %v", n.Old, n.Value, n.Change, n.Normalized)
+		}
+	}
+	if node.Children["replicas"].Normalized {
+		t.Error("1 -> 2 is a real change")
+	}
+	// Label values only look like quantities.
+	if version := node.Children["metadata"].Children["labels"].Children["version"]; version.Change != Modified || version.Normalized {
+		t.Errorf("version label 1.10 -> 1.1: change %v, normalized %v", version.Change, version.Normalized)
+	}
+}
+
+func TestRender_Normalized(t *testing.T) {
+	out := RenderYAML(normalizedFixture(), plainTheme, RenderOptions{IndentSize: 2})
+	assertContains(t, out, `cpu: "1"  # normalized, was "1000m"`)
+	assertContains(t, out, `timeout: "1m"  # normalized, was "60s"`)
+
+	out = RenderYAML(normalizedFixture(), plainTheme, RenderOptions{IndentSize: 2, ChangesOnly: true, HideNormalized: true})
+	if !strings.Contains(out, `version: "1.1"`) || !strings.Contains(out, "replicas: 2") ||
+		strings.Contains(out, "cpu") || strings.Contains(out, "timeout") {
+		t.Errorf("HideNormalized should leave only the real change, got:\n%s", out)
+	}
+}
@@ -3,6 +3,7 @@ package diffpreview
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,20 +14,58 @@ type RenderOptions struct {
 	// changes anywhere in their subtree, producing a compact diff that shows
 	// only what changed (with the surrounding path kept for context).
 	ChangesOnly bool
+	// Collapse is the middle ground between the full tree and ChangesOnly:
+	// changed subtrees are rendered, and so are the CollapseContext siblings
+	// on either side of each change, but every other run of unchanged
+	// siblings is folded into a single "… N unchanged fields" line. An
+	// unchanged map or list kept as context shows its key with its content
+	// folded. Ignored when ChangesOnly is set.
+	Collapse        bool
+	CollapseContext int
+	// Expanded holds the IDs of folded blocks (see CollapsedBlock) to show
+	// instead. The siblings of an expanded block are rendered as context,
+	// so their own content stays folded until it is expanded in turn.
+	Expanded map[string]bool
+	// HideNormalized renders normalized edits (see AnnotatedNode.Normalized)
+	// as unchanged. Otherwise they are highlighted like any edit and marked
+	// with a "# normalized" comment naming the old spelling.
+	HideNormalized bool
+}
+
+// CollapsedBlock is a run of unchanged fields or list items that Collapse
+// folded into one line.
+type CollapsedBlock struct {
+	// ID names the block by the path of its parent and its first sibling; it
+	// is stable across renders of the same tree.
+	ID string
+	// Line is the 0-based line of the fold marker in the output.
+	Line int
+	// Count is the number of fields or items folded.
+	Count int
 }
 
 // RenderYAML turns an AnnotatedNode tree into syntax-highlighted YAML with
 // colored backgrounds on changed lines.
 func RenderYAML(node *AnnotatedNode, theme Theme, opts RenderOptions) string {
+	out, _ := RenderYAMLBlocks(node, theme, opts)
+	return out
+}
+
+// RenderYAMLBlocks is RenderYAML that also returns, in output order, the
+// blocks Collapse folded, so that a viewer can offer to expand them.
+func RenderYAMLBlocks(node *AnnotatedNode, theme Theme, opts RenderOptions) (string, []CollapsedBlock) {
 	r := renderer{theme: theme, opts: opts}
 	r.renderNode(node, 0)
-	return r.sb.String()
+	return r.sb.String(), r.blocks
 }
 
 type renderer struct {
 	sb    strings.Builder
 	theme Theme
 	opts  RenderOptions
+	// path holds the segments of the node being rendered, for block IDs.
+	path   []string
+	blocks []CollapsedBlock
 }
 
 func (r *renderer) indent(level int) string {
@@ -41,48 +80,125 @@ func (r *renderer) renderNode(node *AnnotatedNode, level int) {
 	case node.List != nil:
 		r.renderList(node.List, level, node.Change)
 	default:
-		r.renderLeaf(node.Value, node.Change)
+		r.renderLeafNode(node, r.change(node))
 	}
 }
 
 func (r *renderer) renderMap(children map[string]*AnnotatedNode, level int) {
 	prefix := r.indent(level)
-	for _, key := range sortedKeys(children) {
-		child := children[key]
-		if r.opts.ChangesOnly && !hasChanges(child) {
+	keys := sortedKeys(children)
+	r.eachVisible(len(keys),
+		func(i int) bool { return r.hasChanges(children[keys[i]]) },
+		func(i int) string { return keys[i] },
+		"field", func() string { return prefix },
+		func(i int, lead string) {
+			child := children[keys[i]]
+			r.sb.WriteString(lead + r.styledKey(keys[i], r.change(child)))
+			r.renderChildValue(child, level)
+		})
+}
+
+// eachVisible calls render for each of [n] siblings that is shown, with the
+// indentation [lead] returns for the line it starts, and keeps r.path on the
+// sibling meanwhile. ChangesOnly skips unchanged siblings; Collapse folds
+// runs of them (see RenderOptions.Collapse) into a "… N unchanged [noun]s"
+// line. [name] gives a sibling's path segment.
+func (r *renderer) eachVisible(n int, changed func(int) bool, name func(int) string, noun string, lead func() string, render func(i int, lead string)) {
+	show := make([]bool, n)
+	for i := range n {
+		switch {
+		case r.opts.ChangesOnly:
+			show[i] = changed(i)
+		case !r.opts.Collapse:
+			show[i] = true
+		case changed(i):
+			for j := max(i-r.opts.CollapseContext, 0); j <= min(i+r.opts.CollapseContext, n-1); j++ {
+				show[j] = true
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if !show[i] && r.opts.Collapse && !r.opts.ChangesOnly {
+			end := i
+			for end < n && !show[end] {
+				end++
+			}
+			id := r.blockID(name(i))
+			// A single field takes a line either way; show it.
+			if end-i > 1 && !r.opts.Expanded[id] {
+				r.fold(lead(), id, end-i, noun)
+				i = end - 1
+				continue
+			}
+			for j := i; j < end; j++ {
+				show[j] = true
+			}
+		}
+		if !show[i] {
 			continue
 		}
-		r.sb.WriteString(prefix + r.styledKey(key, child.Change))
-		r.renderChildValue(child, level)
+		r.path = append(r.path, name(i))
+		render(i, lead())
+		r.path = r.path[:len(r.path)-1]
+	}
+}
+
+// blockID names a folded run by the current path and its first sibling.
+func (r *renderer) blockID(first string) string {
+	var sb strings.Builder
+	for _, seg := range r.path {
+		if sb.Len() > 0 && !strings.HasPrefix(seg, "[") {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(seg)
 	}
+	return sb.String() + "/" + first
+}
+
+// fold writes the line that stands in for [count] unchanged siblings.
+func (r *renderer) fold(prefix, id string, count int, noun string) {
+	if count != 1 {
+		noun += "s"
+	}
+	r.blocks = append(r.blocks, CollapsedBlock{ID: id, Line: strings.Count(r.sb.String(), "\n"), Count: count})
+	r.sb.WriteString(prefix + r.theme.NullStyle.Render(fmt.Sprintf("… %d unchanged %s", count, noun)) + "\n")
 }
 
 // renderList writes a YAML sequence. Map items get their first key on the
 // same line as "- " to match standard YAML block style.
 func (r *renderer) renderList(items []*AnnotatedNode, level int, parentChange ChangeType) {
 	prefix := r.indent(level)
+	r.eachVisible(len(items),
+		func(i int) bool { return r.hasChanges(items[i]) },
+		func(i int) string { return "[" + strconv.Itoa(i) + "]" },
+		"item", func() string { return prefix },
+		func(i int, prefix string) {
+			r.renderListItem(items[i], prefix, level, parentChange)
+		})
+}
 
-	for _, item := range items {
-		if r.opts.ChangesOnly && !hasChanges(item) {
-			continue
-		}
-		change := effectiveChange(item.Change, parentChange)
-		dash := r.styledDash(change)
+// renderListItem writes one item of a sequence, starting with its "- ".
+func (r *renderer) renderListItem(item *AnnotatedNode, prefix string, level int, parentChange ChangeType) {
+	change := effectiveChange(r.change(item), parentChange)
+	dash := r.styledDash(change)
 
-		switch {
-		case item.Children != nil:
-			r.renderListMapItem(item.Children, prefix, dash, level, change)
-		case item.List != nil:
-			if len(item.List) == 0 {
-				r.sb.WriteString(prefix + dash + "[]\n")
-				break
-			}
-			r.sb.WriteString(prefix + dash + "\n")
-			r.renderList(item.List, level+1, change)
-		default:
-			r.sb.WriteString(prefix + dash)
-			r.renderLeaf(item.Value, change)
+	switch {
+	case item.Children != nil:
+		r.renderListMapItem(item.Children, prefix, dash, level, change)
+	case item.List != nil:
+		if len(item.List) == 0 {
+			r.sb.WriteString(prefix + dash + "[]\n")
+			break
 		}
+		r.sb.WriteString(prefix + dash + "\n")
+		r.renderList(item.List, level+1, change)
+	case item.Lines != nil:
+		r.sb.WriteString(prefix + dash)
+		r.renderLines(item.Lines, level+1)
+	default:
+		r.sb.WriteString(prefix + dash)
+		r.renderLeafNode(item, change)
 	}
 }
 
@@ -90,29 +206,26 @@ func (r *renderer) renderList(items []*AnnotatedNode, level int, parentChange Ch
 // "- " line; the rest are indented one level deeper.
 func (r *renderer) renderListMapItem(children map[string]*AnnotatedNode, prefix, dash string, level int, _ ChangeType) {
 	keys := sortedKeys(children)
-	if r.opts.ChangesOnly {
-		filtered := keys[:0:0]
-		for _, k := range keys {
-			if hasChanges(children[k]) {
-				filtered = append(filtered, k)
-			}
+	rest := r.indent(level + 1)
+	first := true
+	lead := func() string {
+		if first {
+			first = false
+			return prefix + dash
 		}
-		keys = filtered
+		return rest
 	}
-	if len(keys) == 0 {
+	r.eachVisible(len(keys),
+		func(i int) bool { return r.hasChanges(children[keys[i]]) },
+		func(i int) string { return keys[i] },
+		"field", lead,
+		func(i int, lead string) {
+			child := children[keys[i]]
+			r.sb.WriteString(lead + r.styledKey(keys[i], r.change(child)))
+			r.renderChildValue(child, level+1)
+		})
+	if first {
 		r.sb.WriteString(prefix + dash + "{}\n")
-		return
-	}
-
-	first := children[keys[0]]
-	r.sb.WriteString(prefix + dash + r.styledKey(keys[0], first.Change))
-	r.renderChildValue(first, level+1)
-
-	rest := r.indent(level + 1)
-	for _, key := range keys[1:] {
-		child := children[key]
-		r.sb.WriteString(rest + r.styledKey(key, child.Change))
-		r.renderChildValue(child, level+1)
 	}
 }
 
@@ -134,12 +247,100 @@ func (r *renderer) renderChildValue(child *AnnotatedNode, level int) {
 		}
 		r.sb.WriteString("\n")
 		r.renderMap(child.Children, level+1)
+	case child.Lines != nil:
+		r.sb.WriteString(" ")
+		r.renderLines(child.Lines, level+1)
 	default:
 		r.sb.WriteString(" ")
-		r.renderLeaf(child.Value, child.Change)
+		r.renderLeafNode(child, r.change(child))
 	}
 }
 
+// changesOnlyLineContext is how many unchanged lines of a multi-line string
+// ChangesOnly and Collapse keep around each changed one.
+const changesOnlyLineContext = 2
+
+// renderLines writes the line diff of a multi-line string as a "|" block:
+// removed and added lines are marked with "-" and "+" and highlighted, the
+// others shown as is. ChangesOnly and Collapse cut runs of unchanged lines
+// down to a little context.
+func (r *renderer) renderLines(lines []LineDiff, level int) {
+	r.sb.WriteString("|\n")
+	prefix := r.indent(level)
+
+	keep := make([]bool, len(lines))
+	for i, l := range lines {
+		if !r.opts.ChangesOnly && !r.opts.Collapse {
+			keep[i] = true
+			continue
+		}
+		if l.Change != Unchanged {
+			for j := max(i-changesOnlyLineContext, 0); j <= min(i+changesOnlyLineContext, len(lines)-1); j++ {
+				keep[j] = true
+			}
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if !keep[i] {
+			skipped := 0
+			for ; i < len(lines) && !keep[i]; i++ {
+				skipped++
+			}
+			i--
+			r.sb.WriteString(prefix + r.theme.NullStyle.Render(fmt.Sprintf("⋯ %d unchanged lines", skipped)) + "\n")
+			continue
+		}
+		l := lines[i]
+		marker := "  "
+		switch l.Change {
+		case Added:
+			marker = "+ "
+		case Removed:
+			marker = "- "
+		}
+		content := r.theme.StringStyle.Render(marker + l.Text)
+		if r.opts.EnableBackgroundHighlight {
+			if bg := r.theme.backgroundStyle(l.Change); bg != nil {
+				content = bg.Render(content)
+			}
+		}
+		r.sb.WriteString(prefix + content + "\n")
+	}
+}
+
+// change is the node's change type as rendered: HideNormalized shows
+// normalized edits as Unchanged.
+func (r *renderer) change(node *AnnotatedNode) ChangeType {
+	if node.Normalized && r.opts.HideNormalized {
+		return Unchanged
+	}
+	return node.Change
+}
+
+func (r *renderer) hasChanges(node *AnnotatedNode) bool {
+	if r.opts.HideNormalized {
+		return hasSemanticChanges(node)
+	}
+	return hasChanges(node)
+}
+
+// renderLeafNode writes a leaf like renderLeaf, followed for a shown
+// normalized edit by a comment with the old spelling.
+func (r *renderer) renderLeafNode(node *AnnotatedNode, change ChangeType) {
+	if !node.Normalized || r.opts.HideNormalized {
+		r.renderLeaf(node.Value, change)
+		return
+	}
+	content := r.syntaxHighlight(node.Value)
+	if r.opts.EnableBackgroundHighlight {
+		if bg := r.theme.backgroundStyle(change); bg != nil {
+			content = bg.Render(content)
+		}
+	}
+	r.sb.WriteString(content + r.theme.NullStyle.Render("  # normalized, was "+formatScalar(node.Old)) + "\n")
+}
+
 // renderLeaf writes a syntax-highlighted scalar, optionally with a diff
 // background colour, followed by a newline.
 func (r *renderer) renderLeaf(val any, change ChangeType) {
@@ -153,19 +354,30 @@ func (r *renderer) renderLeaf(val any, change ChangeType) {
 }
 
 func (r *renderer) syntaxHighlight(val any) string {
-	switch v := val.(type) {
+	switch val.(type) {
 	case string:
-		return r.theme.StringStyle.Render(fmt.Sprintf("%q", v))
+		return r.theme.StringStyle.Render(formatScalar(val))
 	case bool:
-		return r.theme.BoolStyle.Render(fmt.Sprintf("%v", v))
-	case int:
-		return r.theme.NumberStyle.Render(fmt.Sprintf("%d", v))
-	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return r.theme.NumberStyle.Render(fmt.Sprintf("%d", v))
-	case float32, float64:
-		return r.theme.NumberStyle.Render(fmt.Sprintf("%v", v))
+		return r.theme.BoolStyle.Render(formatScalar(val))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return r.theme.NumberStyle.Render(formatScalar(val))
+	case nil:
+		return r.theme.NullStyle.Render(formatScalar(val))
+	default:
+		return formatScalar(val)
+	}
+}
+
+// formatScalar renders a leaf value as YAML text: strings quoted, null for
+// nil, everything else in its natural form.
+func formatScalar(val any) string {
+	switch v := val.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
 	case nil:
-		return r.theme.NullStyle.Render("null")
+		return "null"
 	default:
 		return fmt.Sprintf("%v", v)
 	}
@@ -0,0 +1,97 @@
+package diffpreview
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// SideBySideOptions configures RenderSideBySide.
+type SideBySideOptions struct {
+	// Width is the width of a whole row: both columns and the separator.
+	Width int
+	// IndentSize is the YAML indentation; 2 when zero.
+	IndentSize                int
+	EnableBackgroundHighlight bool
+	// Separator is drawn between the columns; "│" when empty. It may be
+	// styled.
+	Separator string
+}
+
+// RenderSideBySide renders an AnnotatedNode tree as two columns, the old
+// version on the left and the new one on the right. Unchanged lines and the
+// two sides of a modified value share a row; added and removed blocks are
+// padded with blank cells on the other side. Lines longer than a column are
+// truncated with "…".
+func RenderSideBySide(node *AnnotatedNode, theme Theme, opts SideBySideOptions) string {
+	if opts.IndentSize <= 0 {
+		opts.IndentSize = 2
+	}
+	if opts.Separator == "" {
+		opts.Separator = "│"
+	}
+	colW := max((opts.Width-lipgloss.Width(opts.Separator))/2, 4)
+
+	w := unifiedWriter{indentSize: opts.IndentSize}
+	if node != nil && node.Children != nil {
+		w.renderMap(node.Children, 0)
+	} else if node != nil {
+		w.renderEntry("", node, 0)
+	}
+
+	var sb strings.Builder
+	cell := func(l *diffLine, change ChangeType) string {
+		if l == nil {
+			return strings.Repeat(" ", colW)
+		}
+		marker := "  "
+		switch change {
+		case Added:
+			marker = "+ "
+		case Removed:
+			marker = "- "
+		}
+		text := marker + l.text
+		text = ansi.Truncate(text, colW, "…")
+		text += strings.Repeat(" ", colW-ansi.StringWidth(text))
+		if opts.EnableBackgroundHighlight {
+			if bg := theme.backgroundStyle(change); bg != nil {
+				return bg.Render(text)
+			}
+		}
+		return text
+	}
+	row := func(left, right *diffLine, lc, rc ChangeType) {
+		sb.WriteString(cell(left, lc) + opts.Separator + cell(right, rc) + "\n")
+	}
+
+	for i := 0; i < len(w.lines); {
+		if w.lines[i].op == opContext {
+			row(&w.lines[i], &w.lines[i], Unchanged, Unchanged)
+			i++
+			continue
+		}
+		// A run of changes from one block: pair its old and new lines.
+		var dels, ins []*diffLine
+		block := w.lines[i].block
+		for ; i < len(w.lines) && w.lines[i].op != opContext && w.lines[i].block == block; i++ {
+			if w.lines[i].op == opDelete {
+				dels = append(dels, &w.lines[i])
+			} else {
+				ins = append(ins, &w.lines[i])
+			}
+		}
+		for j := range max(len(dels), len(ins)) {
+			var left, right *diffLine
+			if j < len(dels) {
+				left = dels[j]
+			}
+			if j < len(ins) {
+				right = ins[j]
+			}
+			row(left, right, Removed, Added)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package diffpreview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRenderSideBySide(t *testing.T) {
+	a := map[string]any{
+		"spec": map[string]any{
+			"replicas": 1,
+			"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:1"},
+				map[string]any{"name": "sidecar", "image": "envoy"},
+			},
+		},
+	}
+	b := map[string]any{
+		"spec": map[string]any{
+			"replicas": 3,
+			"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:2"},
+			},
+		},
+	}
+
+	got := RenderSideBySide(Diff(a, b), plainTheme, SideBySideOptions{Width: 51, Separator: "|"})
+	want := strings.Join([]string{
+		"  spec:                  |  spec:                  ",
+		"    containers:          |    containers:          ",
+		"-     - image: \"nginx:1\" |+     - image: \"nginx:2\" ",
+		"        name: \"app\"      |        name: \"app\"      ",
+		"-     - image: \"envoy\"   |                         ",
+		"-       name: \"sidecar\"  |                         ",
+		"-   replicas: 1          |+   replicas: 3          ",
+	}, "\n") + "\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderSideBySide_TruncatesToWidth(t *testing.T) {
+	a := map[string]any{"annotation": strings.Repeat("x", 100)}
+	b := map[string]any{"annotation": strings.Repeat("y", 100) + "界"}
+
+	out := RenderSideBySide(Diff(a, b), plainTheme, SideBySideOptions{Width: 41})
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if w := lipgloss.Width(line); w != 41 {
+			t.Errorf("row %q is %d wide, want 41", line, w)
+		}
+		if !strings.Contains(line, "…") {
+			t.Errorf("row %q should be truncated", line)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package diffpreview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnifiedOptions configures RenderUnified.
+type UnifiedOptions struct {
+	// Context is the number of unchanged lines kept around each change
+	// (3 is what diff -u uses).
+	Context int
+	// IndentSize is the YAML indentation; 2 when zero.
+	IndentSize int
+	// FromLabel and ToLabel name the two sides in the "---" and "+++"
+	// headers; "a" and "b" when empty.
+	FromLabel string
+	ToLabel   string
+}
+
+// RenderUnified turns an AnnotatedNode tree into a plain-text unified diff
+// (---/+++ headers and @@ hunks) of the YAML of the two versions, without any
+// ANSI styling. List items line up exactly as in the tree, so it agrees with
+// RenderYAML. It returns "" when nothing changed.
+func RenderUnified(node *AnnotatedNode, opts UnifiedOptions) string {
+	if opts.IndentSize <= 0 {
+		opts.IndentSize = 2
+	}
+	if opts.FromLabel == "" {
+		opts.FromLabel = "a"
+	}
+	if opts.ToLabel == "" {
+		opts.ToLabel = "b"
+	}
+
+	w := unifiedWriter{indentSize: opts.IndentSize}
+	if node != nil && node.Children != nil {
+		w.renderMap(node.Children, 0)
+	} else if node != nil {
+		w.renderEntry("", node, 0)
+	}
+	lines := groupChanges(w.lines)
+
+	hunks := buildHunks(lines, max(opts.Context, 0))
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("--- " + opts.FromLabel + "\n")
+	sb.WriteString("+++ " + opts.ToLabel + "\n")
+	for _, h := range hunks {
+		sb.WriteString(h.header() + "\n")
+		for _, l := range lines[h.start:h.end] {
+			sb.WriteByte(l.op)
+			sb.WriteString(l.text + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// Line operations, as written in front of each line of a unified diff.
+const (
+	opContext = ' '
+	opDelete  = '-'
+	opInsert  = '+'
+)
+
+type diffLine struct {
+	op   byte
+	text string
+	// block groups the old and new lines of one replaced value, so that a
+	// side-by-side layout can put them on the same rows.
+	block int
+}
+
+// unifiedWriter flattens an AnnotatedNode tree into YAML lines tagged with
+// the side(s) they belong to, using the same layout as renderer.
+type unifiedWriter struct {
+	lines      []diffLine
+	indentSize int
+	block      int
+}
+
+func (w *unifiedWriter) indent(level int) string {
+	return strings.Repeat(" ", level*w.indentSize)
+}
+
+func (w *unifiedWriter) add(change ChangeType, text string) {
+	op := byte(opContext)
+	switch change {
+	case Added:
+		op = opInsert
+	case Removed:
+		op = opDelete
+	}
+	w.lines = append(w.lines, diffLine{op: op, text: strings.TrimRight(text, " "), block: w.block})
+}
+
+func (w *unifiedWriter) renderMap(children map[string]*AnnotatedNode, level int) {
+	for _, key := range sortedKeys(children) {
+		w.renderEntry(w.indent(level)+key+":", children[key], level)
+	}
+}
+
+// renderEntry writes a node after [head] ("key:" or "-" with indentation).
+// Nested content goes one level below [level].
+func (w *unifiedWriter) renderEntry(head string, node *AnnotatedNode, level int) {
+	switch {
+	case node.Lines != nil && isMultiline(node.Old) && isMultiline(node.Value):
+		// Both sides are blocks; only the changed lines differ.
+		w.add(Unchanged, head+" "+blockIndicator(node.Value.(string)))
+		for _, l := range node.Lines {
+			w.add(l.Change, w.indent(level+1)+l.Text)
+		}
+	case node.Change == Modified && node.Children == nil && node.List == nil:
+		// A replaced value: the old one on the left, the new one on the right.
+		w.block++
+		w.renderEntry(head, buildFullNode(node.Old, Removed), level)
+		w.renderEntry(head, buildFullNode(node.Value, Added), level)
+		w.block++
+	case node.Children != nil:
+		if len(node.Children) == 0 {
+			w.add(node.Change, head+" {}")
+			return
+		}
+		w.add(node.Change, head)
+		w.renderMap(node.Children, level+1)
+	case node.List != nil:
+		if len(node.List) == 0 {
+			w.add(node.Change, head+" []")
+			return
+		}
+		w.add(node.Change, head)
+		w.renderList(node.List, level+1)
+	case isMultiline(node.Value):
+		w.add(node.Change, head+" "+blockIndicator(node.Value.(string)))
+		for _, line := range splitLines(node.Value.(string)) {
+			w.add(node.Change, w.indent(level+1)+line)
+		}
+	default:
+		w.add(node.Change, head+" "+formatScalar(node.Value))
+	}
+}
+
+func (w *unifiedWriter) renderList(items []*AnnotatedNode, level int) {
+	prefix := w.indent(level)
+	for _, item := range items {
+		if item.Children == nil || len(item.Children) == 0 || item.Change == Modified {
+			w.renderEntry(prefix+"-", item, level)
+			continue
+		}
+		// Map items: the first key shares the "- " line.
+		keys := sortedKeys(item.Children)
+		w.renderEntry(prefix+"- "+keys[0]+":", item.Children[keys[0]], level+1)
+		for _, key := range keys[1:] {
+			w.renderEntry(w.indent(level+1)+key+":", item.Children[key], level+1)
+		}
+	}
+}
+
+// groupChanges reorders each run of changed lines so that its deletions come
+// before its insertions, like diff(1) prints them. Both sides keep their
+// order.
+func groupChanges(lines []diffLine) []diffLine {
+	out := make([]diffLine, 0, len(lines))
+	var ins []diffLine
+	for _, l := range lines {
+		switch l.op {
+		case opDelete:
+			out = append(out, l)
+		case opInsert:
+			ins = append(ins, l)
+		default:
+			out = append(out, ins...)
+			ins = ins[:0]
+			out = append(out, l)
+		}
+	}
+	return append(out, ins...)
+}
+
+// hunk is a range of lines, plus the position of its first line and its
+// length on each side.
+type hunk struct {
+	start, end       int
+	oldStart, oldLen int
+	newStart, newLen int
+}
+
+func (h hunk) header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.oldStart, h.oldLen), hunkRange(h.newStart, h.newLen))
+}
+
+// hunkRange formats one side of a hunk header the way diff -u does: an empty
+// range names the line before it, and a length of one is left out.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, n)
+	}
+}
+
+// buildHunks groups changed lines with [context] unchanged lines around
+// them, merging groups whose context would touch.
+func buildHunks(lines []diffLine, context int) []hunk {
+	var hunks []hunk
+	for i := 0; i < len(lines); i++ {
+		if lines[i].op == opContext {
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		// Extend while the next change is within reach of the context.
+		for j := i; j < len(lines) && j-end <= 2*context; j++ {
+			if lines[j].op != opContext {
+				end = j + 1
+				i = j
+			}
+		}
+		end = min(end+context, len(lines))
+		hunks = append(hunks, hunk{start: start, end: end})
+	}
+
+	// Line numbers on each side: context lines count on both.
+	oldLine, newLine, pos := 1, 1, 0
+	for k := range hunks {
+		h := &hunks[k]
+		for ; pos < h.start; pos++ {
+			oldLine, newLine = advance(lines[pos].op, oldLine, newLine)
+		}
+		h.oldStart, h.newStart = oldLine, newLine
+		for ; pos < h.end; pos++ {
+			switch lines[pos].op {
+			case opDelete:
+				h.oldLen++
+			case opInsert:
+				h.newLen++
+			default:
+				h.oldLen++
+				h.newLen++
+			}
+			oldLine, newLine = advance(lines[pos].op, oldLine, newLine)
+		}
+	}
+	return hunks
+}
+
+func advance(op byte, oldLine, newLine int) (int, int) {
+	switch op {
+	case opDelete:
+		return oldLine + 1, newLine
+	case opInsert:
+		return oldLine, newLine + 1
+	default:
+		return oldLine + 1, newLine + 1
+	}
+}
@@ -0,0 +1,158 @@
+package diffpreview
+
+import (
+	"strings"
+	"testing"
+)
+
+func unifiedFixture() (a, b map[string]any) {
+	a = map[string]any{
+		"metadata": map[string]any{"name": "web", "labels": map[string]any{"app": "web"}},
+		"spec": map[string]any{
+			"replicas": 1,
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:1"},
+				map[string]any{"name": "sidecar", "image": "envoy"},
+			}}},
+		},
+	}
+	b = map[string]any{
+		"metadata": map[string]any{"name": "web", "labels": map[string]any{"app": "web", "tier": "fe"}},
+		"spec": map[string]any{
+			"replicas": 3,
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:2"},
+			}}},
+		},
+	}
+	return a, b
+}
+
+func TestRenderUnified(t *testing.T) {
+	a, b := unifiedFixture()
+	got := RenderUnified(Diff(a, b), UnifiedOptions{Context: 1, FromLabel: "web@0001", ToLabel: "web@0002"})
+	want := `--- web@0001
++++ web@0002
+@@ -3,5 +3,6 @@
+     app: "web"
++    tier: "fe"
+   name: "web"
+ spec:
+-  replicas: 1
++  replicas: 3
+   template:
+@@ -9,5 +10,3 @@
+       containers:
+-        - image: "nginx:1"
++        - image: "nginx:2"
+           name: "app"
+-        - image: "envoy"
+-          name: "sidecar"
+`
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Contains(got, "\x1b") {
+		t.Fatal("unified output must not contain ANSI escapes")
+	}
+}
+
+// TestRenderUnified_SidesMatchYAML checks that the old and new sides of a
+// full-context diff are exactly the YAML of each version.
+func TestRenderUnified_SidesMatchYAML(t *testing.T) {
+	a, b := unifiedFixture()
+	out := RenderUnified(Diff(a, b), UnifiedOptions{Context: 1000})
+
+	var oldSide, newSide []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n")[3:] {
+		switch line[0] {
+		case ' ':
+			oldSide = append(oldSide, line[1:])
+			newSide = append(newSide, line[1:])
+		case '-':
+			oldSide = append(oldSide, line[1:])
+		case '+':
+			newSide = append(newSide, line[1:])
+		}
+	}
+
+	yamlOf := func(obj map[string]any) []string {
+		w := unifiedWriter{indentSize: 2}
+		w.renderMap(buildFullNode(obj, Unchanged).Children, 0)
+		lines := make([]string, len(w.lines))
+		for i, l := range w.lines {
+			lines[i] = l.text
+		}
+		return lines
+	}
+	if got, want := strings.Join(oldSide, "\n"), strings.Join(yamlOf(a), "\n"); got != want {
+		t.Errorf("old side:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := strings.Join(newSide, "\n"), strings.Join(yamlOf(b), "\n"); got != want {
+		t.Errorf("new side:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderUnified_NoChanges(t *testing.T) {
+	a, _ := unifiedFixture()
+	if got := RenderUnified(Diff(a, a), UnifiedOptions{Context: 3}); got != "" {
+		t.Fatalf("want empty output for equal objects, got:\n%s", got)
+	}
+}
+
+func TestRenderUnified_TypeChangeAndZeroContext(t *testing.T) {
+	a := map[string]any{"a": 1, "data": "x", "z": 1}
+	b := map[string]any{"a": 1, "data": map[string]any{"k": "v"}, "z": 1}
+	got := RenderUnified(Diff(a, b), UnifiedOptions{})
+	want := `--- a
++++ b
+@@ -2 +2,2 @@
+-data: "x"
++data:
++  k: "v"
+`
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// A change of the trailing newline alone has no changed line, but must
+// still show up.
+func TestRenderUnified_TrailingNewline(t *testing.T) {
+	a := map[string]any{"script": "a\nb\n"}
+	b := map[string]any{"script": "a\nb"}
+	node := Diff(a, b)
+	if node.Children["script"].Lines != nil {
+		t.Errorf("a change of the trailing newline must not get a line diff: %v", node.Children["script"].Lines)
+	}
+	got := RenderUnified(node, UnifiedOptions{})
+	want := `--- a
++++ b
+@@ -1,3 +1,3 @@
+-script: |
+-  a
+-  b
++script: |-
++  a
++  b
+`
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHunkRange(t *testing.T) {
+	tests := []struct {
+		start, n int
+		want     string
+	}{
+		{1, 0, "0,0"},
+		{5, 1, "5"},
+		{5, 3, "5,3"},
+	}
+	for _, tt := range tests {
+		if got := hunkRange(tt.start, tt.n); got != tt.want {
+			t.Errorf("hunkRange(%d, %d) = %q, want %q", tt.start, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package mux
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+)
+
+// WithMetadataClient sets the client used by watches added with
+// [MetadataOnly].
+func WithMetadataClient(client metadata.Interface) Option {
+	return func(c *muxConfig) {
+		c.metadataClient = client
+	}
+}
+
+// MetadataOnly watches the GVR through the metadata API instead of the
+// full objects: only metadata is transferred, so label, annotation, owner,
+// finalizer and resourceVersion changes are seen without the bodies. That
+// saves memory and disk on large resources and keeps data like Secret
+// values out of reach. The metadata API doesn't report the kind of the
+// objects, so it is given here. Needs [WithMetadataClient].
+//
+// The objects are delivered as [*unstructured.Unstructured] holding only
+// apiVersion, kind and metadata.
+func MetadataOnly(kind string) AddOption {
+	return func(c *addConfig) {
+		c.metadataKind = kind
+	}
+}
+
+// metadataObject turns an object of a metadata-only watch into an
+// unstructured object of kind [gvk], like the other watches deliver.
+// Other objects are returned as they are.
+func metadataObject(ro runtime.Object, gvk schema.GroupVersionKind) runtime.Object {
+	pom, ok := ro.(*metav1.PartialObjectMetadata)
+	if !ok {
+		return ro
+	}
+	meta, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pom.ObjectMeta)
+	if err != nil {
+		return ro
+	}
+	u := &unstructured.Unstructured{Object: map[string]any{"metadata": meta}}
+	u.SetGroupVersionKind(gvk)
+	return u
+}
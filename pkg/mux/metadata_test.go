@@ -0,0 +1,124 @@
+package mux
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	fakemetadata "k8s.io/client-go/metadata/fake"
+)
+
+var secretGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+func testSecretMetadata(name, namespace string, labels map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Labels:     labels,
+			Finalizers: []string{"example.com/keep"},
+		},
+	}
+}
+
+// newTestMetadataClient creates a fake metadata client pre-loaded with the
+// given Secrets.
+func newTestMetadataClient(objects ...*metav1.PartialObjectMetadata) *fakemetadata.FakeMetadataClient {
+	scheme := fakemetadata.NewTestScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, &metav1.PartialObjectMetadata{})
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "SecretList"}, &metav1.PartialObjectMetadataList{})
+	var objs []runtime.Object
+	for _, o := range objects {
+		objs = append(objs, o)
+	}
+	return fakemetadata.NewSimpleMetadataClient(scheme, objs...)
+}
+
+func TestAdd_MetadataOnly(t *testing.T) {
+	secret := testSecretMetadata("token", "default", map[string]string{"app": "api"})
+	client := newTestMetadataClient(secret)
+	m, _ := newTestMuxWithOptions(t, []Option{WithMetadataClient(client)})
+
+	if err := m.Add(secretGVR, MetadataOnly("Secret")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	events := drainEvents(t, m.Events(), 1, 2*time.Second)
+	u, ok := events[0].Object.(*unstructured.Unstructured)
+	if !ok {
+		t.Fatalf("object is %T, want *unstructured.Unstructured", events[0].Object)
+	}
+	if u.GetAPIVersion() != "v1" || u.GetKind() != "Secret" {
+		t.Errorf("apiVersion/kind = %s/%s, want v1/Secret", u.GetAPIVersion(), u.GetKind())
+	}
+	if u.GetName() != "token" || u.GetNamespace() != "default" {
+		t.Errorf("object = %s/%s, want default/token", u.GetNamespace(), u.GetName())
+	}
+	if !reflect.DeepEqual(u.GetLabels(), map[string]string{"app": "api"}) {
+		t.Errorf("labels = %v", u.GetLabels())
+	}
+	if !reflect.DeepEqual(u.GetFinalizers(), []string{"example.com/keep"}) {
+		t.Errorf("finalizers = %v", u.GetFinalizers())
+	}
+	for key := range u.Object {
+		if key != "apiVersion" && key != "kind" && key != "metadata" {
+			t.Errorf("unexpected field %q in metadata-only object", key)
+		}
+	}
+
+	updated := testSecretMetadata("token", "default", map[string]string{"app": "web"})
+	secrets := client.Resource(secretGVR).Namespace("default").(fakemetadata.MetadataClient)
+	if _, err := secrets.UpdateFake(updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateFake: %v", err)
+	}
+	events = drainEvents(t, m.Events(), 1, 2*time.Second)
+	if events[0].Type != watch.Modified {
+		t.Fatalf("event type = %s, want %s", events[0].Type, watch.Modified)
+	}
+	if got := events[0].Object.(*unstructured.Unstructured).GetLabels()["app"]; got != "web" {
+		t.Errorf("updated label app = %q, want web", got)
+	}
+}
+
+func TestAdd_MetadataOnly_NoClient(t *testing.T) {
+	m, _ := newTestMux(t)
+	if err := m.Add(secretGVR, MetadataOnly("Secret")); err == nil {
+		t.Fatal("expected an error without a metadata client")
+	}
+	if m.Has(secretGVR) {
+		t.Error("failed metadata-only watch must not be kept")
+	}
+}
+
+func TestRepair_MetadataOnly(t *testing.T) {
+	secret := testSecretMetadata("token", "default", nil)
+	client := newTestMetadataClient(secret)
+	m, _ := newTestMuxWithOptions(t, []Option{WithMetadataClient(client)})
+	if err := m.Add(secretGVR, MetadataOnly("Secret")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	drainEvents(t, m.Events(), 1, 2*time.Second)
+
+	m.mu.RLock()
+	w := m.watches[secretGVR]
+	m.mu.RUnlock()
+	w.noteDropped("default/token", watch.Event{Type: watch.Modified, Object: testPod("token", "default")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go m.repair(w)
+	select {
+	case ev := <-m.Events():
+		if u, ok := ev.Object.(*unstructured.Unstructured); !ok || u.GetKind() != "Secret" {
+			t.Errorf("repaired object = %#v, want a Secret", ev.Object)
+		}
+	case <-ctx.Done():
+		t.Fatal("no repair event")
+	}
+}
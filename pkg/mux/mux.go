@@ -1,12 +1,17 @@
 // Package mux multiplexes Kubernetes dynamic informers created on demand.
 //
 // Each registered GroupVersionResource gets its own shared informer that
-// lists existing objects and then watches for changes. All events are
-// delivered on a single unified channel accessible via [Mux.Events].
+// lists existing objects and then watches for changes; with [WithNamespaces]
+// it gets one per namespace instead. All events are delivered on a single
+// unified channel accessible via [Mux.Events].
 //
 // The underlying informers handle gap recovery automatically (HTTP 410
-// Gone, timeouts, etc.), so no events are missed. Consumers may see
-// duplicates and should deduplicate if exactly-once semantics are
+// Gone, timeouts, etc.), so no events are missed. When the consumer falls
+// behind and the channel stays full, events are dropped rather than
+// blocking the informers; the dropped objects are counted (see
+// [Mux.Stats]) and sent again with their latest cached state, so a
+// consumer still converges on the final state of every object. Consumers
+// may see duplicates and should deduplicate if exactly-once semantics are
 // required.
 package mux
 
@@ -14,15 +19,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -38,9 +47,13 @@ var ErrStopped = errors.New("mux is stopped")
 type Option func(*muxConfig)
 
 type muxConfig struct {
-	buffer        int
-	labelSelector string
-	fieldSelector string
+	buffer         int
+	labelSelector  string
+	fieldSelector  string
+	namespaces     []string
+	metadataClient metadata.Interface
+	repairInterval time.Duration // set by withRepairInterval
+	resumeIdle     time.Duration // set by withResumeIdle
 }
 
 // WithBuffer sets the capacity of the internal event channel.
@@ -67,6 +80,58 @@ func WithFieldSelector(s string) Option {
 	}
 }
 
+// WithNamespaces restricts namespaced resources to the given namespaces:
+// Add starts one informer per namespace for each GVR, so loog only needs
+// list and watch permissions in those namespaces. Cluster-scoped resources
+// must be added with [ClusterScoped]. Duplicates and empty names are
+// ignored; without any namespace the informers watch all namespaces.
+func WithNamespaces(namespaces ...string) Option {
+	return func(c *muxConfig) {
+		for _, ns := range namespaces {
+			if ns != metav1.NamespaceAll && !slices.Contains(c.namespaces, ns) {
+				c.namespaces = append(c.namespaces, ns)
+			}
+		}
+	}
+}
+
+// AddOption configures a single watch registered with [Mux.Add].
+type AddOption func(*addConfig)
+
+type addConfig struct {
+	clusterScoped bool
+	labelSelector string
+	fieldSelector string
+	metadataKind  string // set by MetadataOnly
+	resumeFrom    string // set by ResumeFrom
+}
+
+// ClusterScoped marks the GVR as cluster-scoped, so it is watched with a
+// single cluster-wide informer regardless of [WithNamespaces].
+func ClusterScoped() AddOption {
+	return func(c *addConfig) {
+		c.clusterScoped = true
+	}
+}
+
+// LabelSelector restricts this watch to objects matching the Kubernetes
+// label selector s, server-side. It is combined (ANDed) with the selector
+// of [WithLabelSelector], if any.
+func LabelSelector(s string) AddOption {
+	return func(c *addConfig) {
+		c.labelSelector = s
+	}
+}
+
+// FieldSelector restricts this watch to objects matching the Kubernetes
+// field selector s, server-side. It is combined (ANDed) with the selector
+// of [WithFieldSelector], if any.
+func FieldSelector(s string) AddOption {
+	return func(c *addConfig) {
+		c.fieldSelector = s
+	}
+}
+
 // Mux manages a dynamic set of Kubernetes informers and merges their
 // events into a single channel. Watches can be added and removed at
 // runtime. All methods are safe for concurrent use.
@@ -84,11 +149,34 @@ type Mux struct {
 	stopped bool
 }
 
-// watchEntry holds the per-GVR cancel func and a channel that is closed once
-// the initial Add for that GVR has finished (whether it synced or failed).
+// watchEntry holds the per-GVR cancel func, a channel that is closed once
+// the initial Add for that GVR has finished (whether it synced or failed),
+// its informers (one per namespace, set under the Mux lock once synced) and
+// its event counters.
 type watchEntry struct {
-	cancel context.CancelFunc
-	synced chan struct{}
+	cancel    context.CancelFunc
+	done      <-chan struct{} // closed by cancel
+	synced    chan struct{}
+	informers []cache.SharedIndexInformer
+	// metadataGVK is the kind of a metadata-only watch, empty otherwise.
+	metadataGVK schema.GroupVersionKind
+	// replayDeleted holds the UIDs of the objects whose Deleted events were
+	// replayed (see [ResumeFrom]); only written before the watch synced.
+	replayDeleted map[types.UID]bool
+	watchCounters
+
+	// sendMu is held for reading while an event of the watch is sent, so
+	// Remove can wait for those in flight before setting removed.
+	sendMu  sync.RWMutex
+	removed bool
+}
+
+// object converts an informer object to what the watch delivers.
+func (w *watchEntry) object(ro runtime.Object) runtime.Object {
+	if w.metadataGVK.Empty() {
+		return ro
+	}
+	return metadataObject(ro, w.metadataGVK)
 }
 
 // New creates a Mux that will create informers using the provided
@@ -107,6 +195,12 @@ func New(ctx context.Context, client dynamic.Interface, opts ...Option) (*Mux, e
 	if cfg.buffer < 1 {
 		cfg.buffer = defaultBuffer
 	}
+	if cfg.repairInterval <= 0 {
+		cfg.repairInterval = defaultRepairInterval
+	}
+	if cfg.resumeIdle <= 0 {
+		cfg.resumeIdle = defaultResumeIdle
+	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	return &Mux{
@@ -122,8 +216,14 @@ func New(ctx context.Context, client dynamic.Interface, opts ...Option) (*Mux, e
 // Add registers a watch for the given GVR. It blocks until the
 // informer's cache has synced (the initial list is complete). Calling
 // Add for an already-watched GVR (including a concurrent Add for the
-// same GVR) blocks until that watch has synced, then returns.
-func (m *Mux) Add(gvr schema.GroupVersionResource) error {
+// same GVR) blocks until that watch has synced, then returns; its
+// options are ignored.
+func (m *Mux) Add(gvr schema.GroupVersionResource, opts ...AddOption) error {
+	var ac addConfig
+	for _, o := range opts {
+		o(&ac)
+	}
+
 	m.mu.Lock()
 	if m.stopped {
 		m.mu.Unlock()
@@ -145,10 +245,18 @@ func (m *Mux) Add(gvr schema.GroupVersionResource) error {
 		return fmt.Errorf("concurrent add for %s did not complete", gvr)
 	}
 
+	if ac.metadataKind != "" && m.cfg.metadataClient == nil {
+		m.mu.Unlock()
+		return fmt.Errorf("metadata-only watch for %s needs a metadata client", gvr)
+	}
+
 	ctx, cancel := context.WithCancel(m.ctx)
 
 	// Reserve the slot so a concurrent Add for the same GVR waits on synced.
-	entry := &watchEntry{cancel: cancel, synced: make(chan struct{})}
+	entry := &watchEntry{cancel: cancel, done: ctx.Done(), synced: make(chan struct{})}
+	if ac.metadataKind != "" {
+		entry.metadataGVK = gvr.GroupVersion().WithKind(ac.metadataKind)
+	}
 	m.watches[gvr] = entry
 	m.mu.Unlock()
 
@@ -159,47 +267,80 @@ func (m *Mux) Add(gvr schema.GroupVersionResource) error {
 	// Everything below runs without the lock. The informer's event
 	// handlers acquire a read-lock internally, so holding a write-lock
 	// here would deadlock during the initial list.
-	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
-		m.client, 0, metav1.NamespaceAll, m.tweakListOptions,
-	)
-
-	inf := factory.ForResource(gvr).Informer()
-	if _, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    m.dispatch(watch.Added),
-		UpdateFunc: func(_, newObj any) { m.dispatch(watch.Modified)(newObj) },
-		DeleteFunc: m.dispatch(watch.Deleted),
-	}); err != nil {
-		cancel()
-		m.unwatch(gvr)
-		return fmt.Errorf("register event handler for %s: %w", gvr, err)
+	namespaces := m.cfg.namespaces
+	if ac.clusterScoped || len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	informers := make([]cache.SharedIndexInformer, 0, len(namespaces))
+	if ac.resumeFrom != "" {
+		resumed := true
+		for _, ns := range namespaces {
+			resumed = m.replay(ctx, gvr, ns, ac, entry) && resumed
+		}
+		entry.resumed.Store(resumed)
 	}
 
-	// Start is non-blocking (it launches the informer goroutines and
-	// returns), so call it directly; wrapping it in `go` would let
-	// WaitForCacheSync run before Start.
-	factory.Start(ctx.Done())
+	for _, ns := range namespaces {
+		inf, start := m.newInformer(gvr, ns, ac)
+		if _, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    m.dispatch(entry, watch.Added),
+			UpdateFunc: func(_, newObj any) { m.dispatch(entry, watch.Modified)(newObj) },
+			DeleteFunc: m.dispatch(entry, watch.Deleted),
+		}); err != nil {
+			cancel()
+			m.unwatch(gvr, entry)
+			return fmt.Errorf("register event handler for %s: %w", gvr, err)
+		}
 
-	if !cache.WaitForCacheSync(ctx.Done(), inf.HasSynced) {
-		cancel()
-		m.unwatch(gvr)
-		return fmt.Errorf("cache sync failed for %s", gvr)
+		// Start is non-blocking (it launches the informer goroutines and
+		// returns), so call it directly; wrapping it in `go` would let
+		// WaitForCacheSync run before Start.
+		start(ctx.Done())
+		informers = append(informers, inf)
 	}
 
+	for i, inf := range informers {
+		if !cache.WaitForCacheSync(ctx.Done(), inf.HasSynced) {
+			cancel()
+			m.unwatch(gvr, entry)
+			if namespaces[i] != metav1.NamespaceAll {
+				return fmt.Errorf("cache sync failed for %s in namespace %s", gvr, namespaces[i])
+			}
+			return fmt.Errorf("cache sync failed for %s", gvr)
+		}
+	}
+
+	// Re-send objects whose events get dropped, from the informer caches.
+	m.mu.Lock()
+	entry.informers = informers
+	m.mu.Unlock()
+	go m.repairLoop(ctx, entry)
+
 	return nil
 }
 
 // Remove stops the watch for the given GVR and returns true. If the GVR
 // is not being watched, Remove returns false.
+//
+// Remove returns once no further event of the watch can be sent: events
+// waiting for room on the channel are given up, and those being sent are
+// waited for. Events already on the channel stay there.
 func (m *Mux) Remove(gvr schema.GroupVersionResource) bool {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	entry, ok := m.watches[gvr]
 	if !ok {
+		m.mu.Unlock()
 		return false
 	}
 	entry.cancel()
 	delete(m.watches, gvr)
+	m.mu.Unlock()
+
+	// Not under m.mu: a send in flight holds sendMu and needs m.mu to
+	// register with the WaitGroup.
+	entry.sendMu.Lock()
+	entry.removed = true
+	entry.sendMu.Unlock()
 	return true
 }
 
@@ -260,11 +401,31 @@ func (m *Mux) Stop() {
 	close(m.events)
 }
 
-// unwatch removes a GVR from the watch map under the write lock. Used
-// as cleanup when Add fails after the slot has been reserved.
-func (m *Mux) unwatch(gvr schema.GroupVersionResource) {
+// newInformer creates the informer of a watch in namespace ns, from a
+// factory of its own, and returns it with the factory's Start.
+func (m *Mux) newInformer(
+	gvr schema.GroupVersionResource, ns string, ac addConfig,
+) (cache.SharedIndexInformer, func(stopCh <-chan struct{})) {
+	if ac.metadataKind != "" {
+		factory := metadatainformer.NewFilteredSharedInformerFactory(
+			m.cfg.metadataClient, 0, ns, metadatainformer.TweakListOptionsFunc(m.listOptionsTweak(ac)),
+		)
+		return factory.ForResource(gvr).Informer(), factory.Start
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
+		m.client, 0, ns, m.listOptionsTweak(ac),
+	)
+	return factory.ForResource(gvr).Informer(), factory.Start
+}
+
+// unwatch removes a GVR from the watch map under the write lock, unless
+// it was removed and added again meanwhile. Used as cleanup when Add fails
+// after the slot has been reserved.
+func (m *Mux) unwatch(gvr schema.GroupVersionResource, entry *watchEntry) {
 	m.mu.Lock()
-	delete(m.watches, gvr)
+	if m.watches[gvr] == entry {
+		delete(m.watches, gvr)
+	}
 	m.mu.Unlock()
 }
 
@@ -280,50 +441,117 @@ func (m *Mux) tweakListOptions(lo *metav1.ListOptions) {
 	}
 }
 
+// listOptionsTweak returns the list options tweak of a single watch: the
+// Mux-wide selectors, joined with the ones given to Add.
+func (m *Mux) listOptionsTweak(ac addConfig) dynamicinformer.TweakListOptionsFunc {
+	return func(lo *metav1.ListOptions) {
+		m.tweakListOptions(lo)
+		lo.LabelSelector = joinSelectors(lo.LabelSelector, ac.labelSelector)
+		lo.FieldSelector = joinSelectors(lo.FieldSelector, ac.fieldSelector)
+	}
+}
+
+// joinSelectors ANDs two label or field selectors; either may be empty.
+func joinSelectors(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "," + b
+	}
+}
+
 // dispatch returns an informer event handler that converts the callback
 // argument into a [watch.Event] and sends it to the event channel. If
 // the channel is full and cannot accept within [eventDeliveryTimeout],
-// the event is dropped (informer cache is authoritative, so no data is
-// truly lost).
-func (m *Mux) dispatch(eventType watch.EventType) func(obj any) {
+// the event is dropped and counted, and the object is sent again later
+// with its latest state from the informer cache (see [Mux.Stats]).
+func (m *Mux) dispatch(w *watchEntry, eventType watch.EventType) func(obj any) {
 	return func(obj any) {
-		// Acquire a read-lock to register with the WaitGroup. This
-		// pairs with the write-lock in Stop: once Stop sets
-		// m.stopped=true and releases the lock, no new dispatch can
-		// call wg.Add, so the subsequent wg.Wait is safe.
-		m.mu.RLock()
-		if m.stopped {
-			m.mu.RUnlock()
+		ro, ok := toRuntimeObject(obj)
+		if !ok {
 			return
 		}
-		m.wg.Add(1)
-		m.mu.RUnlock()
-		defer m.wg.Done()
-
-		ro, ok := toRuntimeObject(obj)
+		key, ok := objectKey(ro)
 		if !ok {
 			return
 		}
 
-		event := watch.Event{Type: eventType, Object: ro}
+		m.deliver(w, key, watch.Event{Type: eventType, Object: w.object(ro)})
+	}
+}
 
-		// Fast path: non-blocking send avoids allocating a timer when
-		// the channel has capacity.
-		select {
-		case m.events <- event:
-			return
-		default:
-		}
+// deliver sends an event of watch [w] about the object with cache key
+// [key] and counts it, remembering it for repair when it is dropped.
+func (m *Mux) deliver(w *watchEntry, key string, event watch.Event) sendResult {
+	result := m.sendFor(w, event)
+	switch result {
+	case sendDelivered:
+		w.noteDelivered(key)
+	case sendDropped:
+		w.noteDropped(key, event)
+	}
+	return result
+}
 
-		// Channel full – apply backpressure with timeout.
-		timer := time.NewTimer(eventDeliveryTimeout)
-		defer timer.Stop()
+// sendResult tells what became of an event passed to send.
+type sendResult int
 
-		select {
-		case m.events <- event:
-		case <-timer.C:
-		case <-m.ctx.Done():
-		}
+const (
+	sendDelivered sendResult = iota
+	sendDropped              // the channel stayed full
+	sendStopped              // the Mux is stopping
+)
+
+// sendFor sends an event of watch [w], unless the watch was removed.
+func (m *Mux) sendFor(w *watchEntry, event watch.Event) sendResult {
+	w.sendMu.RLock()
+	defer w.sendMu.RUnlock()
+	if w.removed {
+		return sendStopped
+	}
+	return m.send(event, w.done)
+}
+
+// send delivers an event on the channel, waiting up to
+// [eventDeliveryTimeout] when it is full or until [done] is closed.
+func (m *Mux) send(event watch.Event, done <-chan struct{}) sendResult {
+	// Acquire a read-lock to register with the WaitGroup. This
+	// pairs with the write-lock in Stop: once Stop sets
+	// m.stopped=true and releases the lock, no new send can
+	// call wg.Add, so the subsequent wg.Wait is safe.
+	m.mu.RLock()
+	if m.stopped {
+		m.mu.RUnlock()
+		return sendStopped
+	}
+	m.wg.Add(1)
+	m.mu.RUnlock()
+	defer m.wg.Done()
+
+	// Fast path: non-blocking send avoids allocating a timer when
+	// the channel has capacity.
+	select {
+	case m.events <- event:
+		return sendDelivered
+	default:
+	}
+
+	// Channel full – apply backpressure with timeout.
+	timer := time.NewTimer(eventDeliveryTimeout)
+	defer timer.Stop()
+
+	select {
+	case m.events <- event:
+		return sendDelivered
+	case <-timer.C:
+		return sendDropped
+	case <-done:
+		return sendStopped
+	case <-m.ctx.Done():
+		return sendStopped
 	}
 }
 
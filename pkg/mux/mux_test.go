@@ -61,6 +61,12 @@ var gvrListKinds = map[schema.GroupVersionResource]string{
 // test completes.
 func newTestMux(t *testing.T, objects ...runtime.Object) (*Mux, *fakedynamic.FakeDynamicClient) {
 	t.Helper()
+	return newTestMuxWithOptions(t, nil, objects...)
+}
+
+// newTestMuxWithOptions is newTestMux with Mux options.
+func newTestMuxWithOptions(t *testing.T, opts []Option, objects ...runtime.Object) (*Mux, *fakedynamic.FakeDynamicClient) {
+	t.Helper()
 
 	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(
 		runtime.NewScheme(), gvrListKinds, objects...,
@@ -69,7 +75,7 @@ func newTestMux(t *testing.T, objects ...runtime.Object) (*Mux, *fakedynamic.Fak
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	t.Cleanup(cancel)
 
-	m, err := New(ctx, client)
+	m, err := New(ctx, client, opts...)
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -235,6 +241,54 @@ func TestAdd_DeliversInitialListEvents(t *testing.T) {
 	}
 }
 
+func TestAdd_WithNamespaces(t *testing.T) {
+	pods := []runtime.Object{
+		testPod("alpha", "team-a"),
+		testPod("bravo", "team-b"),
+		testPod("charlie", "kube-system"),
+	}
+	m, client := newTestMuxWithOptions(t, []Option{WithNamespaces("team-a", "team-b", "team-a", "")}, pods...)
+	if fmt.Sprint(m.cfg.namespaces) != "[team-a team-b]" {
+		t.Fatalf("namespaces: got %v", m.cfg.namespaces)
+	}
+
+	if err := m.Add(podGVR); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	names := eventNames(drainEvents(t, m.Events(), 2, 5*time.Second))
+	if fmt.Sprint(names) != "[alpha bravo]" {
+		t.Fatalf("names: got %v, want [alpha bravo]", names)
+	}
+
+	// Events from every namespace's informer reach the one channel.
+	if _, err := client.Resource(podGVR).Namespace("team-b").
+		Create(context.Background(), testPod("delta", "team-b"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if names := eventNames(drainEvents(t, m.Events(), 1, 5*time.Second)); names[0] != "delta" {
+		t.Fatalf("names: got %v, want [delta]", names)
+	}
+
+	select {
+	case ev := <-m.Events():
+		t.Fatalf("unexpected event for %v", eventNames([]watch.Event{ev}))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAdd_ClusterScopedIgnoresNamespaces(t *testing.T) {
+	pods := []runtime.Object{testPod("alpha", "team-a"), testPod("bravo", "kube-system")}
+	m, _ := newTestMuxWithOptions(t, []Option{WithNamespaces("team-a")}, pods...)
+
+	if err := m.Add(podGVR, ClusterScoped()); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	names := eventNames(drainEvents(t, m.Events(), 2, 5*time.Second))
+	if fmt.Sprint(names) != "[alpha bravo]" {
+		t.Fatalf("names: got %v, want [alpha bravo]", names)
+	}
+}
+
 func TestAdd_Idempotent(t *testing.T) {
 	m, _ := newTestMux(t, testPod("p1", "default"))
 
@@ -333,6 +387,29 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+// After Remove returns, only the events already on the channel are left:
+// sends waiting for room and the repair of dropped events are given up.
+func TestRemove_GivesUpPendingSends(t *testing.T) {
+	const repairInterval = 20 * time.Millisecond
+	m, _ := newTestMuxWithOptions(t, []Option{WithBuffer(1), withRepairInterval(repairInterval)},
+		testPod("alpha", "default"), testPod("bravo", "default"), testPod("charlie", "default"))
+	if err := m.Add(podGVR); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if !m.Remove(podGVR) {
+		t.Fatal("Remove: got false, want true")
+	}
+
+	if names := eventNames(drainEvents(t, m.Events(), 1, 5*time.Second)); names[0] != "alpha" {
+		t.Fatalf("buffered event: got %v, want alpha", names)
+	}
+	select {
+	case ev := <-m.Events():
+		t.Fatalf("event after Remove: %s %v", ev.Type, eventNames([]watch.Event{ev}))
+	case <-time.After(10 * repairInterval):
+	}
+}
+
 func TestRemove_Nonexistent(t *testing.T) {
 	m, _ := newTestMux(t)
 
@@ -815,3 +892,85 @@ func containsGVR(list []schema.GroupVersionResource, target schema.GroupVersionR
 	}
 	return false
 }
+
+func TestListOptionsTweak_JoinsPerWatchSelectors(t *testing.T) {
+	client := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	m, err := New(context.Background(), client, WithLabelSelector("tier=web"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop()
+
+	var ac addConfig
+	LabelSelector("app=api")(&ac)
+	FieldSelector("metadata.name=x")(&ac)
+	lo := &metav1.ListOptions{ResourceVersion: "42"}
+	m.listOptionsTweak(ac)(lo)
+
+	if lo.LabelSelector != "tier=web,app=api" {
+		t.Errorf("LabelSelector: got %q, want %q", lo.LabelSelector, "tier=web,app=api")
+	}
+	if lo.FieldSelector != "metadata.name=x" {
+		t.Errorf("FieldSelector: got %q, want %q", lo.FieldSelector, "metadata.name=x")
+	}
+	if lo.ResourceVersion != "42" {
+		t.Errorf("ResourceVersion was overwritten: got %q", lo.ResourceVersion)
+	}
+}
+
+func TestDispatch_DropsAreCountedAndRepaired(t *testing.T) {
+	pods := []runtime.Object{
+		testPod("alpha", "default"),
+		testPod("bravo", "default"),
+		testPod("charlie", "default"),
+		testPod("delta", "default"),
+	}
+	m, _ := newTestMuxWithOptions(t, []Option{WithBuffer(1), withRepairInterval(20 * time.Millisecond)}, pods...)
+	if err := m.Add(podGVR); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// Nobody reads: one event fits the buffer, the other three are dropped.
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Stats()[podGVR].Dropped < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats: got %+v, want 3 drops", m.Stats()[podGVR])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := m.Stats()[podGVR]; st.Pending != 3 || st.Delivered != 1 {
+		t.Fatalf("stats: got %+v, want 1 delivered and 3 pending", st)
+	}
+
+	// Reading again lets the repair loop re-send the dropped objects.
+	names := eventNames(drainEvents(t, m.Events(), 4, 5*time.Second))
+	if fmt.Sprint(names) != "[alpha bravo charlie delta]" {
+		t.Fatalf("names: got %v", names)
+	}
+	st := m.Stats()[podGVR]
+	if st.Repaired != 3 || st.Pending != 0 || st.Delivered != 4 {
+		t.Fatalf("stats after repair: got %+v", st)
+	}
+}
+
+func TestRepair_GoneObjectIsDeleted(t *testing.T) {
+	m, _ := newTestMux(t)
+	if err := m.Add(podGVR); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	m.mu.RLock()
+	w := m.watches[podGVR]
+	m.mu.RUnlock()
+
+	// The pod was dropped and deleted before it could be repaired.
+	w.noteDropped("default/gone", watch.Event{Type: watch.Modified, Object: testPod("gone", "default")})
+	m.repair(w)
+
+	ev := drainEvents(t, m.Events(), 1, 5*time.Second)[0]
+	if ev.Type != watch.Deleted || eventNames([]watch.Event{ev})[0] != "gone" {
+		t.Fatalf("got %s %v, want DELETED gone", ev.Type, eventNames([]watch.Event{ev}))
+	}
+	if st := m.Stats()[podGVR]; st.Pending != 0 || st.Repaired != 1 {
+		t.Fatalf("stats: got %+v", st)
+	}
+}
@@ -0,0 +1,173 @@
+package mux
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultRepairInterval is how often a watch re-sends the objects whose
+// events it had to drop.
+const defaultRepairInterval = time.Second
+
+// withRepairInterval sets how often the watches re-send dropped objects.
+// Values below 1ns are ignored and [defaultRepairInterval] is used.
+func withRepairInterval(d time.Duration) Option {
+	return func(c *muxConfig) {
+		c.repairInterval = d
+	}
+}
+
+// Stats counts the events of one watch.
+type Stats struct {
+	// Delivered is the number of events sent on the channel, repairs
+	// included.
+	Delivered uint64
+	// Dropped is the number of events dropped because the channel stayed
+	// full for longer than the delivery timeout.
+	Dropped uint64
+	// Repaired is the number of dropped objects that were sent again with
+	// their latest state from the informer cache.
+	Repaired uint64
+	// Pending is the number of dropped objects still waiting to be sent.
+	Pending int
+	// Replayed is the number of events replayed from the version given to
+	// [ResumeFrom].
+	Replayed uint64
+	// Resumed reports whether the watch replayed all changes since the
+	// version given to [ResumeFrom].
+	Resumed bool
+}
+
+// Stats returns the event counters of every current watch.
+func (m *Mux) Stats() map[schema.GroupVersionResource]Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make(map[schema.GroupVersionResource]Stats, len(m.watches))
+	for gvr, w := range m.watches {
+		w.pendingMu.Lock()
+		pending := len(w.pending)
+		w.pendingMu.Unlock()
+		out[gvr] = Stats{
+			Delivered: w.delivered.Load(),
+			Dropped:   w.dropped.Load(),
+			Repaired:  w.repaired.Load(),
+			Pending:   pending,
+			Replayed:  w.replayed.Load(),
+			Resumed:   w.resumed.Load(),
+		}
+	}
+	return out
+}
+
+// watchCounters holds the per-watch counters and the objects whose last
+// event was dropped, by cache key, until they are repaired.
+type watchCounters struct {
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	repaired  atomic.Uint64
+	replayed  atomic.Uint64
+	resumed   atomic.Bool
+
+	pendingMu sync.Mutex
+	pending   map[string]watch.Event
+}
+
+// noteDelivered forgets a pending drop of the object: the consumer now has
+// a newer state of it.
+func (w *watchCounters) noteDelivered(key string) {
+	w.delivered.Add(1)
+	w.pendingMu.Lock()
+	delete(w.pending, key)
+	w.pendingMu.Unlock()
+}
+
+// noteDropped remembers the dropped event so the object can be repaired.
+func (w *watchCounters) noteDropped(key string, ev watch.Event) {
+	w.dropped.Add(1)
+	w.pendingMu.Lock()
+	if w.pending == nil {
+		w.pending = make(map[string]watch.Event)
+	}
+	w.pending[key] = ev
+	w.pendingMu.Unlock()
+}
+
+// repairLoop periodically re-sends the objects of [w] whose events were
+// dropped, until [ctx] is done.
+func (m *Mux) repairLoop(ctx context.Context, w *watchEntry) {
+	ticker := time.NewTicker(m.cfg.repairInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.repair(w)
+		}
+	}
+}
+
+// repair sends the latest state of every pending object from the informer
+// caches: the object itself when it still exists, or a Deleted event for
+// the last state seen when it is gone. Objects that can't be delivered this
+// time stay pending.
+func (m *Mux) repair(w *watchEntry) {
+	w.pendingMu.Lock()
+	pending := make(map[string]watch.Event, len(w.pending))
+	for key, ev := range w.pending {
+		pending[key] = ev
+	}
+	w.pendingMu.Unlock()
+
+	for key, dropped := range pending {
+		ev := watch.Event{Type: watch.Deleted, Object: dropped.Object}
+		if obj, ok := w.cached(key); ok {
+			ev = watch.Event{Type: dropped.Type, Object: w.object(obj)}
+			if dropped.Type == watch.Deleted {
+				ev.Type = watch.Added // deleted and created again
+			}
+		}
+
+		switch m.sendFor(w, ev) {
+		case sendDelivered:
+			w.pendingMu.Lock()
+			// A newer drop replaced this one meanwhile; repair that next time.
+			if cur, ok := w.pending[key]; ok && cur.Object == dropped.Object {
+				delete(w.pending, key)
+			}
+			w.pendingMu.Unlock()
+			w.delivered.Add(1)
+			w.repaired.Add(1)
+		case sendStopped:
+			return
+		}
+	}
+}
+
+// cached looks the object up in the caches of the watch's informers.
+func (w *watchEntry) cached(key string) (runtime.Object, bool) {
+	for _, inf := range w.informers {
+		item, exists, err := inf.GetStore().GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		if ro, ok := item.(runtime.Object); ok {
+			return ro, true
+		}
+	}
+	return nil, false
+}
+
+// objectKey returns the informer cache key of an event object.
+func objectKey(obj runtime.Object) (string, bool) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	return key, err == nil
+}
@@ -0,0 +1,225 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultResumeIdle is how long a replay waits for further changes before
+// it takes itself as caught up: the API server sends the changes it still
+// keeps since the resume version right away.
+const defaultResumeIdle = time.Second
+
+// withResumeIdle sets how long replays wait for further changes. Values
+// below 1ns are ignored and [defaultResumeIdle] is used.
+func withResumeIdle(d time.Duration) Option {
+	return func(c *muxConfig) {
+		c.resumeIdle = d
+	}
+}
+
+// ResumeFrom replays the changes made to the watched objects since
+// resourceVersion, e.g. the one of the last event a previous run handled,
+// before the initial list: objects changed and
+// deleted meanwhile are delivered with their intermediate states and their
+// real Deleted events. The initial list follows as usual, so consumers see
+// the objects that didn't change again.
+//
+// When the server no longer keeps the changes since then (410 Gone, once
+// etcd compacted them), nothing more is replayed; [Stats.Resumed] tells
+// whether the replay was complete.
+func ResumeFrom(resourceVersion string) AddOption {
+	return func(c *addConfig) {
+		c.resumeFrom = resourceVersion
+	}
+}
+
+// Objects returns the objects of a watch as its informer caches hold them,
+// in the form the watch delivers them. It is nil when the GVR is not
+// watched or not synced yet.
+func (m *Mux) Objects(gvr schema.GroupVersionResource) []runtime.Object {
+	m.mu.RLock()
+	w, ok := m.watches[gvr]
+	var informers []cache.SharedIndexInformer
+	if ok {
+		informers = w.informers
+	}
+	m.mu.RUnlock()
+	var out []runtime.Object
+	for _, inf := range informers {
+		for _, item := range inf.GetStore().List() {
+			if ro, ok := item.(runtime.Object); ok {
+				out = append(out, w.object(ro))
+			}
+		}
+	}
+	return out
+}
+
+// ReplayedDeletion reports whether the replay of a watch (see [ResumeFrom])
+// delivered the Deleted event of the object [uid]. Consumers that compare
+// the objects of a watch with what they knew before can leave those
+// deletions to the event.
+func (m *Mux) ReplayedDeletion(gvr schema.GroupVersionResource, uid types.UID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.watches[gvr]
+	return ok && w.informers != nil && w.replayDeleted[uid]
+}
+
+// replay delivers the changes made to the objects of watch [w] in namespace
+// [ns] since [ac.resumeFrom], up to the current resourceVersion, and reports
+// whether all of them were.
+func (m *Mux) replay(
+	ctx context.Context, gvr schema.GroupVersionResource, ns string, ac addConfig, w *watchEntry,
+) bool {
+	opts := metav1.ListOptions{}
+	m.listOptionsTweak(ac)(&opts)
+
+	// Later changes come with the initial list.
+	until, err := m.listResourceVersion(ctx, gvr, ns, ac, opts)
+	if err != nil {
+		return false
+	}
+	if until == ac.resumeFrom {
+		return true
+	}
+
+	opts.ResourceVersion = ac.resumeFrom
+	opts.AllowWatchBookmarks = true
+	var wi watch.Interface
+	if ac.metadataKind != "" {
+		wi, err = m.cfg.metadataClient.Resource(gvr).Namespace(ns).Watch(ctx, opts)
+	} else {
+		wi, err = m.client.Resource(gvr).Namespace(ns).Watch(ctx, opts)
+	}
+	if err != nil {
+		return false
+	}
+	defer wi.Stop()
+
+	idle := time.NewTimer(m.cfg.resumeIdle)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-idle.C:
+			return true
+		case ev, ok := <-wi.ResultChan():
+			if !ok {
+				return false
+			}
+			if ev.Type == watch.Error {
+				// Typically 410 Gone: the changes since then are compacted.
+				return false
+			}
+			cmp := CompareResourceVersions(objectResourceVersion(ev.Object), until)
+			if ev.Type == watch.Bookmark || cmp > 0 {
+				if cmp >= 0 {
+					return true
+				}
+				break
+			}
+			if key, ok := objectKey(ev.Object); ok {
+				if m.deliver(w, key, watch.Event{Type: ev.Type, Object: w.object(ev.Object)}) == sendStopped {
+					return false
+				}
+				w.replayed.Add(1)
+				if accessor, err := meta.Accessor(ev.Object); err == nil && ev.Type == watch.Deleted {
+					if w.replayDeleted == nil {
+						w.replayDeleted = make(map[types.UID]bool)
+					}
+					w.replayDeleted[accessor.GetUID()] = true
+				}
+			}
+			if cmp == 0 {
+				return true
+			}
+		}
+		idle.Reset(m.cfg.resumeIdle)
+	}
+}
+
+// listResourceVersion returns the current resourceVersion of the objects of
+// a watch in namespace [ns], listing a single one with the client the watch
+// uses.
+func (m *Mux) listResourceVersion(
+	ctx context.Context, gvr schema.GroupVersionResource, ns string, ac addConfig, opts metav1.ListOptions,
+) (string, error) {
+	opts.Limit = 1
+	if ac.metadataKind != "" {
+		list, err := m.cfg.metadataClient.Resource(gvr).Namespace(ns).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		return list.GetResourceVersion(), nil
+	}
+	list, err := m.client.Resource(gvr).Namespace(ns).List(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return list.GetResourceVersion(), nil
+}
+
+// Get fetches an object of a watch from the API server with the client the
+// watch uses, in the form the watch delivers it: only its metadata for a
+// watch added with [MetadataOnly].
+func (m *Mux) Get(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (runtime.Object, error) {
+	m.mu.RLock()
+	w, ok := m.watches[gvr]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("GVR '%s' is not watched", gvr)
+	}
+	if w.metadataGVK.Kind != "" {
+		obj, err := m.cfg.metadataClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return w.object(obj), nil
+	}
+	obj, err := m.client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// objectResourceVersion returns the resourceVersion of an event object.
+func objectResourceVersion(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
+
+// CompareResourceVersions compares two resourceVersions like [cmp.Compare].
+// They are opaque, but those of etcd are increasing integers; others, and
+// empty ones, only compare equal, and are otherwise taken as older.
+func CompareResourceVersions(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case a == b:
+		return 0
+	case errA != nil || errB != nil:
+		return -1
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
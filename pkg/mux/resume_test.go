@@ -0,0 +1,156 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func versionedPod(name, rv string) *unstructured.Unstructured {
+	pod := testPod(name, "default")
+	pod.SetUID(types.UID("uid-" + name))
+	pod.SetResourceVersion(rv)
+	return pod
+}
+
+// resumeMux is a Mux whose pods are listed at resourceVersion 20 and whose
+// watches from resourceVersion 5 deliver [replayed].
+func resumeMux(t *testing.T, replayed ...watch.Event) *Mux {
+	t.Helper()
+	m, client := newTestMuxWithOptions(t, []Option{withResumeIdle(50 * time.Millisecond)})
+	client.PrependReactor("list", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*versionedPod("alpha", "12")}}
+		list.SetResourceVersion("20")
+		return true, list, nil
+	})
+	client.PrependWatchReactor("pods", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		if action.(clienttesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion != "5" {
+			return false, nil, nil
+		}
+		w := watch.NewFakeWithChanSize(len(replayed), false)
+		for _, ev := range replayed {
+			w.Action(ev.Type, ev.Object)
+		}
+		return true, w, nil
+	})
+
+	if err := m.Add(podGVR, ResumeFrom("5")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	return m
+}
+
+func TestAdd_ResumeFromReplaysChanges(t *testing.T) {
+	m := resumeMux(t,
+		watch.Event{Type: watch.Modified, Object: versionedPod("alpha", "12")},
+		watch.Event{Type: watch.Deleted, Object: versionedPod("bravo", "15")},
+		watch.Event{Type: watch.Bookmark, Object: versionedPod("", "20")},
+	)
+
+	events := drainEvents(t, m.Events(), 3, 5*time.Second)
+	want := []struct {
+		typ  watch.EventType
+		name string
+	}{{watch.Modified, "alpha"}, {watch.Deleted, "bravo"}, {watch.Added, "alpha"}}
+	for i, w := range want {
+		if name := events[i].Object.(*unstructured.Unstructured).GetName(); events[i].Type != w.typ || name != w.name {
+			t.Errorf("event %d = %s %s, want %s %s", i, events[i].Type, name, w.typ, w.name)
+		}
+	}
+	if st := m.Stats()[podGVR]; !st.Resumed || st.Replayed != 2 {
+		t.Errorf("stats = %+v, want resumed with 2 replayed", st)
+	}
+	if objs := m.Objects(podGVR); len(objs) != 1 {
+		t.Errorf("Objects = %v, want alpha", objs)
+	}
+	if !m.ReplayedDeletion(podGVR, "uid-bravo") || m.ReplayedDeletion(podGVR, "uid-alpha") {
+		t.Error("ReplayedDeletion doesn't tell the replayed deletion of bravo")
+	}
+}
+
+func TestAdd_ResumeFromCompacted(t *testing.T) {
+	gone := &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired}
+	m := resumeMux(t,
+		watch.Event{Type: watch.Error, Object: gone},
+		watch.Event{Type: watch.Modified, Object: versionedPod("alpha", "12")},
+	)
+
+	ev := drainEvents(t, m.Events(), 1, 5*time.Second)[0]
+	if ev.Type != watch.Added {
+		t.Errorf("first event = %s, want the ADDED of the initial list", ev.Type)
+	}
+	if st := m.Stats()[podGVR]; st.Resumed || st.Replayed != 0 {
+		t.Errorf("stats = %+v, want not resumed", st)
+	}
+}
+
+func TestCompareResourceVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"10", "9", 1},
+		{"10", "10", 0},
+		{"", "10", -1},
+		{"abc", "abc", 0},
+		{"abc", "10", -1},
+	} {
+		if got := CompareResourceVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareResourceVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestAdd_ResumeFromMetadataOnly(t *testing.T) {
+	meta := newTestMetadataClient(testSecretMetadata("token", "default", nil))
+	meta.PrependReactor("list", "secrets", func(clienttesting.Action) (bool, runtime.Object, error) {
+		list := &metav1.List{Items: []runtime.RawExtension{{Object: testSecretMetadata("token", "default", nil)}}}
+		list.ResourceVersion = "20"
+		return true, list, nil
+	})
+	meta.PrependWatchReactor("secrets", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		if action.(clienttesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion != "5" {
+			return false, nil, nil
+		}
+		gone := testSecretMetadata("old", "default", nil)
+		gone.UID = "uid-old"
+		w := watch.NewFakeWithChanSize(1, false)
+		w.Delete(gone)
+		return true, w, nil
+	})
+	m, client := newTestMuxWithOptions(t, []Option{WithMetadataClient(meta), withResumeIdle(50 * time.Millisecond)})
+	// Metadata-only watches must not read the full objects.
+	client.PrependReactor("*", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		t.Errorf("full %s of secrets", action.GetVerb())
+		return true, nil, errors.New("forbidden")
+	})
+
+	if err := m.Add(secretGVR, MetadataOnly("Secret"), ResumeFrom("5")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ev := drainEvents(t, m.Events(), 1, 5*time.Second)[0]
+	if u, ok := ev.Object.(*unstructured.Unstructured); ev.Type != watch.Deleted || !ok || u.GetKind() != "Secret" {
+		t.Errorf("first event = %s %#v, want the replayed deletion of a Secret", ev.Type, ev.Object)
+	}
+	if !m.ReplayedDeletion(secretGVR, "uid-old") {
+		t.Error("replayed deletion not remembered")
+	}
+
+	obj, err := m.Get(context.Background(), secretGVR, "default", "token")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if u, ok := obj.(*unstructured.Unstructured); !ok || u.GetKind() != "Secret" || u.Object["data"] != nil {
+		t.Errorf("Get = %#v, want the metadata of the Secret", obj)
+	}
+}